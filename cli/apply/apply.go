@@ -0,0 +1,122 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package apply
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/arduino/arduino-cli/cli/errorcodes"
+	"github.com/arduino/arduino-cli/cli/feedback"
+	"github.com/arduino/arduino-cloud-cli/command/apply"
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type applyFlags struct {
+	file   string
+	state  string
+	dryRun bool
+}
+
+// NewCommand creates a new `apply` command.
+func NewCommand() *cobra.Command {
+	flags := &applyFlags{}
+	applyCommand := &cobra.Command{
+		Use:   "apply",
+		Short: "Apply a fleet manifest",
+		Long: "Create and update the devices, things and dashboards described by a manifest file, " +
+			"recording their ids in a state file",
+		Example: "  " + os.Args[0] + " apply -f fleet.yaml",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runApplyCommand(flags); err != nil {
+				feedback.Errorf("Error during apply: %v", err)
+				os.Exit(errorcodes.ErrGeneric)
+			}
+		},
+	}
+	applyCommand.Flags().StringVarP(&flags.file, "file", "f", "",
+		"File containing the fleet manifest, JSON and YAML format are supported")
+	applyCommand.Flags().StringVar(&flags.state, "state", "",
+		"State file recording the ids of the applied resources. Default: manifest path with '.state.json' extension")
+	applyCommand.Flags().BoolVar(&flags.dryRun, "dry-run", false, "Print the plan without applying it")
+
+	applyCommand.MarkFlagRequired("file")
+	return applyCommand
+}
+
+func runApplyCommand(flags *applyFlags) error {
+	logrus.Infof("Applying manifest %s", flags.file)
+
+	cred, err := config.RetrieveCredentials()
+	if err != nil {
+		return fmt.Errorf("retrieving credentials: %w", err)
+	}
+
+	params := &apply.Params{
+		File:  flags.file,
+		State: flags.state,
+	}
+	plan, err := apply.NewPlan(context.TODO(), params, cred)
+	if err != nil {
+		return err
+	}
+
+	feedback.PrintResult(planResult{plan})
+	if flags.dryRun || plan.Count(apply.OpNone) == len(plan.Actions) {
+		return nil
+	}
+
+	if err = plan.Apply(context.TODO()); err != nil {
+		return err
+	}
+	feedback.Print("Manifest successfully applied")
+	return nil
+}
+
+type planResult struct {
+	plan *apply.Plan
+}
+
+func (r planResult) Data() interface{} {
+	return r.plan.Actions
+}
+
+func (r planResult) String() string {
+	var b strings.Builder
+	for _, a := range r.plan.Actions {
+		switch a.Operation {
+		case apply.OpCreate:
+			fmt.Fprintf(&b, "+ %s %s\n", a.Resource, a.Name)
+		case apply.OpUpdate:
+			fmt.Fprintf(&b, "~ %s %s (%s)\n", a.Resource, a.Name, a.ID)
+		default:
+			continue
+		}
+		for _, c := range a.Changes {
+			fmt.Fprintf(&b, "    %s\n", c)
+		}
+	}
+	fmt.Fprintf(&b, "Plan: %d to create, %d to update, %d unchanged.",
+		r.plan.Count(apply.OpCreate), r.plan.Count(apply.OpUpdate), r.plan.Count(apply.OpNone))
+	return b.String()
+}
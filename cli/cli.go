@@ -22,16 +22,22 @@ import (
 	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/arduino/arduino-cli/cli/errorcodes"
 	"github.com/arduino/arduino-cli/cli/feedback"
+	"github.com/arduino/arduino-cloud-cli/cli/apply"
 	"github.com/arduino/arduino-cloud-cli/cli/credentials"
 	"github.com/arduino/arduino-cloud-cli/cli/dashboard"
+	"github.com/arduino/arduino-cloud-cli/cli/dev"
 	"github.com/arduino/arduino-cloud-cli/cli/device"
+	"github.com/arduino/arduino-cloud-cli/cli/fleet"
 	"github.com/arduino/arduino-cloud-cli/cli/ota"
 	"github.com/arduino/arduino-cloud-cli/cli/template"
 	"github.com/arduino/arduino-cloud-cli/cli/thing"
 	"github.com/arduino/arduino-cloud-cli/cli/version"
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/arduino/arduino-cloud-cli/internal/httpclient"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -39,6 +45,9 @@ import (
 type cliFlags struct {
 	verbose      bool
 	outputFormat string
+	profile      string
+	httpTimeout  time.Duration
+	maxRetries   int
 }
 
 func Execute() {
@@ -59,6 +68,15 @@ func Execute() {
 	cli.PersistentFlags().StringVar(&flags.outputFormat, "format", "text",
 		fmt.Sprintf("The output format, can be: %s", strings.Join(validOutputFormats, ", ")),
 	)
+	cli.PersistentFlags().StringVar(&flags.profile, "profile", "",
+		fmt.Sprintf("The credentials profile to use. Can also be set with the %s environment variable", config.ProfileEnv),
+	)
+	cli.PersistentFlags().DurationVar(&flags.httpTimeout, "http-timeout", httpclient.DefaultTimeout,
+		"Timeout of each request to Arduino Cloud, 0 to disable it; firmware and file uploads are not affected",
+	)
+	cli.PersistentFlags().IntVar(&flags.maxRetries, "max-retries", httpclient.DefaultMaxRetries,
+		"Maximum number of retries of requests failed because of transient errors",
+	)
 
 	cli.AddCommand(version.NewCommand())
 	cli.AddCommand(credentials.NewCommand())
@@ -67,6 +85,9 @@ func Execute() {
 	cli.AddCommand(dashboard.NewCommand())
 	cli.AddCommand(ota.NewCommand())
 	cli.AddCommand(template.NewCommand())
+	cli.AddCommand(apply.NewCommand())
+	cli.AddCommand(fleet.NewCommand())
+	cli.AddCommand(dev.NewCommand())
 
 	if err := cli.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
@@ -101,5 +122,21 @@ func preRun(flags *cliFlags) error {
 	}
 	// use the output format to configure the Feedback
 	feedback.SetFormat(format)
+
+	// select the credentials profile, if passed
+	if flags.profile != "" {
+		if err := config.ValidateProfileName(flags.profile); err != nil {
+			return err
+		}
+		config.SetProfile(flags.profile)
+	}
+
+	if flags.httpTimeout < 0 {
+		return fmt.Errorf("invalid http timeout: %v", flags.httpTimeout)
+	}
+	if flags.maxRetries < 0 {
+		return fmt.Errorf("invalid max retries: %d", flags.maxRetries)
+	}
+	httpclient.Configure(flags.httpTimeout, flags.maxRetries)
 	return nil
 }
@@ -30,6 +30,10 @@ func NewCommand() *cobra.Command {
 
 	credentialsCommand.AddCommand(initInitCommand())
 	credentialsCommand.AddCommand(initFindCommand())
+	credentialsCommand.AddCommand(initListCommand())
+	credentialsCommand.AddCommand(initUseCommand())
+	credentialsCommand.AddCommand(initRemoveCommand())
+	credentialsCommand.AddCommand(initLogoutCommand())
 
 	return credentialsCommand
 }
@@ -46,11 +46,15 @@ func initFindCommand() *cobra.Command {
 func runFindCommand() error {
 	logrus.Info("Looking for credentials")
 
-	src, err := config.FindCredentials()
+	src, profile, err := config.FindCredentials()
 	if err != nil {
 		return err
 	}
 
-	feedback.Printf("Using credentials in: %s", src)
+	if profile == "" {
+		feedback.Printf("Using credentials in: %s", src)
+		return nil
+	}
+	feedback.Printf("Using credentials of profile '%s' in: %s", profile, src)
 	return nil
 }
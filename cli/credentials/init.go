@@ -27,6 +27,7 @@ import (
 	"github.com/arduino/arduino-cli/cli/feedback"
 	"github.com/arduino/arduino-cloud-cli/arduino"
 	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/arduino/arduino-cloud-cli/internal/secret"
 	"github.com/arduino/go-paths-helper"
 	"github.com/manifoldco/promptui"
 	"github.com/sirupsen/logrus"
@@ -35,9 +36,11 @@ import (
 )
 
 type initFlags struct {
-	destDir   string
-	overwrite bool
-	format    string
+	destDir     string
+	overwrite   bool
+	format      string
+	baseURL     string
+	secretStore string
 }
 
 func initInitCommand() *cobra.Command {
@@ -45,7 +48,8 @@ func initInitCommand() *cobra.Command {
 	initCommand := &cobra.Command{
 		Use:   "init",
 		Short: "Initialize a credentials file",
-		Long:  "Initialize an Arduino IoT Cloud CLI credentials file",
+		Long: "Initialize an Arduino IoT Cloud CLI credentials file.\n" +
+			"If a profile is selected with '--profile', the credentials are added to that profile of the file.",
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := runInitCommand(flags); err != nil {
 				feedback.Errorf("Error during credentials init: %v", err)
@@ -57,6 +61,10 @@ func initInitCommand() *cobra.Command {
 	initCommand.Flags().StringVar(&flags.destDir, "dest-dir", "", "Sets where to save the credentials file")
 	initCommand.Flags().BoolVar(&flags.overwrite, "overwrite", false, "Overwrite existing credentials file")
 	initCommand.Flags().StringVar(&flags.format, "file-format", "yaml", "Format of the credentials file, can be {yaml|json}")
+	initCommand.Flags().StringVar(&flags.baseURL, "base-url", "", "Base URL of Arduino Cloud API to be used with these credentials")
+	initCommand.Flags().StringVar(&flags.secretStore, "secret-store", secret.Plaintext,
+		fmt.Sprintf("Where to store the client secret, can be {%s}", strings.Join(secret.Stores(), "|")),
+	)
 
 	return initCommand
 }
@@ -94,8 +102,33 @@ func runInitCommand(flags *initFlags) error {
 		return fmt.Errorf("%s is not a valid directory", credPath)
 	}
 	credFile := credPath.Join(config.CredentialsFilename + "." + flags.format)
-	if !flags.overwrite && credFile.Exist() {
-		return fmt.Errorf("%s already exists, use '--overwrite' to overwrite it", credFile)
+
+	// When a profile is selected, the credentials are added to the profiles already stored in the file
+	profile := config.SelectedProfile()
+	var file *config.CredentialsFile
+	if profile == "" {
+		if !flags.overwrite && credFile.Exist() {
+			return fmt.Errorf("%s already exists, use '--overwrite' to overwrite it", credFile)
+		}
+	} else {
+		file = config.NewCredentialsFile(credFile.String())
+		if credFile.Exist() {
+			if file, err = config.LoadCredentialsFile(credFile.String()); err != nil {
+				return err
+			}
+		}
+		if _, found := file.Profiles[profile]; found && !flags.overwrite {
+			return fmt.Errorf("profile '%s' already exists in %s, use '--overwrite' to overwrite it", profile, credFile)
+		}
+	}
+
+	if flags.baseURL != "" {
+		if err := config.ValidateBaseURL(flags.baseURL); err != nil {
+			return err
+		}
+	}
+	if err := secret.ValidateStore(flags.secretStore); err != nil {
+		return err
 	}
 
 	// Take needed credentials starting an interactive mode
@@ -104,13 +137,45 @@ func runInitCommand(flags *initFlags) error {
 	if err != nil {
 		return fmt.Errorf("cannot take credentials params: %w", err)
 	}
+	cred := &config.Credentials{Client: id, Secret: key, Organization: org, BaseURL: flags.baseURL}
+
+	// Move the secret out of the credentials file, if requested
+	if flags.secretStore != secret.Plaintext {
+		store, err := config.SecretStore(flags.secretStore, credPath.String())
+		if err != nil {
+			return err
+		}
+		if err := store.Set(cred.Client, cred.Secret); err != nil {
+			return err
+		}
+		cred.Secret = ""
+		cred.SecretStore = flags.secretStore
+	}
 
 	// Write the credentials file
+	if profile != "" {
+		if err := file.Set(profile, cred); err != nil {
+			return err
+		}
+		if err := file.Write(); err != nil {
+			return err
+		}
+		feedback.Printf("Credentials profile '%s' successfully initialized at: %s", profile, credFile)
+		return nil
+	}
+
 	newSettings := viper.New()
 	newSettings.SetConfigPermissions(os.FileMode(0600))
-	newSettings.Set("client", id)
-	newSettings.Set("secret", key)
-	newSettings.Set("organization", org)
+	newSettings.Set("client", cred.Client)
+	if cred.SecretStore != "" {
+		newSettings.Set("secret_store", cred.SecretStore)
+	} else {
+		newSettings.Set("secret", cred.Secret)
+	}
+	newSettings.Set("organization", cred.Organization)
+	if cred.BaseURL != "" {
+		newSettings.Set("base_url", cred.BaseURL)
+	}
 	if err := newSettings.WriteConfigAs(credFile.String()); err != nil {
 		return fmt.Errorf("cannot write credentials file: %w", err)
 	}
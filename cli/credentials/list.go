@@ -0,0 +1,132 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package credentials
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/arduino/arduino-cli/cli/errorcodes"
+	"github.com/arduino/arduino-cli/cli/feedback"
+	"github.com/arduino/arduino-cli/table"
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/arduino/arduino-cloud-cli/internal/secret"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func initListCommand() *cobra.Command {
+	listCommand := &cobra.Command{
+		Use:   "list",
+		Short: "List credentials profiles",
+		Long:  "List the profiles of the credentials file that would be used in your current directory",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runListCommand(); err != nil {
+				feedback.Errorf("Error during credentials list: %v", err)
+				os.Exit(errorcodes.ErrGeneric)
+			}
+		},
+	}
+	return listCommand
+}
+
+func runListCommand() error {
+	logrus.Info("Listing credentials profiles")
+
+	file, err := loadCredentialsFile()
+	if err != nil {
+		return err
+	}
+
+	_, current, err := file.Resolve(config.SelectedProfile())
+	if err != nil {
+		// No profile would be used, don't highlight any of them
+		current = ""
+	}
+
+	profiles := make([]profileInfo, 0, len(file.Profiles))
+	for _, name := range file.Names() {
+		cred := file.Profiles[name]
+		profiles = append(profiles, profileInfo{
+			Name:         name,
+			Client:       cred.Client,
+			Organization: cred.Organization,
+			BaseURL:      cred.BaseURL,
+			SecretStore:  secretStore(cred),
+			Current:      name == current,
+		})
+	}
+
+	feedback.PrintResult(listResult{path: file.Path, profiles: profiles})
+	return nil
+}
+
+// loadCredentialsFile loads the credentials file
+// that would be used in the current directory.
+func loadCredentialsFile() (*config.CredentialsFile, error) {
+	path, found, err := config.FindCredentialsFile()
+	if err != nil {
+		return nil, fmt.Errorf("looking for credentials file: %w", err)
+	}
+	if !found {
+		return nil, errors.New("credentials file not found in the current directory, its parents or in arduino15")
+	}
+	return config.LoadCredentialsFile(path)
+}
+
+type profileInfo struct {
+	Name         string `json:"name"`
+	Client       string `json:"client"`
+	Organization string `json:"organization,omitempty"`
+	BaseURL      string `json:"base_url,omitempty"`
+	SecretStore  string `json:"secret_store"`
+	Current      bool   `json:"current"`
+}
+
+func secretStore(cred *config.Credentials) string {
+	if cred.SecretStore == "" {
+		return secret.Plaintext
+	}
+	return cred.SecretStore
+}
+
+type listResult struct {
+	path     string
+	profiles []profileInfo
+}
+
+func (r listResult) Data() interface{} {
+	return r.profiles
+}
+
+func (r listResult) String() string {
+	if len(r.profiles) == 0 {
+		return fmt.Sprintf("No profiles found in %s.", r.path)
+	}
+	t := table.New()
+	t.SetHeader("", "Profile", "Client ID", "Organization", "Base URL", "Secret Store")
+	for _, p := range r.profiles {
+		current := ""
+		if p.Current {
+			current = "*"
+		}
+		t.AddRow(current, p.Name, p.Client, p.Organization, p.BaseURL, p.SecretStore)
+	}
+	return fmt.Sprintf("Profiles in %s:\n%s", r.path, t.Render())
+}
@@ -0,0 +1,72 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package credentials
+
+import (
+	"os"
+
+	"github.com/arduino/arduino-cli/cli/errorcodes"
+	"github.com/arduino/arduino-cli/cli/feedback"
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/arduino/arduino-cloud-cli/internal/iot"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type logoutFlags struct {
+	all bool
+}
+
+func initLogoutCommand() *cobra.Command {
+	flags := &logoutFlags{}
+	logoutCommand := &cobra.Command{
+		Use:   "logout",
+		Short: "Purge cached access tokens",
+		Long:  "Purge the access tokens cached for the credentials in use, or for all the credentials",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runLogoutCommand(flags); err != nil {
+				feedback.Errorf("Error during credentials logout: %v", err)
+				os.Exit(errorcodes.ErrGeneric)
+			}
+		},
+	}
+	logoutCommand.Flags().BoolVarP(&flags.all, "all", "a", false, "Purge the cached tokens of all the credentials")
+	return logoutCommand
+}
+
+func runLogoutCommand(flags *logoutFlags) error {
+	if flags.all {
+		logrus.Info("Purging all cached tokens")
+		if err := iot.PurgeAllTokenCache(); err != nil {
+			return err
+		}
+		feedback.Print("All cached tokens have been purged")
+		return nil
+	}
+
+	logrus.Info("Purging cached token")
+	cred, err := config.RetrieveCredentials()
+	if err != nil {
+		return err
+	}
+	if err := iot.PurgeTokenCache(cred); err != nil {
+		return err
+	}
+	feedback.Print("Cached token has been purged")
+	return nil
+}
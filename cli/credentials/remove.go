@@ -0,0 +1,89 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package credentials
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/arduino/arduino-cli/cli/errorcodes"
+	"github.com/arduino/arduino-cli/cli/feedback"
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/arduino/arduino-cloud-cli/internal/secret"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func initRemoveCommand() *cobra.Command {
+	removeCommand := &cobra.Command{
+		Use:   "remove <profile>",
+		Short: "Remove a credentials profile",
+		Long:  "Remove a profile from the credentials file that would be used in your current directory",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runRemoveCommand(args[0]); err != nil {
+				feedback.Errorf("Error during credentials remove: %v", err)
+				os.Exit(errorcodes.ErrGeneric)
+			}
+		},
+	}
+	return removeCommand
+}
+
+func runRemoveCommand(profile string) error {
+	logrus.Infof("Removing credentials profile %s", profile)
+
+	file, err := loadCredentialsFile()
+	if err != nil {
+		return err
+	}
+	cred, name, err := file.Resolve(profile)
+	if err != nil {
+		return err
+	}
+	if err := file.Remove(name); err != nil {
+		return err
+	}
+	if err := file.Write(); err != nil {
+		return err
+	}
+	deleteSecret(file, cred)
+
+	feedback.Printf("Profile '%s' removed from %s", name, file.Path)
+	return nil
+}
+
+// deleteSecret removes the secret of the removed credentials from
+// their secret store, unless it's still used by another profile of the file.
+func deleteSecret(file *config.CredentialsFile, cred *config.Credentials) {
+	if cred.SecretStore == "" || cred.SecretStore == secret.Plaintext {
+		return
+	}
+	for _, other := range file.Profiles {
+		if other.Client == cred.Client && other.SecretStore == cred.SecretStore {
+			return
+		}
+	}
+	store, err := config.SecretStore(cred.SecretStore, filepath.Dir(file.Path))
+	if err == nil {
+		err = store.Delete(cred.Client)
+	}
+	if err != nil {
+		logrus.Warnf("Cannot delete secret of client %s from %s store: %v", cred.Client, cred.SecretStore, err)
+	}
+}
@@ -0,0 +1,61 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package credentials
+
+import (
+	"os"
+
+	"github.com/arduino/arduino-cli/cli/errorcodes"
+	"github.com/arduino/arduino-cli/cli/feedback"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func initUseCommand() *cobra.Command {
+	useCommand := &cobra.Command{
+		Use:   "use <profile>",
+		Short: "Set the default credentials profile",
+		Long:  "Set the default profile of the credentials file that would be used in your current directory",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runUseCommand(args[0]); err != nil {
+				feedback.Errorf("Error during credentials use: %v", err)
+				os.Exit(errorcodes.ErrGeneric)
+			}
+		},
+	}
+	return useCommand
+}
+
+func runUseCommand(profile string) error {
+	logrus.Infof("Setting %s as default credentials profile", profile)
+
+	file, err := loadCredentialsFile()
+	if err != nil {
+		return err
+	}
+	if err := file.Use(profile); err != nil {
+		return err
+	}
+	if err := file.Write(); err != nil {
+		return err
+	}
+
+	feedback.Printf("Default profile of %s set to '%s'", file.Path, file.DefaultProfile)
+	return nil
+}
@@ -32,6 +32,7 @@ func NewCommand() *cobra.Command {
 	dashboardCommand.AddCommand(initListCommand())
 	dashboardCommand.AddCommand(initDeleteCommand())
 	dashboardCommand.AddCommand(initExtractCommand())
+	dashboardCommand.AddCommand(initDiffCommand())
 
 	return dashboardCommand
 }
@@ -0,0 +1,71 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package dev
+
+import (
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/arduino/arduino-cli/cli/errorcodes"
+	"github.com/arduino/arduino-cli/cli/feedback"
+	"github.com/arduino/arduino-cloud-cli/internal/mockserver"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type mockServerFlags struct {
+	address string
+	client  string
+	secret  string
+}
+
+func initMockServerCommand() *cobra.Command {
+	flags := &mockServerFlags{}
+	mockServerCommand := &cobra.Command{
+		Use:   "mock-server",
+		Short: "Run a local mock of Arduino Cloud",
+		Long:  "Run a local server mocking the Arduino Cloud APIs used by the CLI, keeping its state in memory",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runMockServerCommand(flags); err != nil {
+				feedback.Errorf("Error during dev mock-server: %v", err)
+				os.Exit(errorcodes.ErrGeneric)
+			}
+		},
+	}
+	mockServerCommand.Flags().StringVarP(&flags.address, "address", "a", "localhost:8080", "Address the server listens on")
+	mockServerCommand.Flags().StringVar(&flags.client, "client", "", "Client ID accepted by the server, any client is accepted if empty")
+	mockServerCommand.Flags().StringVar(&flags.secret, "secret", "", "Client secret accepted by the server")
+	return mockServerCommand
+}
+
+func runMockServerCommand(flags *mockServerFlags) error {
+	srv := mockserver.New()
+	srv.Client = flags.client
+	srv.Secret = flags.secret
+
+	listener, err := net.Listen("tcp", flags.address)
+	if err != nil {
+		return err
+	}
+	url := "http://" + listener.Addr().String()
+	logrus.Infof("Mock server listening on %s", url)
+	feedback.Printf("Mock Arduino Cloud listening on %s", url)
+	feedback.Printf("Point the CLI to it with: export IOT_API_URL=%s IOT_API_MEDIA_URL=%s", url, url)
+	return http.Serve(listener, srv)
+}
@@ -34,6 +34,8 @@ func NewCommand() *cobra.Command {
 	deviceCommand.AddCommand(initProvisioningCommand())
 	deviceCommand.AddCommand(initListCommand())
 	deviceCommand.AddCommand(initShowCommand())
+	deviceCommand.AddCommand(initEventsCommand())
+	deviceCommand.AddCommand(initStatusHistoryCommand())
 	deviceCommand.AddCommand(initDeleteCommand())
 	deviceCommand.AddCommand(tag.InitCreateTagsCommand())
 	deviceCommand.AddCommand(tag.InitDeleteTagsCommand())
@@ -0,0 +1,133 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package device
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/arduino/arduino-cli/cli/errorcodes"
+	"github.com/arduino/arduino-cli/cli/feedback"
+	"github.com/arduino/arduino-cli/table"
+	"github.com/arduino/arduino-cloud-cli/command/device"
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/arduino/arduino-cloud-cli/internal/timeutil"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// defaultEventsSpan is the time range of the events
+// retrieved when the start of the range is not passed.
+const defaultEventsSpan = 24 * time.Hour
+
+type eventsFlags struct {
+	deviceID string
+	tags     map[string]string
+	from     string
+	to       string
+}
+
+// addEventsFlags adds the flags selecting devices
+// and a time range, shared by events and status-history.
+func addEventsFlags(cmd *cobra.Command, flags *eventsFlags) {
+	cmd.Flags().StringVarP(&flags.deviceID, "device-id", "d", "", "Device ID")
+	cmd.Flags().StringToStringVar(
+		&flags.tags,
+		"tags",
+		nil,
+		"Comma-separated list of tags with format <key>=<value>.\n"+
+			"Use all the devices that match the provided tags.\n"+
+			"Do not use this flag with --device-id.",
+	)
+	cmd.Flags().StringVar(&flags.from, "from", "", "Start of the time range, in RFC3339 format or as a YYYY-MM-DD date; defaults to 24 hours before its end")
+	cmd.Flags().StringVar(&flags.to, "to", "", "End of the time range, in RFC3339 format or as a YYYY-MM-DD date; defaults to now")
+}
+
+func (flags *eventsFlags) params() (*device.EventsParams, error) {
+	from, to, err := timeutil.ParseRange(flags.from, flags.to, defaultEventsSpan)
+	if err != nil {
+		return nil, err
+	}
+	return &device.EventsParams{
+		DeviceID: flags.deviceID,
+		Tags:     flags.tags,
+		From:     from,
+		To:       to,
+	}, nil
+}
+
+func initEventsCommand() *cobra.Command {
+	flags := &eventsFlags{}
+	eventsCommand := &cobra.Command{
+		Use:   "events",
+		Short: "List connection events of devices",
+		Long:  "List the connection and disconnection events of devices on Arduino IoT Cloud",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runEventsCommand(flags); err != nil {
+				feedback.Errorf("Error during device events: %v", err)
+				os.Exit(errorcodes.ErrGeneric)
+			}
+		},
+	}
+	addEventsFlags(eventsCommand, flags)
+	return eventsCommand
+}
+
+func runEventsCommand(flags *eventsFlags) error {
+	logrus.Info("Listing device events")
+
+	params, err := flags.params()
+	if err != nil {
+		return err
+	}
+
+	cred, err := config.RetrieveCredentials()
+	if err != nil {
+		return fmt.Errorf("retrieving credentials: %w", err)
+	}
+
+	events, err := device.Events(context.TODO(), params, cred)
+	if err != nil {
+		return err
+	}
+
+	feedback.PrintResult(eventsResult{events})
+	return nil
+}
+
+type eventsResult struct {
+	events []device.EventInfo
+}
+
+func (r eventsResult) Data() interface{} {
+	return r.events
+}
+
+func (r eventsResult) String() string {
+	if len(r.events) == 0 {
+		return "No events found."
+	}
+	t := table.New()
+	t.SetHeader("Time", "Device", "ID", "Event")
+	for _, e := range r.events {
+		t.AddRow(e.Time.Format(time.RFC3339), cleanStrings(e.DeviceName), e.DeviceID, e.Status)
+	}
+	return t.Render()
+}
@@ -0,0 +1,104 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package device
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/arduino/arduino-cli/cli/errorcodes"
+	"github.com/arduino/arduino-cli/cli/feedback"
+	"github.com/arduino/arduino-cli/table"
+	"github.com/arduino/arduino-cloud-cli/command/device"
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func initStatusHistoryCommand() *cobra.Command {
+	flags := &eventsFlags{}
+	statusHistoryCommand := &cobra.Command{
+		Use:   "status-history",
+		Short: "Show the connection history of devices",
+		Long:  "Show uptime, connections and last seen time of devices on Arduino IoT Cloud",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runStatusHistoryCommand(flags); err != nil {
+				feedback.Errorf("Error during device status-history: %v", err)
+				os.Exit(errorcodes.ErrGeneric)
+			}
+		},
+	}
+	addEventsFlags(statusHistoryCommand, flags)
+	return statusHistoryCommand
+}
+
+func runStatusHistoryCommand(flags *eventsFlags) error {
+	logrus.Info("Retrieving device status history")
+
+	params, err := flags.params()
+	if err != nil {
+		return err
+	}
+
+	cred, err := config.RetrieveCredentials()
+	if err != nil {
+		return fmt.Errorf("retrieving credentials: %w", err)
+	}
+
+	history, err := device.StatusHistory(context.TODO(), params, cred)
+	if err != nil {
+		return err
+	}
+
+	feedback.PrintResult(statusHistoryResult{history})
+	return nil
+}
+
+type statusHistoryResult struct {
+	history []device.StatusHistoryInfo
+}
+
+func (r statusHistoryResult) Data() interface{} {
+	return r.history
+}
+
+func (r statusHistoryResult) String() string {
+	if len(r.history) == 0 {
+		return "No devices found."
+	}
+	t := table.New()
+	t.SetHeader("Name", "ID", "Status", "Last seen", "Uptime", "Connections", "Disconnections")
+	for _, h := range r.history {
+		lastSeen := ""
+		if h.LastSeen != nil {
+			lastSeen = h.LastSeen.Format(time.RFC3339)
+		}
+		t.AddRow(
+			cleanStrings(h.DeviceName),
+			h.DeviceID,
+			dereferenceString(h.Status),
+			lastSeen,
+			fmt.Sprintf("%.2f%%", h.UptimePercent),
+			h.Connections,
+			h.Disconnections,
+		)
+	}
+	return t.Render()
+}
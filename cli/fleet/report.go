@@ -0,0 +1,185 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package fleet
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/arduino/arduino-cli/cli/errorcodes"
+	"github.com/arduino/arduino-cli/cli/feedback"
+	"github.com/arduino/arduino-cli/table"
+	"github.com/arduino/arduino-cloud-cli/command/fleet"
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// Report formats in addition to the ones of the --format flag.
+const (
+	reportFormatCSV  = "csv"
+	reportFormatHTML = "html"
+)
+
+type reportFlags struct {
+	tags         map[string]string
+	offlineDays  int
+	reportFormat string
+}
+
+func initReportCommand() *cobra.Command {
+	flags := &reportFlags{}
+	reportCommand := &cobra.Command{
+		Use:   "report",
+		Short: "Report the state of the fleet",
+		Long:  "Summarize status, firmware and OTA state of the devices on Arduino IoT Cloud, grouped by FQBN",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runReportCommand(flags); err != nil {
+				feedback.Errorf("Error during fleet report: %v", err)
+				os.Exit(errorcodes.ErrGeneric)
+			}
+		},
+	}
+	reportCommand.Flags().StringToStringVar(
+		&flags.tags,
+		"tags",
+		nil,
+		"Comma-separated list of tags with format <key>=<value>.\n"+
+			"Report only devices that match the provided tags.",
+	)
+	reportCommand.Flags().IntVar(&flags.offlineDays, "offline-days", fleet.DefaultOfflineDays, "Report devices not seen for more than this number of days as offline")
+	reportCommand.Flags().StringVar(
+		&flags.reportFormat,
+		"report-format",
+		"",
+		"Write the report as csv or html to the standard output, instead of using --format",
+	)
+	return reportCommand
+}
+
+func runReportCommand(flags *reportFlags) error {
+	logrus.Info("Building fleet report")
+
+	if flags.reportFormat != "" && flags.reportFormat != reportFormatCSV && flags.reportFormat != reportFormatHTML {
+		return fmt.Errorf("invalid report format: %s", flags.reportFormat)
+	}
+	if flags.offlineDays < 0 {
+		return fmt.Errorf("invalid number of offline days: %d", flags.offlineDays)
+	}
+
+	cred, err := config.RetrieveCredentials()
+	if err != nil {
+		return fmt.Errorf("retrieving credentials: %w", err)
+	}
+
+	params := &fleet.ReportParams{Tags: flags.tags, OfflineDays: flags.offlineDays}
+	report, err := fleet.BuildReport(context.TODO(), params, cred)
+	if err != nil {
+		return err
+	}
+
+	switch flags.reportFormat {
+	case reportFormatCSV:
+		return fleet.WriteCSV(os.Stdout, report)
+	case reportFormatHTML:
+		return fleet.WriteHTML(os.Stdout, report)
+	}
+	feedback.PrintResult(reportResult{report})
+	return nil
+}
+
+type reportResult struct {
+	report *fleet.Report
+}
+
+func (r reportResult) Data() interface{} {
+	return r.report
+}
+
+func (r reportResult) String() string {
+	if len(r.report.Groups) == 0 {
+		return "No devices found."
+	}
+
+	var b strings.Builder
+	t := table.New()
+	t.SetHeader("FQBN", "Devices", "Statuses")
+	for _, g := range r.report.Groups {
+		t.AddRow(g.FQBN, g.Count, formatStatuses(g.Statuses))
+	}
+	b.WriteString(t.Render())
+
+	t = table.New()
+	t.SetHeader("Name", "ID", "FQBN", "Status", "Last seen", "Thing", "Firmware SHA-256", "Last OTA")
+	for _, g := range r.report.Groups {
+		for _, d := range g.Devices {
+			lastSeen := ""
+			if d.LastSeen != nil {
+				lastSeen = d.LastSeen.Format("2006-01-02 15:04")
+			}
+			lastOta := ""
+			if d.LastOta != nil {
+				lastOta = d.LastOta.Status
+				if d.LastOta.ErrorReason != "" {
+					lastOta += ": " + d.LastOta.ErrorReason
+				}
+			}
+			t.AddRow(d.Name, d.ID, d.FQBN, d.Status, lastSeen, d.ThingID, shortHash(d.FirmwareHash), lastOta)
+		}
+	}
+	b.WriteString("\n" + t.Render())
+
+	fmt.Fprintf(&b, "\nDevices without a thing: %s", deviceNames(r.report.Unbound))
+	fmt.Fprintf(&b, "\nDevices offline for more than %d days: %s", r.report.OfflineDays, deviceNames(r.report.Offline))
+	return b.String()
+}
+
+func formatStatuses(statuses map[string]int) string {
+	keys := make([]string, 0, len(statuses))
+	for k := range statuses {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	s := make([]string, 0, len(keys))
+	for _, k := range keys {
+		s = append(s, fmt.Sprintf("%s: %d", k, statuses[k]))
+	}
+	return strings.Join(s, ", ")
+}
+
+// shortHash abbreviates firmware hashes to keep the table readable.
+func shortHash(h string) string {
+	if len(h) > 12 {
+		return h[:12]
+	}
+	return h
+}
+
+func deviceNames(devices []fleet.DeviceReport) string {
+	if len(devices) == 0 {
+		return "none"
+	}
+	names := make([]string, 0, len(devices))
+	for _, d := range devices {
+		names = append(names, d.Name)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,96 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ota
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/arduino/arduino-cli/cli/errorcodes"
+	"github.com/arduino/arduino-cli/cli/feedback"
+	"github.com/arduino/arduino-cli/table"
+	"github.com/arduino/arduino-cloud-cli/command/ota"
+	"github.com/spf13/cobra"
+)
+
+type deltaFlags struct {
+	from    string
+	to      string
+	out     string
+	fqbn    string
+	signKey string
+}
+
+func initDeltaCommand() *cobra.Command {
+	flags := &deltaFlags{}
+	deltaCommand := &cobra.Command{
+		Use:   "delta",
+		Short: "Generate a delta OTA",
+		Long:  "Generate an OTA containing only the differences between two firmware binaries",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runDeltaCommand(flags); err != nil {
+				feedback.Errorf("Error during ota delta: %v", err)
+				os.Exit(errorcodes.ErrGeneric)
+			}
+		},
+	}
+	deltaCommand.Flags().StringVar(&flags.from, "from", "", "Binary file (.bin) currently running on the devices")
+	deltaCommand.Flags().StringVar(&flags.to, "to", "", "Binary file (.bin) to be installed on the devices")
+	deltaCommand.Flags().StringVar(&flags.out, "out", "", "Delta OTA file (.ota) to be generated")
+	deltaCommand.Flags().StringVarP(&flags.fqbn, "fqbn", "b", "", "FQBN of the devices to update")
+	deltaCommand.Flags().StringVar(&flags.signKey, "sign-key", "",
+		"Private key (PEM, ECDSA or Ed25519) used to sign the patch. The signature is written to '<out-file>.sig'")
+	deltaCommand.MarkFlagRequired("from")
+	deltaCommand.MarkFlagRequired("to")
+	deltaCommand.MarkFlagRequired("out")
+	deltaCommand.MarkFlagRequired("fqbn")
+	return deltaCommand
+}
+
+func runDeltaCommand(flags *deltaFlags) error {
+	params := &ota.DeltaParams{
+		From:    flags.from,
+		To:      flags.to,
+		Out:     flags.out,
+		FQBN:    flags.fqbn,
+		SignKey: flags.signKey,
+	}
+	res, err := ota.Delta(params)
+	if err != nil {
+		return err
+	}
+
+	feedback.PrintResult(deltaResult{res})
+	return nil
+}
+
+type deltaResult struct {
+	res *ota.DeltaResult
+}
+
+func (r deltaResult) Data() interface{} {
+	return r.res
+}
+
+func (r deltaResult) String() string {
+	t := table.New()
+	t.SetHeader("File", "Full OTA size", "Delta OTA size", "Saving")
+	saving := 100 * (1 - float64(r.res.DeltaSize)/float64(r.res.FullSize))
+	t.AddRow(r.res.File, fmt.Sprintf("%d bytes", r.res.FullSize), fmt.Sprintf("%d bytes", r.res.DeltaSize), fmt.Sprintf("%.1f%%", saving))
+	return t.Render()
+}
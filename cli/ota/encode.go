@@ -24,12 +24,14 @@ import (
 	"github.com/arduino/arduino-cli/cli/errorcodes"
 	"github.com/arduino/arduino-cli/cli/feedback"
 	"github.com/arduino/arduino-cloud-cli/command/ota"
+	inota "github.com/arduino/arduino-cloud-cli/internal/ota"
 	"github.com/spf13/cobra"
 )
 
 type encodeBinaryFlags struct {
-	FQBN string
-	file string
+	FQBN    string
+	file    string
+	signKey string
 }
 
 func initEncodeBinaryCommand() *cobra.Command {
@@ -47,6 +49,8 @@ func initEncodeBinaryCommand() *cobra.Command {
 	}
 	uploadCommand.Flags().StringVarP(&flags.FQBN, "fqbn", "b", "", "Device fqbn")
 	uploadCommand.Flags().StringVarP(&flags.file, "file", "", "", "Binary file (.bin) to be encoded")
+	uploadCommand.Flags().StringVar(&flags.signKey, "sign-key", "",
+		"Private key (PEM, ECDSA or Ed25519) used to sign the firmware. The signature is written to '<ota-file>.sig'")
 	uploadCommand.MarkFlagRequired("fqbn")
 	uploadCommand.MarkFlagRequired("file")
 	return uploadCommand
@@ -54,8 +58,9 @@ func initEncodeBinaryCommand() *cobra.Command {
 
 func runEncodeCommand(flags *encodeBinaryFlags) error {
 	params := &ota.EncodeParams{
-		FQBN: flags.FQBN,
-		File: flags.file,
+		FQBN:    flags.FQBN,
+		File:    flags.file,
+		SignKey: flags.signKey,
 	}
 	otafile, err := ota.Encode(params)
 	if err != nil {
@@ -63,6 +68,9 @@ func runEncodeCommand(flags *encodeBinaryFlags) error {
 	}
 
 	feedback.Print(fmt.Sprintf("Encode successfully performed. File: %s", *otafile))
+	if flags.signKey != "" {
+		feedback.Print(fmt.Sprintf("Signature: %s", inota.SignatureFile(*otafile)))
+	}
 
 	return nil
 }
@@ -21,13 +21,16 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"sort"
+	"time"
 
 	"github.com/arduino/arduino-cli/cli/errorcodes"
 	"github.com/arduino/arduino-cli/cli/feedback"
 	"github.com/arduino/arduino-cli/table"
 	"github.com/arduino/arduino-cloud-cli/command/ota"
 	"github.com/arduino/arduino-cloud-cli/config"
+	otaapi "github.com/arduino/arduino-cloud-cli/internal/ota-api"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -39,6 +42,15 @@ type massUploadFlags struct {
 	deferred         bool
 	fqbn             string
 	doNotApplyHeader bool
+	wait             bool
+	timeout          time.Duration
+	successTags      map[string]string
+	verifyKey        string
+	journal          string
+	resume           string
+	concurrency      int
+	rate             float64
+	skipSameVersion  bool
 }
 
 func initMassUploadCommand() *cobra.Command {
@@ -65,12 +77,36 @@ func initMassUploadCommand() *cobra.Command {
 	massUploadCommand.Flags().BoolVar(&flags.deferred, "deferred", false, "Perform a deferred OTA. It can take up to 1 week.")
 	massUploadCommand.Flags().StringVarP(&flags.fqbn, "fqbn", "b", "", "FQBN of the devices to update")
 	massUploadCommand.Flags().BoolVar(&flags.doNotApplyHeader, "no-header", false, "Do not apply header and compression to binary file before upload")
+	massUploadCommand.Flags().BoolVar(&flags.wait, "wait", false, "Wait for the OTAs to end, showing their progress")
+	massUploadCommand.Flags().DurationVar(&flags.timeout, "timeout", ota.DefaultWaitTimeout, "Maximum time waited for the OTAs to end, used with --wait")
+	massUploadCommand.Flags().StringToStringVar(&flags.successTags, "tag-on-success", nil,
+		"Comma-separated list of tags with format <key>=<value>, applied to each device once its OTA succeeded.\n"+
+			"The firmware_sha256 tag is always applied. Implies '--wait'.",
+	)
+	massUploadCommand.Flags().StringVar(&flags.journal, "journal", ota.DefaultJournalFile,
+		"File where the progress of the upload is recorded, to resume it if interrupted")
+	massUploadCommand.Flags().StringVar(&flags.resume, "resume", "",
+		"Resume the upload recorded in the given journal, retrying only devices whose OTA failed or was not sent.\n"+
+			"Do not use this flag with '--device-ids' or '--device-tags'.",
+	)
+	massUploadCommand.Flags().IntVar(&flags.concurrency, "concurrency", ota.DefaultConcurrency, "Maximum number of OTA uploads performed at the same time")
+	massUploadCommand.Flags().Float64Var(&flags.rate, "rate", 0, "Maximum number of OTA uploads started per second. Unlimited if 0")
+	massUploadCommand.Flags().StringVar(&flags.verifyKey, "verify-key", "",
+		"Public key (PEM) verifying the signature of the firmware, read from '<file>.sig'.\n"+
+			"The firmware is not uploaded if its signature is not valid.",
+	)
+	massUploadCommand.Flags().BoolVar(&flags.skipSameVersion, "skip-same-version", false,
+		"Skip devices already running the version declared in the firmware manifest")
 	massUploadCommand.MarkFlagRequired("file")
 	massUploadCommand.MarkFlagRequired("fqbn")
 	return massUploadCommand
 }
 
 func runMassUploadCommand(flags *massUploadFlags) error {
+	// Devices can be tagged only once their OTA is confirmed successful
+	if flags.successTags != nil {
+		flags.wait = true
+	}
 	logrus.Infof("Uploading binary %s", flags.file)
 
 	params := &ota.MassUploadParams{
@@ -80,6 +116,24 @@ func runMassUploadCommand(flags *massUploadFlags) error {
 		Deferred:         flags.deferred,
 		FQBN:             flags.fqbn,
 		DoNotApplyHeader: flags.doNotApplyHeader,
+		Wait:             flags.wait,
+		Timeout:          flags.timeout,
+		SuccessTags:      flags.successTags,
+		VerifyKey:        flags.verifyKey,
+		Journal:          flags.journal,
+		Concurrency:      flags.concurrency,
+		Rate:             flags.rate,
+		SkipSameVersion:  flags.skipSameVersion,
+	}
+	if flags.concurrency < 1 {
+		return fmt.Errorf("concurrency must be at least 1")
+	}
+	if flags.resume != "" {
+		if flags.deviceIDs != nil || flags.tags != nil {
+			return fmt.Errorf("devices to update are read from the journal when resuming")
+		}
+		params.Journal = flags.resume
+		params.Resume = true
 	}
 
 	cred, err := config.RetrieveCredentials()
@@ -87,8 +141,12 @@ func runMassUploadCommand(flags *massUploadFlags) error {
 		return fmt.Errorf("retrieving credentials: %w", err)
 	}
 
-	resp, err := ota.MassUpload(context.TODO(), params, cred)
-	if err != nil {
+	// On interrupt, no more uploads are started and the ones already started are shown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	resp, err := ota.MassUpload(ctx, params, cred)
+	if resp == nil {
 		return err
 	}
 
@@ -97,8 +155,30 @@ func runMassUploadCommand(flags *massUploadFlags) error {
 		return resp[i].Err == nil
 	})
 
-	feedback.PrintResult(massUploadResult{resp})
-	return nil
+	if !flags.wait {
+		feedback.PrintResult(massUploadResult{resp})
+		return err
+	}
+
+	// Devices that failed validation or upload are part of the summary too
+	summary := ota.WaitSummary{}
+	for _, r := range resp {
+		o := r.OtaStatus
+		o.DeviceID = r.ID
+		switch {
+		case r.Err != nil:
+			o.Status, o.ErrorReason = otaapi.StatusFailed, r.Err.Error()
+		case o.Status == "":
+			// The firmware has been sent, but the OTA status was never retrieved
+			o.Status = ota.StatusUnknown
+		}
+		summary.Otas = append(summary.Otas, o)
+	}
+	feedback.PrintResult(summary)
+	if err != nil {
+		return err
+	}
+	return summary.Err()
 }
 
 type massUploadResult struct {
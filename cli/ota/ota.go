@@ -30,9 +30,12 @@ func NewCommand() *cobra.Command {
 
 	otaCommand.AddCommand(initUploadCommand())
 	otaCommand.AddCommand(initMassUploadCommand())
+	otaCommand.AddCommand(initRolloutCommand())
 	otaCommand.AddCommand(initOtaStatusCommand())
 	otaCommand.AddCommand(initEncodeBinaryCommand())
 	otaCommand.AddCommand(initDecodeHeaderCommand())
+	otaCommand.AddCommand(initDeltaCommand())
+	otaCommand.AddCommand(initUnpackCommand())
 	otaCommand.AddCommand(initOtaCancelCommand())
 
 	return otaCommand
@@ -0,0 +1,192 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ota
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/arduino/arduino-cli/cli/errorcodes"
+	"github.com/arduino/arduino-cli/cli/feedback"
+	"github.com/arduino/arduino-cli/table"
+	"github.com/arduino/arduino-cloud-cli/command/ota"
+	"github.com/arduino/arduino-cloud-cli/config"
+	otaapi "github.com/arduino/arduino-cloud-cli/internal/ota-api"
+	"github.com/manifoldco/promptui"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type rolloutFlags struct {
+	deviceIDs        []string
+	tags             map[string]string
+	file             string
+	deferred         bool
+	fqbn             string
+	doNotApplyHeader bool
+	waves            []float64
+	haltFailureRate  float64
+	pauseFailureRate float64
+	waveTimeout      time.Duration
+	pollInterval     time.Duration
+	stateFile        string
+	resume           bool
+	force            bool
+	yes              bool
+}
+
+func initRolloutCommand() *cobra.Command {
+	flags := &rolloutFlags{}
+	rolloutCommand := &cobra.Command{
+		Use:   "rollout",
+		Short: "Staged OTA rollout",
+		Long: "OTA upload on devices of Arduino IoT Cloud in waves of growing size.\n" +
+			"Each wave starts when the OTAs of the previous one are over and their failure rate is acceptable.",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runRolloutCommand(flags); err != nil {
+				feedback.Errorf("Error during ota rollout: %v", err)
+				os.Exit(errorcodes.ErrGeneric)
+			}
+		},
+	}
+	rolloutCommand.Flags().StringSliceVarP(&flags.deviceIDs, "device-ids", "d", nil,
+		"Comma-separated list of device IDs to update")
+	rolloutCommand.Flags().StringToStringVar(&flags.tags, "device-tags", nil,
+		"Comma-separated list of tags with format <key>=<value>.\n"+
+			"Perform an OTA upload on all devices that match the provided tags.\n"+
+			"Mutually exclusive with '--device-ids'.",
+	)
+	rolloutCommand.Flags().StringVarP(&flags.file, "file", "", "", "Binary file (.bin) to be uploaded")
+	rolloutCommand.Flags().BoolVar(&flags.deferred, "deferred", false, "Perform deferred OTAs. They can take up to 1 week.")
+	rolloutCommand.Flags().StringVarP(&flags.fqbn, "fqbn", "b", "", "FQBN of the devices to update")
+	rolloutCommand.Flags().BoolVar(&flags.doNotApplyHeader, "no-header", false, "Do not apply header and compression to binary file before upload")
+	rolloutCommand.Flags().Float64SliceVar(&flags.waves, "waves", ota.DefaultWaves,
+		"Comma-separated list of the cumulative percentages of devices updated at the end of each wave")
+	rolloutCommand.Flags().Float64Var(&flags.haltFailureRate, "halt-failure-rate", 20,
+		"Halt the rollout when the OTAs of a wave fail above this percentage")
+	rolloutCommand.Flags().Float64Var(&flags.pauseFailureRate, "pause-failure-rate", 5,
+		"Ask for confirmation before the next wave when the OTAs of a wave fail above this percentage")
+	rolloutCommand.Flags().DurationVar(&flags.waveTimeout, "wave-timeout", 0,
+		"Maximum time waited for the OTAs of a wave to end, e.g. 30m (default: OTA expiration)")
+	rolloutCommand.Flags().DurationVar(&flags.pollInterval, "poll-interval", ota.DefaultPollInterval,
+		"Interval between two checks of the OTA statuses")
+	rolloutCommand.Flags().StringVar(&flags.stateFile, "state", ota.DefaultRolloutStateFile, "File where the state of the rollout is saved")
+	rolloutCommand.Flags().BoolVar(&flags.resume, "resume", false, "Resume the rollout saved in the state file")
+	rolloutCommand.Flags().BoolVar(&flags.force, "force", false, "Resume the rollout even if it was halted")
+	rolloutCommand.Flags().BoolVarP(&flags.yes, "yes", "y", false, "Proceed with the next waves without asking for confirmation")
+	return rolloutCommand
+}
+
+func runRolloutCommand(flags *rolloutFlags) error {
+	if !flags.resume && (flags.file == "" || flags.fqbn == "") {
+		return fmt.Errorf("--file and --fqbn are required, unless resuming a rollout")
+	}
+	if flags.haltFailureRate < flags.pauseFailureRate {
+		return fmt.Errorf("--halt-failure-rate must not be lower than --pause-failure-rate")
+	}
+
+	params := &ota.RolloutParams{
+		DeviceIDs:        flags.deviceIDs,
+		Tags:             flags.tags,
+		File:             flags.file,
+		FQBN:             flags.fqbn,
+		Deferred:         flags.deferred,
+		DoNotApplyHeader: flags.doNotApplyHeader,
+		Waves:            flags.waves,
+		HaltFailureRate:  flags.haltFailureRate,
+		PauseFailureRate: flags.pauseFailureRate,
+		WaveTimeout:      flags.waveTimeout,
+		PollInterval:     flags.pollInterval,
+		StateFile:        flags.stateFile,
+		Resume:           flags.resume,
+		Force:            flags.force,
+		Confirm:          confirmWave,
+	}
+	if flags.yes {
+		params.Confirm = func(*ota.RolloutWave) bool { return true }
+	}
+
+	cred, err := config.RetrieveCredentials()
+	if err != nil {
+		return fmt.Errorf("retrieving credentials: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	state, err := ota.Rollout(ctx, params, cred)
+	if state != nil {
+		feedback.PrintResult(rolloutResult{state})
+	}
+	if err != nil {
+		return err
+	}
+
+	switch state.Status {
+	case ota.RolloutPaused:
+		logrus.Infof("Rollout paused: resume it with 'ota rollout --resume --state %s'", flags.stateFile)
+	case ota.RolloutHalted:
+		return fmt.Errorf("rollout halted: failure rate above %v%%", flags.haltFailureRate)
+	}
+	return nil
+}
+
+// confirmWave asks the user whether to proceed after a wave with failures.
+func confirmWave(wave *ota.RolloutWave) bool {
+	prompt := promptui.Prompt{
+		Label:     fmt.Sprintf("%.1f%% of the OTAs of the last wave failed. Proceed with the next wave", wave.FailureRate),
+		IsConfirm: true,
+	}
+	_, err := prompt.Run()
+	return err == nil
+}
+
+type rolloutResult struct {
+	state *ota.RolloutState
+}
+
+func (r rolloutResult) Data() interface{} {
+	return r.state
+}
+
+func (r rolloutResult) String() string {
+	t := table.New()
+	t.SetHeader("Wave", "Devices", "Succeeded", "Failed", "Failure rate", "Status")
+	for i, w := range r.state.Waves {
+		succeeded, failed := 0, 0
+		for _, d := range w.Devices {
+			if d.Status == otaapi.StatusSucceeded {
+				succeeded++
+			} else if d.Failed() {
+				failed++
+			}
+		}
+		t.AddRow(
+			fmt.Sprintf("%d (%v%%)", i+1, w.Percent),
+			len(w.Devices),
+			succeeded,
+			failed,
+			fmt.Sprintf("%.1f%%", w.FailureRate),
+			w.Status,
+		)
+	}
+	return t.Render() + fmt.Sprintf("\nRollout %s.", r.state.Status)
+}
@@ -0,0 +1,90 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ota
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/arduino/arduino-cli/cli/errorcodes"
+	"github.com/arduino/arduino-cli/cli/feedback"
+	"github.com/arduino/arduino-cli/table"
+	"github.com/arduino/arduino-cloud-cli/command/ota"
+	"github.com/spf13/cobra"
+)
+
+type unpackFlags struct {
+	file      string
+	out       string
+	reference string
+}
+
+func initUnpackCommand() *cobra.Command {
+	flags := &unpackFlags{}
+	unpackCommand := &cobra.Command{
+		Use:   "unpack",
+		Short: "Extract the binary of an OTA",
+		Long:  "Verify an OTA file and extract the firmware binary it contains",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runUnpackCommand(flags); err != nil {
+				feedback.Errorf("Error during ota unpack: %v", err)
+				os.Exit(errorcodes.ErrGeneric)
+			}
+		},
+	}
+	unpackCommand.Flags().StringVar(&flags.file, "file", "", "OTA file (.ota) to be unpacked")
+	unpackCommand.Flags().StringVar(&flags.out, "out", "", "Binary file (.bin) to be written")
+	unpackCommand.Flags().StringVar(&flags.reference, "reference", "", "Binary file (.bin) to be compared with the unpacked one")
+	unpackCommand.MarkFlagRequired("file")
+	unpackCommand.MarkFlagRequired("out")
+	return unpackCommand
+}
+
+func runUnpackCommand(flags *unpackFlags) error {
+	params := &ota.UnpackParams{
+		File:      flags.file,
+		Out:       flags.out,
+		Reference: flags.reference,
+	}
+	res, err := ota.Unpack(params)
+	if err != nil {
+		return err
+	}
+
+	feedback.PrintResult(unpackResult{res})
+	return nil
+}
+
+type unpackResult struct {
+	res *ota.UnpackResult
+}
+
+func (r unpackResult) Data() interface{} {
+	return r.res
+}
+
+func (r unpackResult) String() string {
+	t := table.New()
+	t.SetHeader("File", "Size", "SHA256", "Matches")
+	matches := "-"
+	if r.res.Reference != "" {
+		matches = r.res.Reference
+	}
+	t.AddRow(r.res.File, fmt.Sprintf("%d bytes", r.res.Size), r.res.SHA256, matches)
+	return t.Render()
+}
@@ -21,6 +21,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/arduino/arduino-cli/cli/errorcodes"
 	"github.com/arduino/arduino-cli/cli/feedback"
@@ -35,6 +36,10 @@ type uploadFlags struct {
 	file             string
 	deferred         bool
 	doNotApplyHeader bool
+	wait             bool
+	timeout          time.Duration
+	successTags      map[string]string
+	verifyKey        string
 }
 
 func initUploadCommand() *cobra.Command {
@@ -54,12 +59,26 @@ func initUploadCommand() *cobra.Command {
 	uploadCommand.Flags().StringVarP(&flags.file, "file", "", "", "Binary file (.bin) to be uploaded")
 	uploadCommand.Flags().BoolVar(&flags.deferred, "deferred", false, "Perform a deferred OTA. It can take up to 1 week.")
 	uploadCommand.Flags().BoolVar(&flags.doNotApplyHeader, "no-header", false, "Do not apply header and compression to binary file before upload")
+	uploadCommand.Flags().BoolVar(&flags.wait, "wait", false, "Wait for the OTA to end, showing its progress")
+	uploadCommand.Flags().DurationVar(&flags.timeout, "timeout", ota.DefaultWaitTimeout, "Maximum time waited for the OTA to end, used with --wait")
+	uploadCommand.Flags().StringToStringVar(&flags.successTags, "tag-on-success", nil,
+		"Comma-separated list of tags with format <key>=<value>, applied to the device once its OTA succeeded.\n"+
+			"The firmware_sha256 tag is always applied. Implies '--wait'.",
+	)
+	uploadCommand.Flags().StringVar(&flags.verifyKey, "verify-key", "",
+		"Public key (PEM) verifying the signature of the firmware, read from '<file>.sig'.\n"+
+			"The firmware is not uploaded if its signature is not valid.",
+	)
 	uploadCommand.MarkFlagRequired("device-id")
 	uploadCommand.MarkFlagRequired("file")
 	return uploadCommand
 }
 
 func runUploadCommand(flags *uploadFlags) error {
+	// Devices can be tagged only once their OTA is confirmed successful
+	if flags.successTags != nil {
+		flags.wait = true
+	}
 	logrus.Infof("Uploading binary %s to device %s", flags.file, flags.deviceID)
 
 	cred, err := config.RetrieveCredentials()
@@ -72,12 +91,20 @@ func runUploadCommand(flags *uploadFlags) error {
 		File:             flags.file,
 		Deferred:         flags.deferred,
 		DoNotApplyHeader: flags.doNotApplyHeader,
+		Wait:             flags.wait,
+		Timeout:          flags.timeout,
+		SuccessTags:      flags.successTags,
+		VerifyKey:        flags.verifyKey,
 	}
 	err = ota.Upload(context.TODO(), params, cred)
 	if err != nil {
 		return err
 	}
 
+	if flags.wait {
+		logrus.Info("Upload successfully completed")
+		return nil
+	}
 	logrus.Info("Upload successfully started")
 	return nil
 }
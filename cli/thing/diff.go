@@ -0,0 +1,105 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package thing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/arduino/arduino-cli/cli/errorcodes"
+	"github.com/arduino/arduino-cli/cli/feedback"
+	"github.com/arduino/arduino-cloud-cli/command/thing"
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/arduino/arduino-cloud-cli/internal/template"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type diffFlags struct {
+	id       string
+	template string
+}
+
+func initDiffCommand() *cobra.Command {
+	flags := &diffFlags{}
+	diffCommand := &cobra.Command{
+		Use:   "diff",
+		Short: "Compare a thing with a template",
+		Long:  "Compare an Arduino IoT Cloud thing with a template, exiting with a non-zero code if they differ",
+		Run: func(cmd *cobra.Command, args []string) {
+			changes, err := runDiffCommand(flags)
+			if err != nil {
+				feedback.Errorf("Error during thing diff: %v", err)
+				os.Exit(errorcodes.ErrGeneric)
+			}
+			if len(changes) > 0 {
+				os.Exit(errorcodes.ErrGeneric)
+			}
+		},
+	}
+	diffCommand.Flags().StringVarP(&flags.id, "id", "i", "", "Thing ID")
+	diffCommand.Flags().StringVarP(&flags.template, "template", "t", "",
+		"File containing a thing template, JSON and YAML format are supported",
+	)
+	diffCommand.MarkFlagRequired("id")
+	diffCommand.MarkFlagRequired("template")
+	return diffCommand
+}
+
+func runDiffCommand(flags *diffFlags) ([]template.Change, error) {
+	logrus.Infof("Comparing thing %s with template %s", flags.id, flags.template)
+
+	cred, err := config.RetrieveCredentials()
+	if err != nil {
+		return nil, fmt.Errorf("retrieving credentials: %w", err)
+	}
+
+	params := &thing.DiffParams{
+		ID:       flags.id,
+		Template: flags.template,
+	}
+
+	changes, err := thing.Diff(context.TODO(), params, cred)
+	if err != nil {
+		return nil, err
+	}
+
+	feedback.PrintResult(diffResult{changes})
+	return changes, nil
+}
+
+type diffResult struct {
+	changes []template.Change
+}
+
+func (r diffResult) Data() interface{} {
+	return r.changes
+}
+
+func (r diffResult) String() string {
+	if len(r.changes) == 0 {
+		return "No differences found."
+	}
+	lines := make([]string, 0, len(r.changes))
+	for _, c := range r.changes {
+		lines = append(lines, c.String())
+	}
+	return strings.Join(lines, "\n")
+}
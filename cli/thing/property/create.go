@@ -0,0 +1,132 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package property
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/arduino/arduino-cli/cli/errorcodes"
+	"github.com/arduino/arduino-cli/cli/feedback"
+	"github.com/arduino/arduino-cloud-cli/command/property"
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type createFlags struct {
+	thingID         string
+	file            string
+	name            string
+	variableName    string
+	propertyType    string
+	permission      string
+	updateStrategy  string
+	updateParameter float64
+	persist         bool
+	tag             int64
+}
+
+func initCreateCommand() *cobra.Command {
+	flags := &createFlags{}
+	createCommand := &cobra.Command{
+		Use:   "create",
+		Short: "Create variables of a thing",
+		Long:  "Create a variable of a thing on Arduino IoT Cloud, or all the variables listed in a file",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runCreateCommand(cmd, flags); err != nil {
+				feedback.Errorf("Error during thing property create: %v", err)
+				os.Exit(errorcodes.ErrGeneric)
+			}
+		},
+	}
+	createCommand.Flags().StringVarP(&flags.thingID, "thing-id", "t", "", "Thing ID")
+	createCommand.Flags().StringVarP(&flags.file, "file", "f", "",
+		"File containing a list of variables or a thing template, JSON and YAML format are supported.\n"+
+			"Mutually exclusive with '--name'.",
+	)
+	createCommand.Flags().StringVarP(&flags.name, "name", "n", "", "Variable name")
+	createCommand.Flags().StringVar(&flags.variableName, "variable-name", "", "Name of the variable in the sketch. Default: variable name")
+	createCommand.Flags().StringVar(&flags.propertyType, "type", "", "Variable type, e.g. INT, FLOAT, STATUS, TEMPERATURE_C")
+	createCommand.Flags().StringVar(&flags.permission, "permission", "READ_WRITE",
+		fmt.Sprintf("Variable permission, can be: %s", strings.Join(property.Permissions, ", ")))
+	createCommand.Flags().StringVar(&flags.updateStrategy, "update-strategy", "ON_CHANGE",
+		fmt.Sprintf("Variable update strategy, can be: %s", strings.Join(property.UpdateStrategies, ", ")))
+	createCommand.Flags().Float64Var(&flags.updateParameter, "update-parameter", 0,
+		"Seconds between updates with TIMED strategy, minimum change with ON_CHANGE strategy")
+	createCommand.Flags().BoolVar(&flags.persist, "persist", false, "Persist the variable values")
+	createCommand.Flags().Int64Var(&flags.tag, "tag", 0, "Variable tag, used by LoRaWAN devices")
+	createCommand.MarkFlagRequired("thing-id")
+	return createCommand
+}
+
+func runCreateCommand(cmd *cobra.Command, flags *createFlags) error {
+	cred, err := config.RetrieveCredentials()
+	if err != nil {
+		return fmt.Errorf("retrieving credentials: %w", err)
+	}
+
+	if flags.file != "" {
+		if flags.name != "" {
+			return errors.New("parameters '--file' and '--name' are mutually exclusive")
+		}
+		logrus.Infof("Creating variables of thing %s from file %s", flags.thingID, flags.file)
+
+		params := &property.CreateFromFileParams{
+			ThingID: flags.thingID,
+			File:    flags.file,
+		}
+		props, err := property.CreateFromFile(context.TODO(), params, cred)
+		if len(props) > 0 {
+			feedback.PrintResult(listResult{props})
+		}
+		return err
+	}
+
+	if flags.name == "" || flags.propertyType == "" {
+		return errors.New("parameters '--name' and '--type' are required, unless '--file' is passed")
+	}
+	logrus.Infof("Creating variable %s of thing %s", flags.name, flags.thingID)
+
+	params := &property.CreateParams{
+		ThingID:        flags.thingID,
+		Name:           flags.name,
+		VariableName:   flags.variableName,
+		Type:           strings.ToUpper(flags.propertyType),
+		Permission:     strings.ToUpper(flags.permission),
+		UpdateStrategy: strings.ToUpper(flags.updateStrategy),
+		Persist:        flags.persist,
+	}
+	if cmd.Flags().Changed("update-parameter") {
+		params.UpdateParameter = &flags.updateParameter
+	}
+	if cmd.Flags().Changed("tag") {
+		params.Tag = &flags.tag
+	}
+
+	prop, err := property.Create(context.TODO(), params, cred)
+	if err != nil {
+		return err
+	}
+
+	feedback.PrintResult(showResult{prop})
+	return nil
+}
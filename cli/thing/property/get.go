@@ -0,0 +1,113 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package property
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/arduino/arduino-cli/cli/errorcodes"
+	"github.com/arduino/arduino-cli/cli/feedback"
+	"github.com/arduino/arduino-cloud-cli/command/property"
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type getFlags struct {
+	thingID string
+	id      string
+	name    string
+}
+
+func initGetCommand() *cobra.Command {
+	flags := &getFlags{}
+	getCommand := &cobra.Command{
+		Use:   "get",
+		Short: "Get the value of a variable",
+		Long:  "Get the last value of a variable of a thing on Arduino IoT Cloud, along with its timestamp",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runGetCommand(flags); err != nil {
+				feedback.Errorf("Error during thing property get: %v", err)
+				os.Exit(errorcodes.ErrGeneric)
+			}
+		},
+	}
+	getCommand.Flags().StringVarP(&flags.thingID, "thing-id", "t", "", "Thing ID")
+	getCommand.Flags().StringVarP(&flags.id, "id", "i", "", "Variable ID")
+	getCommand.Flags().StringVarP(&flags.name, "name", "n", "", "Variable name, mutually exclusive with '--id'")
+	getCommand.MarkFlagRequired("thing-id")
+	return getCommand
+}
+
+func runGetCommand(flags *getFlags) error {
+	logrus.Infof("Getting value of variable %s%s of thing %s", flags.id, flags.name, flags.thingID)
+
+	cred, err := config.RetrieveCredentials()
+	if err != nil {
+		return fmt.Errorf("retrieving credentials: %w", err)
+	}
+
+	params := &property.GetParams{
+		ThingID: flags.thingID,
+		ID:      flags.id,
+		Name:    flags.name,
+	}
+	value, err := property.Get(context.TODO(), params, cred)
+	if err != nil {
+		return err
+	}
+
+	feedback.PrintResult(valueResult{value})
+	return nil
+}
+
+type valueResult struct {
+	value *property.ValueInfo
+}
+
+func (r valueResult) Data() interface{} {
+	return r.value
+}
+
+func (r valueResult) String() string {
+	updatedAt := ""
+	if r.value.UpdatedAt != nil {
+		updatedAt = r.value.UpdatedAt.Format(time.RFC3339)
+	}
+	return fmt.Sprintf("name: %s\nid: %s\ntype: %s\nvalue: %s\nupdated_at: %s",
+		r.value.Name, r.value.ID, r.value.Type, formatValue(r.value.Value), updatedAt)
+}
+
+// formatValue prints structured values as json.
+func formatValue(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		b, err := json.Marshal(v)
+		if err == nil {
+			return string(b)
+		}
+	}
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
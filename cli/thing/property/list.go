@@ -0,0 +1,71 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package property
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/arduino/arduino-cli/cli/errorcodes"
+	"github.com/arduino/arduino-cli/cli/feedback"
+	"github.com/arduino/arduino-cloud-cli/command/property"
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type listFlags struct {
+	thingID string
+}
+
+func initListCommand() *cobra.Command {
+	flags := &listFlags{}
+	listCommand := &cobra.Command{
+		Use:   "list",
+		Short: "List the variables of a thing",
+		Long:  "List the variables of a thing on Arduino IoT Cloud",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runListCommand(flags); err != nil {
+				feedback.Errorf("Error during thing property list: %v", err)
+				os.Exit(errorcodes.ErrGeneric)
+			}
+		},
+	}
+	listCommand.Flags().StringVarP(&flags.thingID, "thing-id", "t", "", "Thing ID")
+	listCommand.MarkFlagRequired("thing-id")
+	return listCommand
+}
+
+func runListCommand(flags *listFlags) error {
+	logrus.Infof("Listing variables of thing %s", flags.thingID)
+
+	cred, err := config.RetrieveCredentials()
+	if err != nil {
+		return fmt.Errorf("retrieving credentials: %w", err)
+	}
+
+	params := &property.ListParams{ThingID: flags.thingID}
+	properties, err := property.List(context.TODO(), params, cred)
+	if err != nil {
+		return err
+	}
+
+	feedback.PrintResult(listResult{properties})
+	return nil
+}
@@ -0,0 +1,98 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package property
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/arduino/arduino-cli/table"
+	"github.com/arduino/arduino-cloud-cli/command/property"
+	"github.com/spf13/cobra"
+)
+
+// NewCommand creates a new `property` command, grouping
+// the commands to manage the variables of a thing.
+func NewCommand() *cobra.Command {
+	propertyCommand := &cobra.Command{
+		Use:     "property",
+		Aliases: []string{"variable"},
+		Short:   "Thing variable commands.",
+		Long:    "Commands to manage the variables (properties) of a thing.",
+	}
+
+	propertyCommand.AddCommand(initListCommand())
+	propertyCommand.AddCommand(initShowCommand())
+	propertyCommand.AddCommand(initCreateCommand())
+	propertyCommand.AddCommand(initUpdateCommand())
+	propertyCommand.AddCommand(initDeleteCommand())
+	propertyCommand.AddCommand(initGetCommand())
+	propertyCommand.AddCommand(initSetCommand())
+
+	return propertyCommand
+}
+
+type listResult struct {
+	properties []property.PropertyInfo
+}
+
+func (r listResult) Data() interface{} {
+	return r.properties
+}
+
+func (r listResult) String() string {
+	if len(r.properties) == 0 {
+		return "No variables found."
+	}
+	t := table.New()
+	t.SetHeader("Name", "ID", "Type", "Permission", "Update strategy", "Persist", "Tag")
+	for _, p := range r.properties {
+		t.AddRow(p.VariableName, p.ID, p.Type, p.Permission, updateStrategy(p), p.Persist, tag(p))
+	}
+	return t.Render()
+}
+
+type showResult struct {
+	property *property.PropertyInfo
+}
+
+func (r showResult) Data() interface{} {
+	return r.property
+}
+
+func (r showResult) String() string {
+	p := r.property
+	return fmt.Sprintf(
+		"name: %s\nvariable_name: %s\nid: %s\nthing_id: %s\ntype: %s\npermission: %s\nupdate_strategy: %s\npersist: %t\ntag: %s",
+		p.Name, p.VariableName, p.ID, p.ThingID, p.Type, p.Permission, updateStrategy(*p), p.Persist, tag(*p),
+	)
+}
+
+func updateStrategy(p property.PropertyInfo) string {
+	if p.UpdateParameter == nil || *p.UpdateParameter == 0 {
+		return p.UpdateStrategy
+	}
+	return fmt.Sprintf("%s (%s)", p.UpdateStrategy, strconv.FormatFloat(*p.UpdateParameter, 'g', -1, 64))
+}
+
+func tag(p property.PropertyInfo) string {
+	if p.Tag == nil {
+		return ""
+	}
+	return strconv.FormatInt(*p.Tag, 10)
+}
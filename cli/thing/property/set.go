@@ -0,0 +1,85 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package property
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/arduino/arduino-cli/cli/errorcodes"
+	"github.com/arduino/arduino-cli/cli/feedback"
+	"github.com/arduino/arduino-cloud-cli/command/property"
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type setFlags struct {
+	thingID string
+	id      string
+	name    string
+	value   string
+}
+
+func initSetCommand() *cobra.Command {
+	flags := &setFlags{}
+	setCommand := &cobra.Command{
+		Use:   "set",
+		Short: "Set the value of a variable",
+		Long: "Publish a new value of a READ_WRITE variable of a thing on Arduino IoT Cloud.\n" +
+			"The value is parsed according to the variable type: structured values, like colors and locations, " +
+			"are passed as JSON objects. Locations and colors can also be passed as '<lat>,<lon>' and '<hue>,<sat>,<bri>'",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runSetCommand(flags); err != nil {
+				feedback.Errorf("Error during thing property set: %v", err)
+				os.Exit(errorcodes.ErrGeneric)
+			}
+		},
+	}
+	setCommand.Flags().StringVarP(&flags.thingID, "thing-id", "t", "", "Thing ID")
+	setCommand.Flags().StringVarP(&flags.id, "id", "i", "", "Variable ID")
+	setCommand.Flags().StringVarP(&flags.name, "name", "n", "", "Variable name, mutually exclusive with '--id'")
+	setCommand.Flags().StringVar(&flags.value, "value", "", "New value of the variable")
+	setCommand.MarkFlagRequired("thing-id")
+	setCommand.MarkFlagRequired("value")
+	return setCommand
+}
+
+func runSetCommand(flags *setFlags) error {
+	logrus.Infof("Setting value of variable %s%s of thing %s", flags.id, flags.name, flags.thingID)
+
+	cred, err := config.RetrieveCredentials()
+	if err != nil {
+		return fmt.Errorf("retrieving credentials: %w", err)
+	}
+
+	params := &property.SetParams{
+		ThingID: flags.thingID,
+		ID:      flags.id,
+		Name:    flags.name,
+		Value:   flags.value,
+	}
+	value, err := property.Set(context.TODO(), params, cred)
+	if err != nil {
+		return err
+	}
+
+	feedback.PrintResult(valueResult{value})
+	return nil
+}
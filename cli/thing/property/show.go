@@ -0,0 +1,77 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package property
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/arduino/arduino-cli/cli/errorcodes"
+	"github.com/arduino/arduino-cli/cli/feedback"
+	"github.com/arduino/arduino-cloud-cli/command/property"
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type showFlags struct {
+	thingID string
+	id      string
+}
+
+func initShowCommand() *cobra.Command {
+	flags := &showFlags{}
+	showCommand := &cobra.Command{
+		Use:   "show",
+		Short: "Show a variable of a thing",
+		Long:  "Show a variable of a thing on Arduino IoT Cloud",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runShowCommand(flags); err != nil {
+				feedback.Errorf("Error during thing property show: %v", err)
+				os.Exit(errorcodes.ErrGeneric)
+			}
+		},
+	}
+	showCommand.Flags().StringVarP(&flags.thingID, "thing-id", "t", "", "Thing ID")
+	showCommand.Flags().StringVarP(&flags.id, "id", "i", "", "Variable ID")
+	showCommand.MarkFlagRequired("thing-id")
+	showCommand.MarkFlagRequired("id")
+	return showCommand
+}
+
+func runShowCommand(flags *showFlags) error {
+	logrus.Infof("Showing variable %s of thing %s", flags.id, flags.thingID)
+
+	cred, err := config.RetrieveCredentials()
+	if err != nil {
+		return fmt.Errorf("retrieving credentials: %w", err)
+	}
+
+	params := &property.ShowParams{
+		ThingID: flags.thingID,
+		ID:      flags.id,
+	}
+	prop, err := property.Show(context.TODO(), params, cred)
+	if err != nil {
+		return err
+	}
+
+	feedback.PrintResult(showResult{prop})
+	return nil
+}
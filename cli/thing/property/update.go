@@ -0,0 +1,126 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package property
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/arduino/arduino-cli/cli/errorcodes"
+	"github.com/arduino/arduino-cli/cli/feedback"
+	"github.com/arduino/arduino-cloud-cli/command/property"
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type updateFlags struct {
+	thingID         string
+	id              string
+	name            string
+	variableName    string
+	propertyType    string
+	permission      string
+	updateStrategy  string
+	updateParameter float64
+	persist         bool
+	tag             int64
+}
+
+func initUpdateCommand() *cobra.Command {
+	flags := &updateFlags{}
+	updateCommand := &cobra.Command{
+		Use:   "update",
+		Short: "Update a variable of a thing",
+		Long:  "Update a variable of a thing on Arduino IoT Cloud. Only the passed fields are changed",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runUpdateCommand(cmd, flags); err != nil {
+				feedback.Errorf("Error during thing property update: %v", err)
+				os.Exit(errorcodes.ErrGeneric)
+			}
+		},
+	}
+	updateCommand.Flags().StringVarP(&flags.thingID, "thing-id", "t", "", "Thing ID")
+	updateCommand.Flags().StringVarP(&flags.id, "id", "i", "", "Variable ID")
+	updateCommand.Flags().StringVarP(&flags.name, "name", "n", "", "Variable name")
+	updateCommand.Flags().StringVar(&flags.variableName, "variable-name", "", "Name of the variable in the sketch")
+	updateCommand.Flags().StringVar(&flags.propertyType, "type", "", "Variable type, e.g. INT, FLOAT, STATUS, TEMPERATURE_C")
+	updateCommand.Flags().StringVar(&flags.permission, "permission", "",
+		fmt.Sprintf("Variable permission, can be: %s", strings.Join(property.Permissions, ", ")))
+	updateCommand.Flags().StringVar(&flags.updateStrategy, "update-strategy", "",
+		fmt.Sprintf("Variable update strategy, can be: %s", strings.Join(property.UpdateStrategies, ", ")))
+	updateCommand.Flags().Float64Var(&flags.updateParameter, "update-parameter", 0,
+		"Seconds between updates with TIMED strategy, minimum change with ON_CHANGE strategy")
+	updateCommand.Flags().BoolVar(&flags.persist, "persist", false, "Persist the variable values")
+	updateCommand.Flags().Int64Var(&flags.tag, "tag", 0, "Variable tag, used by LoRaWAN devices")
+	updateCommand.MarkFlagRequired("thing-id")
+	updateCommand.MarkFlagRequired("id")
+	return updateCommand
+}
+
+func runUpdateCommand(cmd *cobra.Command, flags *updateFlags) error {
+	logrus.Infof("Updating variable %s of thing %s", flags.id, flags.thingID)
+
+	cred, err := config.RetrieveCredentials()
+	if err != nil {
+		return fmt.Errorf("retrieving credentials: %w", err)
+	}
+
+	params := &property.UpdateParams{
+		ThingID: flags.thingID,
+		ID:      flags.id,
+	}
+	changed := cmd.Flags().Changed
+	if changed("name") {
+		params.Name = &flags.name
+	}
+	if changed("variable-name") {
+		params.VariableName = &flags.variableName
+	}
+	if changed("type") {
+		t := strings.ToUpper(flags.propertyType)
+		params.Type = &t
+	}
+	if changed("permission") {
+		p := strings.ToUpper(flags.permission)
+		params.Permission = &p
+	}
+	if changed("update-strategy") {
+		s := strings.ToUpper(flags.updateStrategy)
+		params.UpdateStrategy = &s
+	}
+	if changed("update-parameter") {
+		params.UpdateParameter = &flags.updateParameter
+	}
+	if changed("persist") {
+		params.Persist = &flags.persist
+	}
+	if changed("tag") {
+		params.Tag = &flags.tag
+	}
+
+	prop, err := property.Update(context.TODO(), params, cred)
+	if err != nil {
+		return err
+	}
+
+	feedback.PrintResult(showResult{prop})
+	return nil
+}
@@ -18,7 +18,9 @@
 package thing
 
 import (
+	"github.com/arduino/arduino-cloud-cli/cli/thing/property"
 	"github.com/arduino/arduino-cloud-cli/cli/thing/tag"
+	"github.com/arduino/arduino-cloud-cli/cli/thing/timeseries"
 	"github.com/spf13/cobra"
 )
 
@@ -30,13 +32,18 @@ func NewCommand() *cobra.Command {
 	}
 
 	thingCommand.AddCommand(initCreateCommand())
+	thingCommand.AddCommand(initUpdateCommand())
 	thingCommand.AddCommand(initCloneCommand())
 	thingCommand.AddCommand(initListCommand())
 	thingCommand.AddCommand(initDeleteCommand())
 	thingCommand.AddCommand(initExtractCommand())
+	thingCommand.AddCommand(initDiffCommand())
 	thingCommand.AddCommand(initBindCommand())
+	thingCommand.AddCommand(initWatchCommand())
 	thingCommand.AddCommand(tag.InitCreateTagsCommand())
 	thingCommand.AddCommand(tag.InitDeleteTagsCommand())
+	thingCommand.AddCommand(property.NewCommand())
+	thingCommand.AddCommand(timeseries.NewCommand())
 
 	return thingCommand
 }
@@ -0,0 +1,134 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package timeseries
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/arduino/arduino-cli/cli/errorcodes"
+	"github.com/arduino/arduino-cli/cli/feedback"
+	"github.com/arduino/arduino-cli/table"
+	"github.com/arduino/arduino-cloud-cli/command/timeseries"
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/arduino/arduino-cloud-cli/internal/timeutil"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type exportFlags struct {
+	thingIDs []string
+	tags     map[string]string
+	from     string
+	to       string
+	interval time.Duration
+	format   string
+	outDir   string
+	merge    bool
+	resume   bool
+}
+
+func initExportCommand() *cobra.Command {
+	flags := &exportFlags{}
+	exportCommand := &cobra.Command{
+		Use:   "export",
+		Short: "Export the values of thing variables",
+		Long:  "Export the historic values of all the variables of the given things to csv, jsonl or parquet files",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runExportCommand(flags); err != nil {
+				feedback.Errorf("Error during thing timeseries export: %v", err)
+				os.Exit(errorcodes.ErrGeneric)
+			}
+		},
+	}
+	exportCommand.Flags().StringSliceVarP(&flags.thingIDs, "thing-ids", "t", nil, "Comma-separated list of thing IDs")
+	exportCommand.Flags().StringToStringVar(
+		&flags.tags,
+		"tags",
+		nil,
+		"Comma-separated list of tags with format <key>=<value>.\n"+
+			"Export the things that match the provided tags.\n"+
+			"Do not use this flag with --thing-ids.",
+	)
+	exportCommand.Flags().StringVar(&flags.from, "from", "", "Start of the time range, in RFC3339 format or as a YYYY-MM-DD date")
+	exportCommand.Flags().StringVar(&flags.to, "to", "", "End of the time range, in RFC3339 format or as a YYYY-MM-DD date; defaults to now")
+	exportCommand.Flags().DurationVar(&flags.interval, "interval", 0, "Aggregate values over the given interval (e.g. 15m, 1h); by default the interval is chosen by the server, depending on the density of values")
+	exportCommand.Flags().StringVar(
+		&flags.format,
+		"format",
+		timeseries.FormatCSV,
+		fmt.Sprintf("Format of the exported files, one of: %s", strings.Join(timeseries.Formats, ", ")),
+	)
+	exportCommand.Flags().StringVarP(&flags.outDir, "out-dir", "o", ".", "Directory where the files are written")
+	exportCommand.Flags().BoolVar(&flags.merge, "merge", false, "Write a single file instead of a file per thing")
+	exportCommand.Flags().BoolVar(&flags.resume, "resume", false, "Resume an interrupted export to the same output directory")
+	exportCommand.MarkFlagRequired("from")
+	return exportCommand
+}
+
+func runExportCommand(flags *exportFlags) error {
+	from, to, err := timeutil.ParseRange(flags.from, flags.to, 0)
+	if err != nil {
+		return err
+	}
+	logrus.Infof("Exporting time series from %s to %s", from.Format(time.RFC3339), to.Format(time.RFC3339))
+
+	cred, err := config.RetrieveCredentials()
+	if err != nil {
+		return fmt.Errorf("retrieving credentials: %w", err)
+	}
+
+	params := &timeseries.ExportParams{
+		ThingIDs: flags.thingIDs,
+		Tags:     flags.tags,
+		From:     from,
+		To:       to,
+		Interval: flags.interval,
+		Format:   flags.format,
+		OutDir:   flags.outDir,
+		Merge:    flags.merge,
+		Resume:   flags.resume,
+	}
+	res, err := timeseries.Export(context.TODO(), params, cred)
+	if err != nil {
+		return err
+	}
+
+	feedback.PrintResult(exportResult{res})
+	return nil
+}
+
+type exportResult struct {
+	res *timeseries.ExportResult
+}
+
+func (r exportResult) Data() interface{} {
+	return r.res
+}
+
+func (r exportResult) String() string {
+	t := table.New()
+	t.SetHeader("File")
+	for _, f := range r.res.Files {
+		t.AddRow(f)
+	}
+	return t.Render() + fmt.Sprintf("\n%d values exported.", r.res.Rows)
+}
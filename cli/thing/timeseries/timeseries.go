@@ -0,0 +1,36 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package timeseries
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// NewCommand creates a new `timeseries` command, grouping
+// the commands to handle the historic values of thing variables.
+func NewCommand() *cobra.Command {
+	timeseriesCommand := &cobra.Command{
+		Use:   "timeseries",
+		Short: "Thing time series commands.",
+		Long:  "Commands to handle the historic values of thing variables.",
+	}
+
+	timeseriesCommand.AddCommand(initExportCommand())
+
+	return timeseriesCommand
+}
@@ -0,0 +1,83 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package thing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/arduino/arduino-cli/cli/errorcodes"
+	"github.com/arduino/arduino-cli/cli/feedback"
+	"github.com/arduino/arduino-cloud-cli/command/thing"
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type updateFlags struct {
+	id       string
+	template string
+	prune    bool
+}
+
+func initUpdateCommand() *cobra.Command {
+	flags := &updateFlags{}
+	updateCommand := &cobra.Command{
+		Use:   "update",
+		Short: "Update a thing from a template",
+		Long:  "Update an Arduino IoT Cloud thing from a template, preserving the IDs of its variables",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runUpdateCommand(flags); err != nil {
+				feedback.Errorf("Error during thing update: %v", err)
+				os.Exit(errorcodes.ErrGeneric)
+			}
+		},
+	}
+	updateCommand.Flags().StringVarP(&flags.id, "id", "i", "", "Thing ID")
+	updateCommand.Flags().StringVarP(&flags.template, "template", "t", "",
+		"File containing a thing template, JSON and YAML format are supported",
+	)
+	updateCommand.Flags().BoolVar(&flags.prune, "prune", false, "Delete the variables and tags missing from the template")
+	updateCommand.MarkFlagRequired("id")
+	updateCommand.MarkFlagRequired("template")
+	return updateCommand
+}
+
+func runUpdateCommand(flags *updateFlags) error {
+	logrus.Infof("Updating thing %s from template %s", flags.id, flags.template)
+
+	cred, err := config.RetrieveCredentials()
+	if err != nil {
+		return fmt.Errorf("retrieving credentials: %w", err)
+	}
+
+	params := &thing.UpdateParams{
+		ID:       flags.id,
+		Template: flags.template,
+		Prune:    flags.prune,
+	}
+
+	changes, err := thing.Update(context.TODO(), params, cred)
+	if err != nil {
+		return err
+	}
+
+	feedback.PrintResult(diffResult{changes})
+	return nil
+}
@@ -0,0 +1,100 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package thing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/arduino/arduino-cli/cli/errorcodes"
+	"github.com/arduino/arduino-cli/cli/feedback"
+	"github.com/arduino/arduino-cloud-cli/command/thing"
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type watchFlags struct {
+	id        string
+	tags      map[string]string
+	variables []string
+}
+
+func initWatchCommand() *cobra.Command {
+	flags := &watchFlags{}
+	watchCommand := &cobra.Command{
+		Use:   "watch",
+		Short: "Watch the variables of things",
+		Long:  "Stream the updates of the variables of things on Arduino IoT Cloud, received from its realtime channel, until interrupted",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runWatchCommand(flags); err != nil {
+				feedback.Errorf("Error during thing watch: %v", err)
+				os.Exit(errorcodes.ErrGeneric)
+			}
+		},
+	}
+	watchCommand.Flags().StringVarP(&flags.id, "id", "i", "", "Thing ID")
+	watchCommand.Flags().StringToStringVar(
+		&flags.tags,
+		"tags",
+		nil,
+		"Comma-separated list of tags with format <key>=<value>.\n"+
+			"Watch all the things that match the provided tags.\n"+
+			"Do not use this flag with --id.",
+	)
+	watchCommand.Flags().StringSliceVar(&flags.variables, "variables", nil, "Comma-separated list of variable names to watch; all variables are watched by default")
+	return watchCommand
+}
+
+func runWatchCommand(flags *watchFlags) error {
+	logrus.Info("Watching things")
+
+	cred, err := config.RetrieveCredentials()
+	if err != nil {
+		return fmt.Errorf("retrieving credentials: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	params := &thing.WatchParams{
+		ID:        flags.id,
+		Tags:      flags.tags,
+		Variables: flags.variables,
+	}
+	// Updates are printed as they arrive: as text rows,
+	// or as JSON lines when a JSON output format is selected.
+	jsonLines := feedback.GetFormat() == feedback.JSON || feedback.GetFormat() == feedback.JSONMini
+	return thing.Watch(ctx, params, cred, func(u *thing.ValueUpdate) {
+		if jsonLines {
+			line, err := json.Marshal(u)
+			if err != nil {
+				logrus.Warnf("Cannot encode update of variable %s: %v", u.Variable, err)
+				return
+			}
+			fmt.Fprintln(os.Stdout, string(line))
+			return
+		}
+		value, _ := json.Marshal(u.Value)
+		fmt.Fprintf(os.Stdout, "%s\t%s\t%s\t%s\n", u.Time.Format(time.RFC3339Nano), u.ThingName, u.Variable, value)
+	})
+}
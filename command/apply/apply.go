@@ -0,0 +1,61 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package apply
+
+import (
+	"context"
+
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/arduino/arduino-cloud-cli/internal/iot"
+)
+
+// Params contains the parameters needed to apply a manifest.
+type Params struct {
+	File  string // Path of the manifest file
+	State string // Path of the state file; if empty, DefaultStatePath is used
+}
+
+// NewPlan computes the actions needed to align the cloud to a manifest.
+// The cloud is not modified until the plan is applied.
+func NewPlan(ctx context.Context, params *Params, cred *config.Credentials) (*Plan, error) {
+	manifest, err := LoadManifest(params.File)
+	if err != nil {
+		return nil, err
+	}
+
+	statePath := params.State
+	if statePath == "" {
+		statePath = DefaultStatePath(params.File)
+	}
+	state, err := LoadState(statePath)
+	if err != nil {
+		return nil, err
+	}
+
+	iotClient, err := iot.NewClient(cred)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := newPlan(ctx, manifest, state, iotClient)
+	if err != nil {
+		return nil, err
+	}
+	plan.statePath = statePath
+	return plan, nil
+}
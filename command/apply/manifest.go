@@ -0,0 +1,164 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package apply
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest describes the desired state of a fleet.
+type Manifest struct {
+	Devices    []DeviceSpec    `json:"devices,omitempty" yaml:"devices,omitempty"`
+	Things     []ThingSpec     `json:"things,omitempty" yaml:"things,omitempty"`
+	Dashboards []DashboardSpec `json:"dashboards,omitempty" yaml:"dashboards,omitempty"`
+}
+
+// DeviceSpec describes an existing device of the fleet.
+// The device is identified either by its id or by a tag selector
+// which must match exactly one device.
+type DeviceSpec struct {
+	Name     string            `json:"name" yaml:"name"`                             // Name used to reference the device in the manifest
+	ID       string            `json:"id,omitempty" yaml:"id,omitempty"`             // Id of the device
+	Selector map[string]string `json:"selector,omitempty" yaml:"selector,omitempty"` // Tags of the device
+	Tags     map[string]string `json:"tags,omitempty" yaml:"tags,omitempty"`         // Tags to be set on the device
+}
+
+// ThingSpec describes a thing of the fleet.
+type ThingSpec struct {
+	Name     string            `json:"name" yaml:"name"`                         // Name of the thing, overrides the one of the template
+	Template string            `json:"template" yaml:"template"`                 // Path of the thing template, relative to the manifest
+	Device   string            `json:"device,omitempty" yaml:"device,omitempty"` // Name of the manifest device to bind to the thing
+	Tags     map[string]string `json:"tags,omitempty" yaml:"tags,omitempty"`     // Tags added to the ones of the template
+}
+
+// DashboardSpec describes a dashboard of the fleet.
+type DashboardSpec struct {
+	Name     string            `json:"name" yaml:"name"`                         // Name of the dashboard, overrides the one of the template
+	Template string            `json:"template" yaml:"template"`                 // Path of the dashboard template, relative to the manifest
+	Things   map[string]string `json:"things,omitempty" yaml:"things,omitempty"` // Thing ids of the template mapped to manifest thing names
+}
+
+// LoadManifest reads and validates a manifest file, in json or yaml format.
+// Template paths are resolved relative to the manifest directory.
+func LoadManifest(file string) (*Manifest, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	// Json is a subset of yaml, so both formats are decoded here
+	m := &Manifest{}
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	dec.KnownFields(true)
+	if err := dec.Decode(m); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("reading manifest file: %w", err)
+	}
+
+	dir := filepath.Dir(file)
+	for i := range m.Things {
+		m.Things[i].Template = resolvePath(dir, m.Things[i].Template)
+	}
+	for i := range m.Dashboards {
+		m.Dashboards[i].Template = resolvePath(dir, m.Dashboards[i].Template)
+	}
+
+	if err := m.validate(); err != nil {
+		return nil, fmt.Errorf("manifest file not valid: %w", err)
+	}
+	return m, nil
+}
+
+func resolvePath(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+func (m *Manifest) validate() error {
+	devices := make(map[string]bool, len(m.Devices))
+	for _, d := range m.Devices {
+		if d.Name == "" {
+			return errors.New("device without name")
+		}
+		if devices[d.Name] {
+			return fmt.Errorf("duplicate device '%s'", d.Name)
+		}
+		devices[d.Name] = true
+		if (d.ID == "") == (len(d.Selector) == 0) {
+			return fmt.Errorf("device '%s': exactly one of id and selector must be specified", d.Name)
+		}
+	}
+
+	things := make(map[string]bool, len(m.Things))
+	bound := make(map[string]string)
+	for _, t := range m.Things {
+		if t.Name == "" {
+			return errors.New("thing without name")
+		}
+		if things[t.Name] {
+			return fmt.Errorf("duplicate thing '%s'", t.Name)
+		}
+		things[t.Name] = true
+		if t.Template == "" {
+			return fmt.Errorf("thing '%s': template not specified", t.Name)
+		}
+		if t.Device == "" {
+			continue
+		}
+		if !devices[t.Device] {
+			return fmt.Errorf("thing '%s': device '%s' not found in manifest", t.Name, t.Device)
+		}
+		if other, ok := bound[t.Device]; ok {
+			return fmt.Errorf("device '%s' bound to both thing '%s' and thing '%s'", t.Device, other, t.Name)
+		}
+		bound[t.Device] = t.Name
+	}
+
+	dashboards := make(map[string]bool, len(m.Dashboards))
+	for _, d := range m.Dashboards {
+		if d.Name == "" {
+			return errors.New("dashboard without name")
+		}
+		if dashboards[d.Name] {
+			return fmt.Errorf("duplicate dashboard '%s'", d.Name)
+		}
+		dashboards[d.Name] = true
+		if d.Template == "" {
+			return fmt.Errorf("dashboard '%s': template not specified", d.Name)
+		}
+		for _, thing := range d.Things {
+			if !things[thing] {
+				return fmt.Errorf("dashboard '%s': thing '%s' not found in manifest", d.Name, thing)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,521 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package apply
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/arduino/arduino-cloud-cli/internal/template"
+	iotclient "github.com/arduino/iot-client-go/v3"
+)
+
+// Operations performed by an Action.
+const (
+	OpCreate = "create"
+	OpUpdate = "update"
+	OpNone   = "none"
+)
+
+// Resource types handled by an Action.
+const (
+	ResourceDevice    = "device"
+	ResourceThing     = "thing"
+	ResourceDashboard = "dashboard"
+)
+
+// Action describes what has to be done to align
+// a resource of the manifest to the cloud.
+type Action struct {
+	Resource  string            `json:"resource"`
+	Name      string            `json:"name"`
+	ID        string            `json:"id,omitempty"` // Empty if the resource has to be created
+	Operation string            `json:"operation"`
+	Changes   []template.Change `json:"changes,omitempty"`
+
+	// run performs the action and returns the id of the resource
+	run func(ctx context.Context) (string, error)
+}
+
+// Plan contains the actions needed to apply a manifest,
+// in the order they have to be executed.
+type Plan struct {
+	Actions []Action
+
+	client    cloudClient
+	state     *State
+	statePath string
+}
+
+// cloudClient contains the methods of the iot client used to apply a manifest.
+type cloudClient interface {
+	template.ThingFetcher
+	DeviceShow(ctx context.Context, id string) (*iotclient.ArduinoDevicev2, error)
+	DeviceList(ctx context.Context, tags map[string]string) ([]iotclient.ArduinoDevicev2, error)
+	DeviceTagsCreate(ctx context.Context, id string, tags map[string]string) error
+	ThingList(ctx context.Context, ids []string, device *string, props bool, tags map[string]string) ([]iotclient.ArduinoThing, error)
+	ThingCreate(ctx context.Context, thing *iotclient.ThingCreate, force bool) (*iotclient.ArduinoThing, error)
+	ThingUpdate(ctx context.Context, id string, thing *iotclient.ThingUpdate, force bool) error
+	ThingTagsCreate(ctx context.Context, id string, tags map[string]string) error
+	PropertyCreate(ctx context.Context, thingId string, property *iotclient.Property) (*iotclient.ArduinoProperty, error)
+	PropertyUpdate(ctx context.Context, thingId, propertyId string, property *iotclient.Property) (*iotclient.ArduinoProperty, error)
+	DashboardList(ctx context.Context) ([]iotclient.ArduinoDashboardv3, error)
+	DashboardCreate(ctx context.Context, dashboard *iotclient.Dashboardv3) (*iotclient.ArduinoDashboardv3, error)
+	DashboardUpdate(ctx context.Context, id string, dashboard *iotclient.Dashboardv3) (*iotclient.ArduinoDashboardv3, error)
+}
+
+// Count returns the number of actions with the passed operation.
+func (p *Plan) Count(operation string) int {
+	n := 0
+	for _, a := range p.Actions {
+		if a.Operation == operation {
+			n++
+		}
+	}
+	return n
+}
+
+// Apply executes the actions of the plan, saving the state file
+// after each of them: an interrupted apply can be resumed by running it again.
+func (p *Plan) Apply(ctx context.Context) error {
+	for _, a := range p.Actions {
+		if a.ID != "" {
+			p.state.ids(a.Resource)[a.Name] = a.ID
+		}
+	}
+	if err := p.state.Save(p.statePath); err != nil {
+		return err
+	}
+
+	for _, a := range p.Actions {
+		if a.Operation == OpNone {
+			continue
+		}
+		id, err := a.run(ctx)
+		if err != nil {
+			return fmt.Errorf("cannot %s %s '%s': %w", a.Operation, a.Resource, a.Name, err)
+		}
+		p.state.ids(a.Resource)[a.Name] = id
+		if err := p.state.Save(p.statePath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *State) ids(resource string) map[string]string {
+	switch resource {
+	case ResourceDevice:
+		return s.Devices
+	case ResourceThing:
+		return s.Things
+	default:
+		return s.Dashboards
+	}
+}
+
+// planner computes the plan of a manifest against the cloud.
+type planner struct {
+	client cloudClient
+	state  *State
+
+	deviceIDs  map[string]string // Device ids by manifest name
+	things     map[string]*Action
+	thingList  []iotclient.ArduinoThing
+	dashboards []iotclient.ArduinoDashboardv3
+}
+
+func newPlan(ctx context.Context, m *Manifest, state *State, client cloudClient) (*Plan, error) {
+	p := &planner{
+		client:    client,
+		state:     state,
+		deviceIDs: make(map[string]string),
+		things:    make(map[string]*Action),
+	}
+	plan := &Plan{client: client, state: state}
+
+	for _, spec := range m.Devices {
+		a, err := p.planDevice(ctx, spec)
+		if err != nil {
+			return nil, fmt.Errorf("planning device '%s': %w", spec.Name, err)
+		}
+		plan.Actions = append(plan.Actions, *a)
+	}
+	for _, spec := range m.Things {
+		a, err := p.planThing(ctx, spec)
+		if err != nil {
+			return nil, fmt.Errorf("planning thing '%s': %w", spec.Name, err)
+		}
+		p.things[spec.Name] = a
+		plan.Actions = append(plan.Actions, *a)
+	}
+	for _, spec := range m.Dashboards {
+		a, err := p.planDashboard(ctx, spec)
+		if err != nil {
+			return nil, fmt.Errorf("planning dashboard '%s': %w", spec.Name, err)
+		}
+		plan.Actions = append(plan.Actions, *a)
+	}
+	return plan, nil
+}
+
+func (p *planner) planDevice(ctx context.Context, spec DeviceSpec) (*Action, error) {
+	var dev *iotclient.ArduinoDevicev2
+	if spec.ID != "" {
+		d, err := p.client.DeviceShow(ctx, spec.ID)
+		if err != nil {
+			return nil, err
+		}
+		dev = d
+	} else {
+		devs, err := p.client.DeviceList(ctx, spec.Selector)
+		if err != nil {
+			return nil, err
+		}
+		if len(devs) != 1 {
+			return nil, fmt.Errorf("selector must match exactly one device, %d found", len(devs))
+		}
+		dev = &devs[0]
+	}
+	p.deviceIDs[spec.Name] = dev.Id
+
+	a := &Action{Resource: ResourceDevice, Name: spec.Name, ID: dev.Id, Operation: OpNone}
+	tags := make(map[string]string)
+	for _, key := range sortedKeys(spec.Tags) {
+		want := spec.Tags[key]
+		cur, ok := dev.Tags[key]
+		switch {
+		case !ok:
+			a.Changes = append(a.Changes, template.Change{Op: template.OpAdd, Path: "tag " + key, To: want})
+		case fmt.Sprint(cur) != want:
+			a.Changes = append(a.Changes, template.Change{Op: template.OpChange, Path: "tag " + key, From: fmt.Sprint(cur), To: want})
+		default:
+			continue
+		}
+		tags[key] = want
+	}
+	if len(tags) == 0 {
+		return a, nil
+	}
+
+	a.Operation = OpUpdate
+	id := dev.Id
+	a.run = func(ctx context.Context) (string, error) {
+		return id, p.client.DeviceTagsCreate(ctx, id, tags)
+	}
+	return a, nil
+}
+
+func (p *planner) planThing(ctx context.Context, spec ThingSpec) (*Action, error) {
+	want, err := template.LoadThing(spec.Template)
+	if err != nil {
+		return nil, err
+	}
+	name := spec.Name
+	want.Name = &name
+	tags := make(map[string]string)
+	for _, t := range want.Tags {
+		tags[t.Key] = t.Value
+	}
+	for k, v := range spec.Tags {
+		tags[k] = v
+	}
+	want.Tags = nil
+	for _, key := range sortedKeys(tags) {
+		want.Tags = append(want.Tags, iotclient.Tag{Key: key, Value: tags[key]})
+	}
+	var deviceID *string
+	if spec.Device != "" {
+		id := p.deviceIDs[spec.Device]
+		deviceID = &id
+	}
+
+	got, err := p.findThing(ctx, spec.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if got == nil {
+		a := &Action{Resource: ResourceThing, Name: spec.Name, Operation: OpCreate}
+		for _, v := range want.Properties {
+			a.Changes = append(a.Changes, template.Change{Op: template.OpAdd, Path: "variable " + variableName(v), To: v.Type})
+		}
+		for _, t := range want.Tags {
+			a.Changes = append(a.Changes, template.Change{Op: template.OpAdd, Path: "tag " + t.Key, To: t.Value})
+		}
+		if deviceID != nil {
+			a.Changes = append(a.Changes, template.Change{Op: template.OpAdd, Path: "device", To: spec.Device})
+			want.DeviceId = deviceID
+		}
+		a.run = func(ctx context.Context) (string, error) {
+			force := true
+			thing, err := p.client.ThingCreate(ctx, want, force)
+			if err != nil {
+				return "", err
+			}
+			return thing.Id, nil
+		}
+		return a, nil
+	}
+
+	a := &Action{Resource: ResourceThing, Name: spec.Name, ID: got.Id, Operation: OpNone}
+	diff, err := template.DiffThing(want, got)
+	if err != nil {
+		return nil, err
+	}
+	// Variables and tags which are not in the manifest are left untouched
+	for _, c := range diff.Changes {
+		if c.Op != template.OpRemove {
+			a.Changes = append(a.Changes, c)
+		}
+	}
+	bind := deviceID != nil && (got.DeviceId == nil || *got.DeviceId != *deviceID)
+	if bind {
+		from := ""
+		if got.DeviceId != nil {
+			from = *got.DeviceId
+		}
+		a.Changes = append(a.Changes, template.Change{Op: template.OpChange, Path: "device", From: from, To: *deviceID})
+	}
+	if len(a.Changes) == 0 {
+		return a, nil
+	}
+
+	a.Operation = OpUpdate
+	id := got.Id
+	a.run = func(ctx context.Context) (string, error) {
+		if diff.Name != nil || bind {
+			update := &iotclient.ThingUpdate{Name: diff.Name}
+			if bind {
+				update.DeviceId = deviceID
+			}
+			force := true
+			if err := p.client.ThingUpdate(ctx, id, update, force); err != nil {
+				return "", err
+			}
+		}
+		for i := range diff.CreateVariables {
+			if _, err := p.client.PropertyCreate(ctx, id, &diff.CreateVariables[i]); err != nil {
+				return "", err
+			}
+		}
+		for _, propID := range sortedKeys(diff.UpdateVariables) {
+			prop := diff.UpdateVariables[propID]
+			if _, err := p.client.PropertyUpdate(ctx, id, propID, &prop); err != nil {
+				return "", err
+			}
+		}
+		if len(diff.SetTags) > 0 {
+			if err := p.client.ThingTagsCreate(ctx, id, diff.SetTags); err != nil {
+				return "", err
+			}
+		}
+		return id, nil
+	}
+	return a, nil
+}
+
+// findThing looks for the thing recorded in the state.
+// If the state doesn't contain it, a thing with the same name is adopted.
+// Returns nil if the thing doesn't exist.
+func (p *planner) findThing(ctx context.Context, name string) (*iotclient.ArduinoThing, error) {
+	if id, ok := p.state.Things[name]; ok {
+		things, err := p.client.ThingList(ctx, []string{id}, nil, true, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(things) == 1 {
+			return &things[0], nil
+		}
+		// The thing has been deleted: it will be created again
+		delete(p.state.Things, name)
+		return nil, nil
+	}
+
+	if p.thingList == nil {
+		things, err := p.client.ThingList(ctx, nil, nil, true, nil)
+		if err != nil {
+			return nil, err
+		}
+		p.thingList = things
+	}
+	var found *iotclient.ArduinoThing
+	for i := range p.thingList {
+		if p.thingList[i].Name != name {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("multiple things named '%s' found: add the id of the right one to the state file", name)
+		}
+		found = &p.thingList[i]
+	}
+	return found, nil
+}
+
+func (p *planner) planDashboard(ctx context.Context, spec DashboardSpec) (*Action, error) {
+	got, err := p.findDashboard(ctx, spec.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	// The dashboard can be loaded only if the things it references
+	// already contain the variables it needs: if some of them
+	// are still to be applied, the changes are known at apply time only
+	pending := false
+	override := make(map[string]string, len(spec.Things))
+	for placeholder, thing := range spec.Things {
+		a := p.things[thing]
+		if a.Operation != OpNone {
+			pending = true
+		}
+		override[placeholder] = a.ID
+	}
+	var want *iotclient.Dashboardv3
+	if loaded, err := p.loadDashboard(ctx, spec, override); err == nil {
+		want = loaded
+	} else if !pending {
+		return nil, err
+	}
+
+	if got == nil {
+		a := &Action{Resource: ResourceDashboard, Name: spec.Name, Operation: OpCreate}
+		if want != nil {
+			for _, w := range want.Widgets {
+				a.Changes = append(a.Changes, template.Change{Op: template.OpAdd, Path: "widget " + widgetName(w)})
+			}
+		}
+		a.run = func(ctx context.Context) (string, error) {
+			want, err := p.loadDashboard(ctx, spec, p.overrideFromState(spec))
+			if err != nil {
+				return "", err
+			}
+			dashboard, err := p.client.DashboardCreate(ctx, want)
+			if err != nil {
+				return "", err
+			}
+			return dashboard.Id, nil
+		}
+		return a, nil
+	}
+
+	a := &Action{Resource: ResourceDashboard, Name: spec.Name, ID: got.Id, Operation: OpNone}
+	deferred := want == nil
+	if deferred {
+		a.Changes = []template.Change{{Op: template.OpChange, Path: "widgets", To: "(known after the things are applied)"}}
+	} else {
+		a.Changes = template.DiffDashboard(want, got).Changes
+	}
+	if len(a.Changes) == 0 {
+		return a, nil
+	}
+
+	a.Operation = OpUpdate
+	id := got.Id
+	a.run = func(ctx context.Context) (string, error) {
+		want, err := p.loadDashboard(ctx, spec, p.overrideFromState(spec))
+		if err != nil {
+			return "", err
+		}
+		if deferred && template.DiffDashboard(want, got).Empty() {
+			return id, nil
+		}
+		_, err = p.client.DashboardUpdate(ctx, id, want)
+		return id, err
+	}
+	return a, nil
+}
+
+func (p *planner) loadDashboard(ctx context.Context, spec DashboardSpec, override map[string]string) (*iotclient.Dashboardv3, error) {
+	dashboard, err := template.LoadDashboard(ctx, spec.Template, override, p.client)
+	if err != nil {
+		return nil, err
+	}
+	name := spec.Name
+	dashboard.Name = &name
+	return dashboard, nil
+}
+
+// overrideFromState maps the thing placeholders of a dashboard
+// to the thing ids of the state, which are known once the things are applied.
+func (p *planner) overrideFromState(spec DashboardSpec) map[string]string {
+	override := make(map[string]string, len(spec.Things))
+	for placeholder, thing := range spec.Things {
+		override[placeholder] = p.state.Things[thing]
+	}
+	return override
+}
+
+// findDashboard looks for the dashboard recorded in the state.
+// If the state doesn't contain it, a dashboard with the same name is adopted.
+// Returns nil if the dashboard doesn't exist.
+func (p *planner) findDashboard(ctx context.Context, name string) (*iotclient.ArduinoDashboardv3, error) {
+	if p.dashboards == nil {
+		dashboards, err := p.client.DashboardList(ctx)
+		if err != nil {
+			return nil, err
+		}
+		p.dashboards = dashboards
+	}
+
+	if id, ok := p.state.Dashboards[name]; ok {
+		for i := range p.dashboards {
+			if p.dashboards[i].Id == id {
+				return &p.dashboards[i], nil
+			}
+		}
+		// The dashboard has been deleted: it will be created again
+		delete(p.state.Dashboards, name)
+		return nil, nil
+	}
+
+	var found *iotclient.ArduinoDashboardv3
+	for i := range p.dashboards {
+		if p.dashboards[i].Name != name {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("multiple dashboards named '%s' found: add the id of the right one to the state file", name)
+		}
+		found = &p.dashboards[i]
+	}
+	return found, nil
+}
+
+func variableName(p iotclient.Property) string {
+	if p.VariableName != nil && *p.VariableName != "" {
+		return *p.VariableName
+	}
+	return p.Name
+}
+
+func widgetName(w iotclient.Widgetv3) string {
+	if w.Name == nil {
+		return "(" + w.Type + ")"
+	}
+	return *w.Name + " (" + w.Type + ")"
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,390 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package apply
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/arduino/arduino-cloud-cli/internal/template"
+	iotclient "github.com/arduino/iot-client-go/v3"
+	"github.com/google/go-cmp/cmp"
+)
+
+// cloudTest is an in-memory cloud implementing cloudClient.
+type cloudTest struct {
+	devices    []iotclient.ArduinoDevicev2
+	things     []iotclient.ArduinoThing
+	dashboards []iotclient.ArduinoDashboardv3
+	nextID     int
+	calls      []string
+}
+
+func (c *cloudTest) newID(prefix string) string {
+	c.nextID++
+	return fmt.Sprintf("%s-%d", prefix, c.nextID)
+}
+
+func (c *cloudTest) thing(id string) (*iotclient.ArduinoThing, error) {
+	for i := range c.things {
+		if c.things[i].Id == id {
+			return &c.things[i], nil
+		}
+	}
+	return nil, errors.New("thing not found")
+}
+
+func (c *cloudTest) DeviceShow(ctx context.Context, id string) (*iotclient.ArduinoDevicev2, error) {
+	for i := range c.devices {
+		if c.devices[i].Id == id {
+			return &c.devices[i], nil
+		}
+	}
+	return nil, errors.New("device not found")
+}
+
+func (c *cloudTest) DeviceList(ctx context.Context, tags map[string]string) ([]iotclient.ArduinoDevicev2, error) {
+	var devs []iotclient.ArduinoDevicev2
+	for _, d := range c.devices {
+		match := true
+		for k, v := range tags {
+			match = match && fmt.Sprint(d.Tags[k]) == v
+		}
+		if match {
+			devs = append(devs, d)
+		}
+	}
+	return devs, nil
+}
+
+func (c *cloudTest) DeviceTagsCreate(ctx context.Context, id string, tags map[string]string) error {
+	c.calls = append(c.calls, "DeviceTagsCreate")
+	d, err := c.DeviceShow(ctx, id)
+	if err != nil {
+		return err
+	}
+	for k, v := range tags {
+		d.Tags[k] = v
+	}
+	return nil
+}
+
+func (c *cloudTest) ThingShow(ctx context.Context, id string) (*iotclient.ArduinoThing, error) {
+	return c.thing(id)
+}
+
+func (c *cloudTest) PropertyShow(ctx context.Context, thingId, variableId string) (*iotclient.ArduinoProperty, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *cloudTest) ThingList(ctx context.Context, ids []string, device *string, props bool, tags map[string]string) ([]iotclient.ArduinoThing, error) {
+	if ids == nil {
+		return c.things, nil
+	}
+	var things []iotclient.ArduinoThing
+	for _, id := range ids {
+		if t, err := c.thing(id); err == nil {
+			things = append(things, *t)
+		}
+	}
+	return things, nil
+}
+
+func (c *cloudTest) ThingCreate(ctx context.Context, thing *iotclient.ThingCreate, force bool) (*iotclient.ArduinoThing, error) {
+	c.calls = append(c.calls, "ThingCreate")
+	t := iotclient.ArduinoThing{Id: c.newID("thing"), Name: *thing.Name, DeviceId: thing.DeviceId, Tags: map[string]interface{}{}}
+	for _, tag := range thing.Tags {
+		t.Tags[tag.Key] = tag.Value
+	}
+	c.things = append(c.things, t)
+	for i := range thing.Properties {
+		if _, err := c.PropertyCreate(ctx, t.Id, &thing.Properties[i]); err != nil {
+			return nil, err
+		}
+	}
+	return &t, nil
+}
+
+func (c *cloudTest) ThingUpdate(ctx context.Context, id string, thing *iotclient.ThingUpdate, force bool) error {
+	c.calls = append(c.calls, "ThingUpdate")
+	t, err := c.thing(id)
+	if err != nil {
+		return err
+	}
+	if thing.Name != nil {
+		t.Name = *thing.Name
+	}
+	if thing.DeviceId != nil {
+		t.DeviceId = thing.DeviceId
+	}
+	return nil
+}
+
+func (c *cloudTest) ThingTagsCreate(ctx context.Context, id string, tags map[string]string) error {
+	c.calls = append(c.calls, "ThingTagsCreate")
+	t, err := c.thing(id)
+	if err != nil {
+		return err
+	}
+	for k, v := range tags {
+		t.Tags[k] = v
+	}
+	return nil
+}
+
+func (c *cloudTest) PropertyCreate(ctx context.Context, thingId string, property *iotclient.Property) (*iotclient.ArduinoProperty, error) {
+	t, err := c.thing(thingId)
+	if err != nil {
+		return nil, err
+	}
+	p := iotclient.ArduinoProperty{
+		Id: c.newID("property"), ThingId: thingId, Name: property.Name, VariableName: property.VariableName,
+		Type: property.Type, Permission: property.Permission, UpdateStrategy: property.UpdateStrategy,
+		UpdateParameter: property.UpdateParameter,
+	}
+	t.Properties = append(t.Properties, p)
+	return &p, nil
+}
+
+func (c *cloudTest) PropertyUpdate(ctx context.Context, thingId, propertyId string, property *iotclient.Property) (*iotclient.ArduinoProperty, error) {
+	c.calls = append(c.calls, "PropertyUpdate")
+	t, err := c.thing(thingId)
+	if err != nil {
+		return nil, err
+	}
+	for i := range t.Properties {
+		if t.Properties[i].Id == propertyId {
+			t.Properties[i].Type = property.Type
+			t.Properties[i].Permission = property.Permission
+			return &t.Properties[i], nil
+		}
+	}
+	return nil, errors.New("property not found")
+}
+
+func (c *cloudTest) DashboardList(ctx context.Context) ([]iotclient.ArduinoDashboardv3, error) {
+	return c.dashboards, nil
+}
+
+func (c *cloudTest) toDashboard(id string, dashboard *iotclient.Dashboardv3) iotclient.ArduinoDashboardv3 {
+	d := iotclient.ArduinoDashboardv3{Id: id, Name: *dashboard.Name}
+	for _, w := range dashboard.Widgets {
+		widget := iotclient.ArduinoWidgetv3{Name: w.Name, Type: w.Type, X: w.X, Y: w.Y, Width: w.Width, Height: w.Height}
+		for _, v := range w.Variables {
+			widget.Variables = append(widget.Variables, iotclient.ArduinoLinkedvariable{Id: v})
+		}
+		d.Widgets = append(d.Widgets, widget)
+	}
+	return d
+}
+
+func (c *cloudTest) DashboardCreate(ctx context.Context, dashboard *iotclient.Dashboardv3) (*iotclient.ArduinoDashboardv3, error) {
+	c.calls = append(c.calls, "DashboardCreate")
+	d := c.toDashboard(c.newID("dashboard"), dashboard)
+	c.dashboards = append(c.dashboards, d)
+	return &d, nil
+}
+
+func (c *cloudTest) DashboardUpdate(ctx context.Context, id string, dashboard *iotclient.Dashboardv3) (*iotclient.ArduinoDashboardv3, error) {
+	c.calls = append(c.calls, "DashboardUpdate")
+	for i := range c.dashboards {
+		if c.dashboards[i].Id == id {
+			c.dashboards[i] = c.toDashboard(id, dashboard)
+			return &c.dashboards[i], nil
+		}
+	}
+	return nil, errors.New("dashboard not found")
+}
+
+func operations(p *Plan) []string {
+	var ops []string
+	for _, a := range p.Actions {
+		ops = append(ops, a.Resource+" "+a.Operation)
+	}
+	return ops
+}
+
+func TestApply(t *testing.T) {
+	manifest, err := LoadManifest("testdata/fleet.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	statePath := filepath.Join(t.TempDir(), "fleet.state.json")
+	cloud := &cloudTest{
+		devices: []iotclient.ArduinoDevicev2{
+			{Id: "device-1", Name: "board", Tags: map[string]interface{}{"location": "greenhouse"}},
+			{Id: "device-2", Name: "other", Tags: map[string]interface{}{"location": "garage"}},
+		},
+	}
+
+	plan := func() *Plan {
+		t.Helper()
+		state, err := LoadState(statePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := newPlan(context.TODO(), manifest, state, cloud)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.statePath = statePath
+		return p
+	}
+
+	// Everything is created
+	p := plan()
+	want := []string{"device update", "thing create", "dashboard create"}
+	if !cmp.Equal(operations(p), want) {
+		t.Fatalf("Wrong plan, diff:\n%s", cmp.Diff(want, operations(p)))
+	}
+	if err := p.Apply(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	thing := cloud.things[0]
+	if thing.DeviceId == nil || *thing.DeviceId != "device-1" {
+		t.Errorf("Thing not bound to device-1: %v", thing.DeviceId)
+	}
+	if thing.Tags["room"] != "greenhouse" || thing.Tags["zone"] != "north" {
+		t.Errorf("Wrong thing tags: %v", thing.Tags)
+	}
+	if got := cloud.dashboards[0].Widgets[0].Variables[0].Id; got != thing.Properties[0].Id {
+		t.Errorf("Dashboard widget linked to %s instead of %s", got, thing.Properties[0].Id)
+	}
+	state, err := LoadState(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantState := &State{
+		Devices:    map[string]string{"greenhouse-board": "device-1"},
+		Things:     map[string]string{"Greenhouse": thing.Id},
+		Dashboards: map[string]string{"Greenhouse": cloud.dashboards[0].Id},
+	}
+	if !cmp.Equal(state, wantState) {
+		t.Errorf("Wrong state, diff:\n%s", cmp.Diff(wantState, state))
+	}
+
+	// A second apply doesn't change anything
+	cloud.calls = nil
+	p = plan()
+	want = []string{"device none", "thing none", "dashboard none"}
+	if !cmp.Equal(operations(p), want) {
+		t.Fatalf("Wrong plan, diff:\n%s", cmp.Diff(want, operations(p)))
+	}
+	if err := p.Apply(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if len(cloud.calls) != 0 {
+		t.Errorf("Unexpected calls: %v", cloud.calls)
+	}
+
+	// Drift is reverted, extra tags are left untouched
+	cloud.things[0].Properties[0].Type = "TEMPERATURE_F"
+	cloud.things[0].Tags["extra"] = "yes"
+	cloud.things[0].DeviceId = nil
+	p = plan()
+	want = []string{"device none", "thing update", "dashboard none"}
+	if !cmp.Equal(operations(p), want) {
+		t.Fatalf("Wrong plan, diff:\n%s", cmp.Diff(want, operations(p)))
+	}
+	wantChanges := []template.Change{
+		{Op: template.OpChange, Path: "variable temperature type", From: "TEMPERATURE_F", To: "TEMPERATURE_C"},
+		{Op: template.OpChange, Path: "device", To: "device-1"},
+	}
+	if !cmp.Equal(p.Actions[1].Changes, wantChanges) {
+		t.Errorf("Wrong changes, diff:\n%s", cmp.Diff(wantChanges, p.Actions[1].Changes))
+	}
+	if err := p.Apply(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(cloud.calls, ","); got != "ThingUpdate,PropertyUpdate" {
+		t.Errorf("Unexpected calls: %s", got)
+	}
+	if cloud.things[0].Tags["extra"] != "yes" {
+		t.Error("Tag not in manifest has been removed")
+	}
+}
+
+func TestLoadManifest(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		wantErr  string
+	}{
+		{
+			name:     "valid",
+			manifest: "devices: [{name: d, id: x}]\nthings: [{name: t, template: t.yaml, device: d}]\ndashboards: [{name: b, template: b.yaml, things: {thing: t}}]",
+		},
+		{
+			name:     "unknown-field",
+			manifest: "things: [{name: t, template: t.yaml, devise: d}]",
+			wantErr:  "field devise not found",
+		},
+		{
+			name:     "id-and-selector",
+			manifest: "devices: [{name: d, id: x, selector: {a: b}}]",
+			wantErr:  "exactly one of id and selector",
+		},
+		{
+			name:     "unknown-device",
+			manifest: "things: [{name: t, template: t.yaml, device: d}]",
+			wantErr:  "device 'd' not found",
+		},
+		{
+			name:     "device-bound-twice",
+			manifest: "devices: [{name: d, id: x}]\nthings: [{name: t, template: t.yaml, device: d}, {name: u, template: t.yaml, device: d}]",
+			wantErr:  "bound to both",
+		},
+		{
+			name:     "duplicate-thing",
+			manifest: "things: [{name: t, template: t.yaml}, {name: t, template: t.yaml}]",
+			wantErr:  "duplicate thing",
+		},
+		{
+			name:     "unknown-thing",
+			manifest: "dashboards: [{name: b, template: b.yaml, things: {thing: t}}]",
+			wantErr:  "thing 't' not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "fleet.yaml")
+			if err := os.WriteFile(file, []byte(tt.manifest), 0600); err != nil {
+				t.Fatal(err)
+			}
+			m, err := LoadManifest(file)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if want := filepath.Join(filepath.Dir(file), "t.yaml"); m.Things[0].Template != want {
+					t.Errorf("Template path not resolved: %s", m.Things[0].Template)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
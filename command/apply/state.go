@@ -0,0 +1,83 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package apply
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// State records the ids of the resources managed by a manifest,
+// keyed by their name in the manifest.
+type State struct {
+	Devices    map[string]string `json:"devices"`
+	Things     map[string]string `json:"things"`
+	Dashboards map[string]string `json:"dashboards"`
+}
+
+// DefaultStatePath returns the path of the state file of a manifest:
+// the manifest path with its extension replaced by '.state.json'.
+func DefaultStatePath(manifest string) string {
+	return strings.TrimSuffix(manifest, filepath.Ext(manifest)) + ".state.json"
+}
+
+// LoadState reads a state file.
+// An empty state is returned if the file doesn't exist.
+func LoadState(path string) (*State, error) {
+	s := &State{}
+	content, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("reading state file: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(content, s); err != nil {
+			return nil, fmt.Errorf("parsing state file %s: %w", path, err)
+		}
+	}
+	if s.Devices == nil {
+		s.Devices = make(map[string]string)
+	}
+	if s.Things == nil {
+		s.Things = make(map[string]string)
+	}
+	if s.Dashboards == nil {
+		s.Dashboards = make(map[string]string)
+	}
+	return s, nil
+}
+
+// Save writes the state file, replacing it atomically.
+func (s *State) Save(path string) error {
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(content, '\n'), 0600); err != nil {
+		return fmt.Errorf("writing state file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("writing state file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package dashboard
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/arduino/arduino-cloud-cli/internal/iot"
+	"github.com/arduino/arduino-cloud-cli/internal/template"
+)
+
+// DiffParams contains the parameters needed to
+// compare a dashboard with a dashboard template.
+type DiffParams struct {
+	ID       string            // Dashboard ID
+	Template string            // Path of the template file
+	Override map[string]string // Thing ids of the template mapped to the ones of the extracted dashboard
+}
+
+// Diff compares a dashboard on Arduino IoT Cloud with a dashboard template.
+// The dashboard is extracted as a template before the comparison,
+// so that variables are compared by thing and variable name.
+// An empty list is returned if the dashboard matches the template.
+func Diff(ctx context.Context, params *DiffParams, cred *config.Credentials) ([]template.Change, error) {
+	iotClient, err := iot.NewClient(cred)
+	if err != nil {
+		return nil, err
+	}
+
+	want, err := template.LoadDashboardTemplate(params.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	dashboard, err := iotClient.DashboardTemplate(ctx, params.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", "cannot retrieve dashboard", err)
+	}
+	got, err := template.FromDashboard(dashboard)
+	if err != nil {
+		return nil, err
+	}
+
+	return template.DiffDashboardTemplate(want, got, params.Override).Changes, nil
+}
@@ -0,0 +1,255 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package device
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/arduino/arduino-cloud-cli/internal/iot"
+	iotclient "github.com/arduino/iot-client-go/v3"
+)
+
+// Values of device status events.
+const (
+	EventConnected    = "CONNECTED"
+	EventDisconnected = "DISCONNECTED"
+)
+
+// eventsPageSize is the number of status events requested at once.
+const eventsPageSize = 1000
+
+// EventsParams contains the parameters needed to retrieve
+// the status events of one or more devices.
+type EventsParams struct {
+	DeviceID string            // ID of the device, mutually exclusive with Tags
+	Tags     map[string]string // Tags of the devices
+	From     time.Time
+	To       time.Time
+}
+
+// EventInfo contains a connection or disconnection event of a device.
+type EventInfo struct {
+	Time       time.Time `json:"time"`
+	DeviceID   string    `json:"device_id"`
+	DeviceName string    `json:"device_name"`
+	Status     string    `json:"status"`
+}
+
+// StatusHistoryInfo summarizes the connection history of a device in a time range.
+type StatusHistoryInfo struct {
+	DeviceID         string     `json:"device_id"`
+	DeviceName       string     `json:"device_name"`
+	Status           *string    `json:"status,omitempty"`
+	LastSeen         *time.Time `json:"last_seen,omitempty"`
+	Connections      int        `json:"connections"`
+	Disconnections   int        `json:"disconnections"`
+	UptimeSeconds    int64      `json:"uptime_seconds"`
+	UptimePercent    float64    `json:"uptime_percentage"`
+	LastConnected    *time.Time `json:"last_connected,omitempty"`
+	LastDisconnected *time.Time `json:"last_disconnected,omitempty"`
+}
+
+type eventsClient interface {
+	DeviceShow(ctx context.Context, id string) (*iotclient.ArduinoDevicev2, error)
+	DeviceList(ctx context.Context, tags map[string]string) ([]iotclient.ArduinoDevicev2, error)
+	DeviceStatusEvents(ctx context.Context, id string, start time.Time, limit int32) ([]iotclient.ArduinoDevicev2StatusEvent, error)
+}
+
+// Events command is used to retrieve the connection and
+// disconnection events of devices in a time range, sorted by time.
+func Events(ctx context.Context, params *EventsParams, cred *config.Credentials) ([]EventInfo, error) {
+	if err := validateEventsParams(params); err != nil {
+		return nil, err
+	}
+	iotClient, err := iot.NewClient(cred)
+	if err != nil {
+		return nil, err
+	}
+	return events(ctx, iotClient, params)
+}
+
+// StatusHistory command is used to summarize the connection
+// history of devices in a time range.
+func StatusHistory(ctx context.Context, params *EventsParams, cred *config.Credentials) ([]StatusHistoryInfo, error) {
+	if err := validateEventsParams(params); err != nil {
+		return nil, err
+	}
+	iotClient, err := iot.NewClient(cred)
+	if err != nil {
+		return nil, err
+	}
+	return statusHistory(ctx, iotClient, params)
+}
+
+func validateEventsParams(params *EventsParams) error {
+	if (params.DeviceID == "") == (len(params.Tags) == 0) {
+		return errors.New("provide either a device ID or tags")
+	}
+	if !params.To.After(params.From) {
+		return errors.New("the end of the time range must follow its start")
+	}
+	return nil
+}
+
+func events(ctx context.Context, client eventsClient, params *EventsParams) ([]EventInfo, error) {
+	devices, err := findDevices(ctx, client, params)
+	if err != nil {
+		return nil, err
+	}
+	var infos []EventInfo
+	for _, d := range devices {
+		evs, err := deviceEvents(ctx, client, d.Id, params.From, params.To)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range evs {
+			infos = append(infos, EventInfo{Time: e.CreatedAt, DeviceID: d.Id, DeviceName: d.Name, Status: e.Value})
+		}
+	}
+	sort.SliceStable(infos, func(i, j int) bool { return infos[i].Time.Before(infos[j].Time) })
+	return infos, nil
+}
+
+func statusHistory(ctx context.Context, client eventsClient, params *EventsParams) ([]StatusHistoryInfo, error) {
+	devices, err := findDevices(ctx, client, params)
+	if err != nil {
+		return nil, err
+	}
+	var infos []StatusHistoryInfo
+	for _, d := range devices {
+		evs, err := deviceEvents(ctx, client, d.Id, params.From, params.To)
+		if err != nil {
+			return nil, err
+		}
+		info := summarizeEvents(evs, isOnline(d.DeviceStatus), params.From, params.To)
+		info.DeviceID = d.Id
+		info.DeviceName = d.Name
+		info.Status = d.DeviceStatus
+		info.LastSeen = d.LastActivityAt
+		infos = append(infos, *info)
+	}
+	return infos, nil
+}
+
+func findDevices(ctx context.Context, client eventsClient, params *EventsParams) ([]iotclient.ArduinoDevicev2, error) {
+	if params.DeviceID != "" {
+		dev, err := client.DeviceShow(ctx, params.DeviceID)
+		if err != nil {
+			return nil, err
+		}
+		return []iotclient.ArduinoDevicev2{*dev}, nil
+	}
+	devices, err := client.DeviceList(ctx, params.Tags)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].Name < devices[j].Name })
+	return devices, nil
+}
+
+// deviceEvents retrieves all the status events of a device
+// in the [from, to) time range, sorted by time.
+func deviceEvents(ctx context.Context, client eventsClient, id string, from, to time.Time) ([]iotclient.ArduinoDevicev2StatusEvent, error) {
+	var all []iotclient.ArduinoDevicev2StatusEvent
+	start := from
+	// Number of events, by value, already retrieved at the start of the next page
+	retrieved := make(map[string]int)
+	for {
+		page, err := client.DeviceStatusEvents(ctx, id, start, eventsPageSize)
+		if err != nil {
+			return nil, err
+		}
+		sort.SliceStable(page, func(i, j int) bool { return page[i].CreatedAt.Before(page[j].CreatedAt) })
+
+		added := 0
+		for _, e := range page {
+			if e.CreatedAt.Before(from) || e.CreatedAt.Before(start) {
+				continue
+			}
+			// Events at the start of a page following the first one may have
+			// already been retrieved with the previous page. Events have no ID,
+			// so only as many of them as already retrieved are skipped: other
+			// events sharing the same time are kept.
+			if e.CreatedAt.Equal(start) && retrieved[e.Value] > 0 {
+				retrieved[e.Value]--
+				continue
+			}
+			if !e.CreatedAt.Before(to) {
+				break
+			}
+			all = append(all, e)
+			added++
+		}
+		if len(page) < eventsPageSize || added == 0 {
+			return all, nil
+		}
+		start = all[len(all)-1].CreatedAt
+		if !start.Before(to) {
+			return all, nil
+		}
+		clear(retrieved)
+		for i := len(all) - 1; i >= 0 && all[i].CreatedAt.Equal(start); i-- {
+			retrieved[all[i].Value]++
+		}
+	}
+}
+
+// summarizeEvents computes the uptime of a device in the [from, to) time range.
+// The status of the device at the start of the range is inferred
+// from the first event or, if there are no events, from its current status.
+func summarizeEvents(events []iotclient.ArduinoDevicev2StatusEvent, online bool, from, to time.Time) *StatusHistoryInfo {
+	info := &StatusHistoryInfo{}
+	if len(events) > 0 {
+		online = events[0].Value != EventConnected
+	}
+
+	var uptime time.Duration
+	last := from
+	for i := range events {
+		e := &events[i]
+		if online {
+			uptime += e.CreatedAt.Sub(last)
+		}
+		last = e.CreatedAt
+		switch e.Value {
+		case EventConnected:
+			online = true
+			info.Connections++
+			info.LastConnected = &e.CreatedAt
+		case EventDisconnected:
+			online = false
+			info.Disconnections++
+			info.LastDisconnected = &e.CreatedAt
+		}
+	}
+	if online {
+		uptime += to.Sub(last)
+	}
+
+	info.UptimeSeconds = int64(uptime / time.Second)
+	info.UptimePercent = float64(uptime) / float64(to.Sub(from)) * 100
+	return info
+}
+
+func isOnline(status *string) bool {
+	return status != nil && *status == "ONLINE"
+}
@@ -0,0 +1,199 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package device
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	iotclient "github.com/arduino/iot-client-go/v3"
+	"github.com/google/go-cmp/cmp"
+)
+
+type eventsTest struct {
+	devices []iotclient.ArduinoDevicev2
+	events  map[string][]iotclient.ArduinoDevicev2StatusEvent
+}
+
+func (c *eventsTest) DeviceShow(ctx context.Context, id string) (*iotclient.ArduinoDevicev2, error) {
+	for i := range c.devices {
+		if c.devices[i].Id == id {
+			return &c.devices[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *eventsTest) DeviceList(ctx context.Context, tags map[string]string) ([]iotclient.ArduinoDevicev2, error) {
+	return c.devices, nil
+}
+
+// DeviceStatusEvents returns the most recent events first, like the cloud does.
+func (c *eventsTest) DeviceStatusEvents(ctx context.Context, id string, start time.Time, limit int32) ([]iotclient.ArduinoDevicev2StatusEvent, error) {
+	var page []iotclient.ArduinoDevicev2StatusEvent
+	for _, e := range c.events[id] {
+		if !e.CreatedAt.Before(start) && len(page) < int(limit) {
+			page = append([]iotclient.ArduinoDevicev2StatusEvent{e}, page...)
+		}
+	}
+	return page, nil
+}
+
+func TestEvents(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	// A device reconnecting every minute needs many pages
+	var flapping []iotclient.ArduinoDevicev2StatusEvent
+	for i := 0; i < 3*eventsPageSize; i++ {
+		value := EventDisconnected
+		if i%2 == 1 {
+			value = EventConnected
+		}
+		flapping = append(flapping, iotclient.ArduinoDevicev2StatusEvent{CreatedAt: from.Add(time.Duration(i) * time.Minute), Value: value})
+	}
+	online := "ONLINE"
+	client := &eventsTest{
+		devices: []iotclient.ArduinoDevicev2{
+			{Id: "dev-1", Name: "flapping", DeviceStatus: &online},
+			{Id: "dev-2", Name: "stable", DeviceStatus: &online},
+		},
+		events: map[string][]iotclient.ArduinoDevicev2StatusEvent{"dev-1": flapping},
+	}
+
+	evs, err := events(context.Background(), client, &EventsParams{DeviceID: "dev-1", From: from, To: to})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Events after the end of the range are excluded
+	if len(evs) != 24*60 {
+		t.Fatalf("expected %d events, got %d", 24*60, len(evs))
+	}
+	for i := range evs {
+		if !evs[i].Time.Equal(from.Add(time.Duration(i) * time.Minute)) {
+			t.Fatalf("event %d: unexpected time %s", i, evs[i].Time)
+		}
+	}
+
+	history, err := statusHistory(context.Background(), client, &EventsParams{Tags: map[string]string{"a": "b"}, From: from, To: to})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]float64)
+	for _, h := range history {
+		got[h.DeviceName] = math.Round(h.UptimePercent)
+	}
+	want := map[string]float64{"flapping": 50, "stable": 100}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected uptime (-want +got):\n%s", diff)
+	}
+}
+
+func TestEventsSameTimeAcrossPages(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	// The first page ends with the first of some events sharing the same time
+	var evs []iotclient.ArduinoDevicev2StatusEvent
+	for i := 0; i < eventsPageSize-1; i++ {
+		evs = append(evs, iotclient.ArduinoDevicev2StatusEvent{CreatedAt: from.Add(time.Duration(i) * time.Second), Value: EventConnected})
+	}
+	boundary := from.Add(time.Hour)
+	for _, v := range []string{EventDisconnected, EventConnected, EventDisconnected} {
+		evs = append(evs, iotclient.ArduinoDevicev2StatusEvent{CreatedAt: boundary, Value: v})
+	}
+	evs = append(evs, iotclient.ArduinoDevicev2StatusEvent{CreatedAt: boundary.Add(time.Second), Value: EventConnected})
+	client := &eventsTest{
+		devices: []iotclient.ArduinoDevicev2{{Id: "dev-1", Name: "device"}},
+		events:  map[string][]iotclient.ArduinoDevicev2StatusEvent{"dev-1": evs},
+	}
+
+	got, err := events(context.Background(), client, &EventsParams{DeviceID: "dev-1", From: from, To: to})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(evs) {
+		t.Fatalf("expected %d events, got %d", len(evs), len(got))
+	}
+	count := make(map[string]int)
+	for _, e := range got {
+		if e.Time.Equal(boundary) {
+			count[e.Status]++
+		}
+	}
+	want := map[string]int{EventConnected: 1, EventDisconnected: 2}
+	if diff := cmp.Diff(want, count); diff != "" {
+		t.Errorf("unexpected events at page boundary (-want +got):\n%s", diff)
+	}
+}
+
+func TestSummarizeEvents(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(10 * time.Hour)
+	at := func(h int, value string) iotclient.ArduinoDevicev2StatusEvent {
+		return iotclient.ArduinoDevicev2StatusEvent{CreatedAt: from.Add(time.Duration(h) * time.Hour), Value: value}
+	}
+
+	tests := []struct {
+		name        string
+		events      []iotclient.ArduinoDevicev2StatusEvent
+		online      bool
+		wantUptime  float64
+		wantConn    int
+		wantDisconn int
+	}{
+		{
+			name:       "no-events-online",
+			online:     true,
+			wantUptime: 100,
+		},
+		{
+			name:       "no-events-offline",
+			wantUptime: 0,
+		},
+		{
+			name:        "online-at-start",
+			events:      []iotclient.ArduinoDevicev2StatusEvent{at(2, EventDisconnected), at(5, EventConnected)},
+			wantUptime:  70,
+			wantConn:    1,
+			wantDisconn: 1,
+		},
+		{
+			name:        "offline-at-start",
+			events:      []iotclient.ArduinoDevicev2StatusEvent{at(1, EventConnected), at(4, EventDisconnected)},
+			online:      true,
+			wantUptime:  30,
+			wantConn:    1,
+			wantDisconn: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := summarizeEvents(tt.events, tt.online, from, to)
+			if info.UptimePercent != tt.wantUptime {
+				t.Errorf("expected uptime %v%%, got %v%%", tt.wantUptime, info.UptimePercent)
+			}
+			if info.Connections != tt.wantConn || info.Disconnections != tt.wantDisconn {
+				t.Errorf("expected %d connections and %d disconnections, got %d and %d",
+					tt.wantConn, tt.wantDisconn, info.Connections, info.Disconnections)
+			}
+		})
+	}
+}
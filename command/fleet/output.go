@@ -0,0 +1,107 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package fleet
+
+import (
+	"encoding/csv"
+	"html/template"
+	"io"
+	"time"
+)
+
+var csvHeader = []string{
+	"fqbn", "id", "name", "status", "last_seen", "thing_id", "firmware_sha256",
+	"last_ota_id", "last_ota_status", "last_ota_ended_at", "last_ota_error",
+}
+
+// WriteCSV writes a row for each device of the report.
+func WriteCSV(w io.Writer, r *Report) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, g := range r.Groups {
+		for _, d := range g.Devices {
+			row := []string{g.FQBN, d.ID, d.Name, d.Status, formatTime(d.LastSeen), d.ThingID, d.FirmwareHash}
+			if d.LastOta != nil {
+				row = append(row, d.LastOta.ID, d.LastOta.Status, d.LastOta.EndedAt, d.LastOta.ErrorReason)
+			} else {
+				row = append(row, "", "", "", "")
+			}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"time": formatTime,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Fleet report</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+th { background: #eee; }
+</style>
+</head>
+<body>
+<h1>Fleet report</h1>
+<p>Generated at {{.GeneratedAt.Format "2006-01-02T15:04:05Z07:00"}}</p>
+<h2>Devices by FQBN</h2>
+<table>
+<tr><th>FQBN</th><th>Devices</th><th>Online</th><th>Offline</th><th>Unknown</th></tr>
+{{- range .Groups}}
+<tr><td>{{.FQBN}}</td><td>{{.Count}}</td><td>{{index .Statuses "ONLINE"}}</td><td>{{index .Statuses "OFFLINE"}}</td><td>{{index .Statuses "UNKNOWN"}}</td></tr>
+{{- end}}
+</table>
+{{- range .Groups}}
+<h2>{{.FQBN}}</h2>
+{{template "devices" .Devices}}
+{{- end}}
+<h2>Devices without a thing</h2>
+{{template "devices" .Unbound}}
+<h2>Devices offline for more than {{.OfflineDays}} days</h2>
+{{template "devices" .Offline}}
+</body>
+</html>
+{{define "devices"}}{{if .}}<table>
+<tr><th>Name</th><th>ID</th><th>Status</th><th>Last seen</th><th>Thing</th><th>Firmware SHA-256</th><th>Last OTA</th></tr>
+{{- range .}}
+<tr><td>{{.Name}}</td><td>{{.ID}}</td><td>{{.Status}}</td><td>{{time .LastSeen}}</td><td>{{.ThingID}}</td><td>{{.FirmwareHash}}</td><td>{{with .LastOta}}{{.Status}}{{with .ErrorReason}}: {{.}}{{end}}{{end}}</td></tr>
+{{- end}}
+</table>{{else}}<p>None.</p>{{end}}{{end}}
+`))
+
+// WriteHTML writes the report as a standalone HTML page.
+func WriteHTML(w io.Writer, r *Report) error {
+	return htmlReport.Execute(w, r)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,197 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package fleet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/arduino/arduino-cloud-cli/command/ota"
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/arduino/arduino-cloud-cli/internal/iot"
+	otaapi "github.com/arduino/arduino-cloud-cli/internal/ota-api"
+	iotclient "github.com/arduino/iot-client-go/v3"
+	"github.com/sirupsen/logrus"
+)
+
+// Device statuses, as reported by Arduino IoT Cloud.
+const (
+	StatusOnline  = "ONLINE"
+	StatusOffline = "OFFLINE"
+	StatusUnknown = "UNKNOWN"
+)
+
+// DefaultOfflineDays is the default number of days after which
+// a device that is not online is reported as offline.
+const DefaultOfflineDays = 7
+
+// ReportParams contains the parameters needed to build a fleet report.
+type ReportParams struct {
+	Tags        map[string]string // If tags are provided, only devices that have all these tags are reported
+	OfflineDays int               // Devices not seen for more than these days are reported as offline
+}
+
+// Report summarizes the health, firmware and OTA state of a fleet of devices.
+type Report struct {
+	GeneratedAt time.Time      `json:"generated_at"`
+	OfflineDays int            `json:"offline_days"`
+	Groups      []Group        `json:"fqbns"`
+	Unbound     []DeviceReport `json:"devices_without_thing"`
+	Offline     []DeviceReport `json:"offline_devices"`
+}
+
+// Group contains the devices sharing the same FQBN.
+type Group struct {
+	FQBN     string         `json:"fqbn"`
+	Count    int            `json:"count"`
+	Statuses map[string]int `json:"statuses"`
+	Devices  []DeviceReport `json:"devices"`
+}
+
+// DeviceReport contains the state of a device.
+type DeviceReport struct {
+	ID           string     `json:"id"`
+	Name         string     `json:"name"`
+	FQBN         string     `json:"fqbn"`
+	Status       string     `json:"status"`
+	LastSeen     *time.Time `json:"last_seen,omitempty"`
+	ThingID      string     `json:"thing_id,omitempty"`
+	FirmwareHash string     `json:"firmware_sha256,omitempty"`
+	LastOta      *OtaReport `json:"last_ota,omitempty"`
+}
+
+// OtaReport contains the result of the last OTA of a device.
+type OtaReport struct {
+	ID          string `json:"id"`
+	Status      string `json:"status"`
+	StartedAt   string `json:"started_at,omitempty"`
+	EndedAt     string `json:"ended_at,omitempty"`
+	ErrorReason string `json:"error_reason,omitempty"`
+}
+
+type deviceLister interface {
+	DeviceList(ctx context.Context, tags map[string]string) ([]iotclient.ArduinoDevicev2, error)
+}
+
+type otaStatusGetter interface {
+	GetOtaLastStatusByDeviceID(deviceID string) (*otaapi.OtaStatusList, error)
+}
+
+// BuildReport command is used to build a report
+// of the devices of Arduino IoT Cloud, grouped by FQBN.
+func BuildReport(ctx context.Context, params *ReportParams, cred *config.Credentials) (*Report, error) {
+	iotClient, err := iot.NewClient(cred)
+	if err != nil {
+		return nil, err
+	}
+	otapi := otaapi.NewClient(cred)
+	return buildReport(ctx, iotClient, otapi, params, time.Now())
+}
+
+func buildReport(ctx context.Context, lister deviceLister, otapi otaStatusGetter, params *ReportParams, now time.Time) (*Report, error) {
+	devices, err := lister.DeviceList(ctx, params.Tags)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].Name < devices[j].Name })
+
+	report := &Report{GeneratedAt: now, OfflineDays: params.OfflineDays}
+	offlineSince := now.AddDate(0, 0, -params.OfflineDays)
+	groups := make(map[string]*Group)
+	for i := range devices {
+		d := getDeviceReport(&devices[i])
+		if d.LastOta, err = lastOta(otapi, d.ID); err != nil {
+			return nil, fmt.Errorf("retrieving last OTA of device %s: %w", d.Name, err)
+		}
+
+		g, ok := groups[d.FQBN]
+		if !ok {
+			g = &Group{FQBN: d.FQBN, Statuses: make(map[string]int)}
+			groups[d.FQBN] = g
+		}
+		g.Count++
+		g.Statuses[d.Status]++
+		g.Devices = append(g.Devices, *d)
+
+		if d.ThingID == "" {
+			report.Unbound = append(report.Unbound, *d)
+		}
+		if d.Status != StatusOnline && (d.LastSeen == nil || d.LastSeen.Before(offlineSince)) {
+			report.Offline = append(report.Offline, *d)
+		}
+	}
+
+	for _, g := range groups {
+		report.Groups = append(report.Groups, *g)
+	}
+	sort.Slice(report.Groups, func(i, j int) bool { return report.Groups[i].FQBN < report.Groups[j].FQBN })
+	return report, nil
+}
+
+func getDeviceReport(device *iotclient.ArduinoDevicev2) *DeviceReport {
+	d := &DeviceReport{
+		ID:       device.Id,
+		Name:     device.Name,
+		FQBN:     device.Type,
+		Status:   StatusUnknown,
+		LastSeen: device.LastActivityAt,
+	}
+	if device.Fqbn != nil && *device.Fqbn != "" {
+		d.FQBN = *device.Fqbn
+	}
+	if device.DeviceStatus != nil && *device.DeviceStatus != "" {
+		d.Status = *device.DeviceStatus
+	}
+	if device.Thing != nil {
+		d.ThingID = device.Thing.Id
+	}
+	// The tag is only written by OTA uploads waiting for completion:
+	// devices updated otherwise have no firmware hash
+	if hash, ok := device.Tags[ota.FirmwareHashTag].(string); ok {
+		d.FirmwareHash = hash
+	}
+	return d
+}
+
+// lastOta retrieves the last OTA of a device.
+// Returns nil if the device never received an OTA.
+func lastOta(otapi otaStatusGetter, deviceID string) (*OtaReport, error) {
+	res, err := otapi.GetOtaLastStatusByDeviceID(deviceID)
+	if errors.Is(err, otaapi.ErrNotFound) {
+		// Devices that never received an OTA are not known by the OTA service
+		logrus.Debugf("No OTA found for device %s: %v", deviceID, err)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if res == nil || len(res.Ota) == 0 {
+		return nil, nil
+	}
+	o := res.Ota[0]
+	return &OtaReport{
+		ID:          o.ID,
+		Status:      o.MapStatus(),
+		StartedAt:   o.StartedAt,
+		EndedAt:     o.EndedAt,
+		ErrorReason: o.ErrorReason,
+	}, nil
+}
@@ -0,0 +1,171 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package fleet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	otaapi "github.com/arduino/arduino-cloud-cli/internal/ota-api"
+	iotclient "github.com/arduino/iot-client-go/v3"
+	"github.com/google/go-cmp/cmp"
+)
+
+type deviceListerTest struct {
+	devices []iotclient.ArduinoDevicev2
+}
+
+func (d *deviceListerTest) DeviceList(ctx context.Context, tags map[string]string) ([]iotclient.ArduinoDevicev2, error) {
+	return d.devices, nil
+}
+
+type otaStatusGetterTest struct {
+	otas map[string]otaapi.Ota
+	err  error // Returned for all the devices, if set
+}
+
+func (o *otaStatusGetterTest) GetOtaLastStatusByDeviceID(deviceID string) (*otaapi.OtaStatusList, error) {
+	if o.err != nil {
+		return nil, o.err
+	}
+	ota, ok := o.otas[deviceID]
+	if !ok {
+		return nil, fmt.Errorf("device-id %s %w", deviceID, otaapi.ErrNotFound)
+	}
+	return &otaapi.OtaStatusList{Ota: []otaapi.Ota{ota}}, nil
+}
+
+func testFleet(now time.Time) (*deviceListerTest, *otaStatusGetterTest) {
+	str := func(s string) *string { return &s }
+	daysAgo := func(n int) *time.Time {
+		t := now.AddDate(0, 0, -n)
+		return &t
+	}
+	lister := &deviceListerTest{devices: []iotclient.ArduinoDevicev2{
+		{
+			Id: "dev-1", Name: "kitchen", Fqbn: str("arduino:samd:nano_33_iot"), DeviceStatus: str(StatusOnline),
+			LastActivityAt: daysAgo(0), Thing: &iotclient.ArduinoThing{Id: "thing-1"},
+			Tags: map[string]interface{}{"firmware_sha256": "abc123"},
+		},
+		{
+			Id: "dev-2", Name: "garage", Fqbn: str("arduino:samd:nano_33_iot"), DeviceStatus: str(StatusOffline),
+			LastActivityAt: daysAgo(30),
+		},
+		{
+			Id: "dev-3", Name: "attic", Fqbn: str("arduino:esp32:nano_nora"), DeviceStatus: str(StatusOffline),
+			LastActivityAt: daysAgo(2), Thing: &iotclient.ArduinoThing{Id: "thing-3"},
+		},
+	}}
+	otapi := &otaStatusGetterTest{otas: map[string]otaapi.Ota{
+		"dev-1": {ID: "ota-1", DeviceID: "dev-1", Status: "succeeded", EndedAt: "2024-01-01T10:00:00Z"},
+		"dev-2": {ID: "ota-2", DeviceID: "dev-2", Status: "failed", ErrorReason: "timeout"},
+	}}
+	return lister, otapi
+}
+
+func TestBuildReport(t *testing.T) {
+	now := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	lister, otapi := testFleet(now)
+
+	report, err := buildReport(context.Background(), lister, otapi, &ReportParams{OfflineDays: 7}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Groups) != 2 {
+		t.Fatalf("expected 2 fqbn groups, got %d", len(report.Groups))
+	}
+	nano := report.Groups[1]
+	if nano.FQBN != "arduino:samd:nano_33_iot" || nano.Count != 2 {
+		t.Errorf("unexpected group %s with %d devices", nano.FQBN, nano.Count)
+	}
+	if diff := cmp.Diff(map[string]int{StatusOnline: 1, StatusOffline: 1}, nano.Statuses); diff != "" {
+		t.Errorf("unexpected statuses (-want +got):\n%s", diff)
+	}
+
+	names := func(devs []DeviceReport) []string {
+		var n []string
+		for _, d := range devs {
+			n = append(n, d.Name)
+		}
+		return n
+	}
+	if diff := cmp.Diff([]string{"garage"}, names(report.Unbound)); diff != "" {
+		t.Errorf("unexpected devices without thing (-want +got):\n%s", diff)
+	}
+	// attic is offline since less than 7 days
+	if diff := cmp.Diff([]string{"garage"}, names(report.Offline)); diff != "" {
+		t.Errorf("unexpected offline devices (-want +got):\n%s", diff)
+	}
+
+	kitchen := nano.Devices[1]
+	if kitchen.FirmwareHash != "abc123" || kitchen.LastOta == nil || kitchen.LastOta.Status != "Succeeded" {
+		t.Errorf("unexpected device report %+v", kitchen)
+	}
+	if attic := report.Groups[0].Devices[0]; attic.LastOta != nil {
+		t.Errorf("device without OTA should have no last OTA, got %+v", attic.LastOta)
+	}
+}
+
+func TestBuildReportOtaError(t *testing.T) {
+	now := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	lister, otapi := testFleet(now)
+	otapi.err = errors.New("retrieving ota status: unexpected status 503 Service Unavailable")
+
+	_, err := buildReport(context.Background(), lister, otapi, &ReportParams{OfflineDays: 7}, now)
+	if err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("expected the OTA service error to be returned, got %v", err)
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	now := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	lister, otapi := testFleet(now)
+	report, err := buildReport(context.Background(), lister, otapi, &ReportParams{OfflineDays: 7}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var csv bytes.Buffer
+	if err := WriteCSV(&csv, report); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(csv.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected header and 3 rows, got %d lines", len(lines))
+	}
+	want := "arduino:samd:nano_33_iot,dev-2,garage,OFFLINE,2024-01-02T00:00:00Z,,,ota-2,Failed,,timeout"
+	if lines[2] != want {
+		t.Errorf("expected row %q, got %q", want, lines[2])
+	}
+
+	var html bytes.Buffer
+	if err := WriteHTML(&html, report); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"<h2>arduino:esp32:nano_nora</h2>", "Failed: timeout", "offline for more than 7 days"} {
+		if !strings.Contains(html.String(), s) {
+			t.Errorf("html report doesn't contain %q", s)
+		}
+	}
+}
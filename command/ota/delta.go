@@ -0,0 +1,112 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ota
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/arduino/arduino-cloud-cli/internal/lzss"
+	"github.com/arduino/arduino-cloud-cli/internal/ota"
+)
+
+// DeltaParams contains the parameters needed
+// to generate a delta OTA.
+type DeltaParams struct {
+	From    string // Binary file (.bin) running on the devices
+	To      string // Binary file (.bin) to be installed on the devices
+	Out     string // Delta OTA file to be written
+	FQBN    string
+	SignKey string // Private key (PEM) used to sign the delta OTA, optional
+}
+
+// DeltaResult describes a generated delta OTA.
+type DeltaResult struct {
+	File      string
+	FullSize  int // Size of the .ota file containing the whole new firmware
+	DeltaSize int // Size of the delta .ota file
+}
+
+// Delta command generates an OTA containing the patch that
+// transforms a firmware into another one, instead of
+// the whole new firmware.
+// The delta OTA is verified, applying it to the old firmware,
+// before being written.
+func Delta(params *DeltaParams) (*DeltaResult, error) {
+	old, err := readBinary(params.From)
+	if err != nil {
+		return nil, err
+	}
+	new, err := readBinary(params.To)
+	if err != nil {
+		return nil, err
+	}
+	magicNumberPart1, magicNumberPart2, err := magicNumber(params.FQBN)
+	if err != nil {
+		return nil, err
+	}
+
+	var delta bytes.Buffer
+	if err := ota.NewEncoder(&delta, magicNumberPart1, magicNumberPart2).EncodeDelta(old, new); err != nil {
+		return nil, fmt.Errorf("failed to encode delta: %w", err)
+	}
+	patched, err := ota.ApplyDelta(bytes.NewReader(delta.Bytes()), old)
+	if err != nil {
+		return nil, fmt.Errorf("delta verification failed: %w", err)
+	}
+	if !bytes.Equal(patched, new) {
+		return nil, fmt.Errorf("delta verification failed: patched firmware differs from %s", params.To)
+	}
+
+	if err := os.WriteFile(params.Out, delta.Bytes(), 0644); err != nil {
+		return nil, fmt.Errorf("%s: %w", "cannot write delta file", err)
+	}
+	if params.SignKey != "" {
+		if err := signFirmware(params.Out, params.SignKey); err != nil {
+			return nil, fmt.Errorf("%s: %w", "cannot sign delta file", err)
+		}
+	}
+	return &DeltaResult{
+		File:      params.Out,
+		FullSize:  ota.HeaderSize + len(lzss.Encode(new)),
+		DeltaSize: delta.Len(),
+	}, nil
+}
+
+// deltaBase returns the SHA-256 of the firmware the passed file
+// applies to, if it's a delta OTA, or an empty string otherwise.
+func deltaBase(file string) string {
+	header, err := ota.DecodeOtaFirmwareHeaderFromFile(file)
+	if err != nil || header.Delta == nil {
+		return ""
+	}
+	return header.Delta.OldSHA256
+}
+
+// readBinary reads a binary file, refusing files with an OTA header.
+func readBinary(file string) ([]byte, error) {
+	if header, _ := ota.DecodeOtaFirmwareHeaderFromFile(file); header != nil {
+		return nil, fmt.Errorf("file %s contains an OTA header: pass the binary (.bin) file", file)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("file %s does not exists: %w", file, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,80 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ota
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/arduino/arduino-cloud-cli/internal/ota"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDelta(t *testing.T) {
+	dir := t.TempDir()
+	old, err := os.ReadFile(cloudFirmwareFilename)
+	require.NoError(t, err)
+	new := append([]byte{}, old...)
+	copy(new[1000:], "new firmware")
+	newFile := filepath.Join(dir, "new.bin")
+	require.NoError(t, os.WriteFile(newFile, new, 0644))
+
+	out := filepath.Join(dir, "patch.ota")
+	res, err := Delta(&DeltaParams{From: cloudFirmwareFilename, To: newFile, Out: out, FQBN: "arduino:samd:nano_33_iot"})
+	require.NoError(t, err)
+	assert.Equal(t, out, res.File)
+	assert.Less(t, res.DeltaSize, res.FullSize)
+
+	header, err := ota.DecodeOtaFirmwareHeaderFromFile(out)
+	require.NoError(t, err)
+	require.NotNil(t, header.Delta)
+	hash, err := fileSHA256(newFile)
+	require.NoError(t, err)
+	assert.Equal(t, hash, header.Delta.NewSHA256)
+	// Devices updated with the delta are tagged with the new firmware
+	tags, err := successTags(out, nil)
+	require.NoError(t, err)
+	assert.Equal(t, hash, tags[FirmwareHashTag])
+
+	// .ota files are refused
+	otaFile := filepath.Join(dir, "new.ota")
+	require.NoError(t, Generate(newFile, otaFile, "arduino:samd:nano_33_iot"))
+	_, err = Delta(&DeltaParams{From: cloudFirmwareFilename, To: otaFile, Out: out, FQBN: "arduino:samd:nano_33_iot"})
+	assert.Error(t, err)
+}
+
+// writeDelta writes a delta OTA updating cloudFirmwareFilename,
+// returning its path and the SHA-256 of the firmware it applies to.
+func writeDelta(t *testing.T) (string, string) {
+	dir := t.TempDir()
+	old, err := os.ReadFile(cloudFirmwareFilename)
+	require.NoError(t, err)
+	new := append([]byte{}, old...)
+	copy(new[1000:], "new firmware")
+	newFile := filepath.Join(dir, "new.bin")
+	require.NoError(t, os.WriteFile(newFile, new, 0644))
+	out := filepath.Join(dir, "patch.ota")
+	_, err = Delta(&DeltaParams{From: cloudFirmwareFilename, To: newFile, Out: out, FQBN: "arduino:samd:nano_33_iot"})
+	require.NoError(t, err)
+	base, err := fileSHA256(cloudFirmwareFilename)
+	require.NoError(t, err)
+	require.Equal(t, base, deltaBase(out))
+	return out, base
+}
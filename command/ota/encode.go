@@ -26,11 +26,14 @@ import (
 )
 
 type EncodeParams struct {
-	FQBN string
-	File string
+	FQBN    string
+	File    string
+	SignKey string // Private key used to sign the firmware; optional
 }
 
-// Encode command is used to encode a firmware OTA
+// Encode command is used to encode a firmware OTA.
+// If a SignKey is passed, the signature of the firmware is written
+// next to the .ota file, see ota.SignatureFile.
 func Encode(params *EncodeParams) (*string, error) {
 	_, err := os.Stat(params.File)
 	if err != nil {
@@ -62,5 +65,11 @@ func Encode(params *EncodeParams) (*string, error) {
 		return nil, fmt.Errorf("%s: %w", "cannot generate .ota file", err)
 	}
 
+	if params.SignKey != "" {
+		if err := signFirmware(otaFile, params.SignKey); err != nil {
+			return nil, fmt.Errorf("%s: %w", "cannot sign .ota file", err)
+		}
+	}
+
 	return &otaFile, nil
 }
@@ -29,31 +29,16 @@ import (
 
 // Generate takes a .bin file and generates a .ota file.
 func Generate(binFile string, outFile string, fqbn string) error {
-
-	// We are going to put a magic number in the ota .bin file, the fw will check the magic number once the binary is received
-	var magicNumberPart1, magicNumberPart2 string
-
-	// The ota update is available for Arduino boards and ESP32 boards
-
-	// Esp32 boards have a wide range of vid and pid, we don't map all of them
-	// If the fqbn is the one of an ESP32 board, we force a default magic number that matches the same default expected on the fw side
-	if !strings.HasPrefix(fqbn, "arduino:esp32") && strings.HasPrefix(fqbn, "esp32") {
-		magicNumberPart1 = boardpids.Esp32MagicNumberPart1
-		magicNumberPart2 = boardpids.Esp32MagicNumberPart2
-	} else {
-		//For Arduino Boards we use vendorId and productID to form the magic number
-		magicNumberPart1 = boardpids.ArduinoVendorID
-		productID, ok := boardpids.ArduinoFqbnToPID[fqbn]
-		if !ok {
-			return errors.New("fqbn not valid")
-		}
-		magicNumberPart2 = productID
+	magicNumberPart1, magicNumberPart2, err := magicNumber(fqbn)
+	if err != nil {
+		return err
 	}
 
-	data, err := os.ReadFile(binFile)
+	data, err := os.Open(binFile)
 	if err != nil {
 		return err
 	}
+	defer data.Close()
 
 	out, err := os.Create(outFile)
 	if err != nil {
@@ -62,7 +47,7 @@ func Generate(binFile string, outFile string, fqbn string) error {
 	defer out.Close()
 
 	enc := inota.NewEncoder(out, magicNumberPart1, magicNumberPart2)
-	err = enc.Encode(data)
+	err = enc.EncodeFrom(data)
 	if err != nil {
 		return fmt.Errorf("failed to encode binary file: %w", err)
 	}
@@ -70,6 +55,27 @@ func Generate(binFile string, outFile string, fqbn string) error {
 	return nil
 }
 
+// magicNumber returns the two parts of the magic number
+// put in the header of the .ota files for the passed fqbn.
+func magicNumber(fqbn string) (string, string, error) {
+	// We are going to put a magic number in the ota .bin file, the fw will check the magic number once the binary is received
+
+	// The ota update is available for Arduino boards and ESP32 boards
+
+	// Esp32 boards have a wide range of vid and pid, we don't map all of them
+	// If the fqbn is the one of an ESP32 board, we force a default magic number that matches the same default expected on the fw side
+	if !strings.HasPrefix(fqbn, "arduino:esp32") && strings.HasPrefix(fqbn, "esp32") {
+		return boardpids.Esp32MagicNumberPart1, boardpids.Esp32MagicNumberPart2, nil
+	}
+
+	//For Arduino Boards we use vendorId and productID to form the magic number
+	productID, ok := boardpids.ArduinoFqbnToPID[fqbn]
+	if !ok {
+		return "", "", errors.New("fqbn not valid")
+	}
+	return boardpids.ArduinoVendorID, productID, nil
+}
+
 func dereferenceString(s *string) string {
 	if s == nil {
 		return ""
@@ -0,0 +1,174 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ota
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	otaapi "github.com/arduino/arduino-cloud-cli/internal/ota-api"
+)
+
+// DefaultJournalFile is the default file where the progress of a mass upload is recorded.
+const DefaultJournalFile = "ota-mass-upload.json"
+
+// Statuses of journal entries, in addition to the OTA statuses.
+const (
+	journalNotSent = "not_sent" // The firmware has not been sent to the device yet
+	journalInvalid = "invalid"  // The device can't be updated
+)
+
+// journal records the progress of a mass upload, so that
+// an interrupted mass upload can be resumed.
+type journal struct {
+	path       string
+	File       string         `json:"file"`
+	FileSHA256 string         `json:"file_sha256"`
+	FQBN       string         `json:"fqbn"`
+	Devices    []journalEntry `json:"devices"`
+}
+
+// journalEntry is the state of the OTA of a device.
+type journalEntry struct {
+	ID     string `json:"id"`
+	OtaID  string `json:"ota_id,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// newJournal creates the journal of a new mass upload, refusing to
+// overwrite the journal of an unfinished one of the same firmware and FQBN,
+// that should be resumed instead. Journals of other mass uploads are overwritten.
+func newJournal(path, file, fqbn string, valid []string, invalid []Result) (*journal, error) {
+	hash, err := fileSHA256(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if prev, err := readJournal(path); err == nil {
+		retry, _ := prev.resume()
+		if len(retry) > 0 && prev.FileSHA256 == hash && prev.FQBN == fqbn {
+			return nil, fmt.Errorf("journal %s records an unfinished mass upload of the same firmware: resume it or remove the file", path)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	j := &journal{path: path, File: file, FileSHA256: hash, FQBN: fqbn}
+	for _, id := range valid {
+		j.Devices = append(j.Devices, journalEntry{ID: id, Status: journalNotSent})
+	}
+	for _, inv := range invalid {
+		j.Devices = append(j.Devices, journalEntry{ID: inv.ID, Status: journalInvalid, Error: inv.Err.Error()})
+	}
+	return j, j.save()
+}
+
+// loadJournal reads the journal of an interrupted mass upload,
+// checking that it has been written for the same firmware.
+func loadJournal(path, file, fqbn string) (*journal, error) {
+	j, err := readJournal(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("journal %s not found", path)
+	} else if err != nil {
+		return nil, err
+	}
+
+	hash, err := fileSHA256(file)
+	if err != nil {
+		return nil, err
+	}
+	if hash != j.FileSHA256 {
+		return nil, fmt.Errorf("journal %s was written for a different firmware than %s", path, file)
+	}
+	if fqbn != j.FQBN {
+		return nil, fmt.Errorf("journal %s was written for FQBN '%s' instead of '%s'", path, j.FQBN, fqbn)
+	}
+	return j, nil
+}
+
+func readJournal(path string) (*journal, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	j := &journal{path: path}
+	if err := json.Unmarshal(content, j); err != nil {
+		return nil, fmt.Errorf("parsing journal %s: %w", path, err)
+	}
+	return j, nil
+}
+
+// save writes the journal atomically, so that an
+// interruption never leaves a truncated file.
+func (j *journal) save() error {
+	content, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := j.path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0644); err != nil {
+		return fmt.Errorf("saving journal: %w", err)
+	}
+	return os.Rename(tmp, j.path)
+}
+
+// record updates the entry of a device with the result of its OTA.
+func (j *journal) record(r Result) {
+	for i := range j.Devices {
+		e := &j.Devices[i]
+		if e.ID != r.ID {
+			continue
+		}
+		if r.OtaStatus.ID != "" {
+			e.OtaID = r.OtaStatus.ID
+		}
+		switch {
+		case r.Err != nil:
+			e.Status, e.Error = otaapi.StatusFailed, r.Err.Error()
+		case r.OtaStatus.Status != "":
+			e.Status, e.Error = strings.ToLower(r.OtaStatus.Status), r.OtaStatus.ErrorReason
+		default:
+			// The OTA has been sent, but its status is unknown
+			e.Status, e.Error = otaapi.StatusPending, ""
+		}
+		return
+	}
+}
+
+// resume returns the devices that must be updated again, because their
+// OTA failed or was never sent, and the results of the other devices.
+func (j *journal) resume() (retry []string, skipped []Result) {
+	for _, e := range j.Devices {
+		switch e.Status {
+		case journalNotSent, otaapi.StatusFailed, otaapi.StatusCancelled, StatusTimeout:
+			retry = append(retry, e.ID)
+		case journalInvalid:
+			skipped = append(skipped, Result{ID: e.ID, Err: errors.New(e.Error)})
+		default:
+			skipped = append(skipped, Result{
+				ID:        e.ID,
+				OtaStatus: otaapi.Ota{ID: e.OtaID, DeviceID: e.ID, Status: e.Status, ErrorReason: e.Error},
+			})
+		}
+	}
+	return retry, skipped
+}
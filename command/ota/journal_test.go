@@ -0,0 +1,118 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ota
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+
+	otaapi "github.com/arduino/arduino-cloud-cli/internal/ota-api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.json")
+	invalid := []Result{{ID: "dev-x", Err: errors.New("not found")}}
+	ids := []string{"dev-1", "dev-2", "dev-3", "dev-4", "dev-5"}
+	j, err := newJournal(path, testFilename, "arduino:samd:nano_33_iot", ids, invalid)
+	require.NoError(t, err)
+
+	// The upload is interrupted after some devices have been sent the firmware
+	j.record(Result{ID: "dev-1", OtaStatus: otaapi.Ota{ID: "ota-1", Status: otaapi.StatusSucceeded}})
+	j.record(Result{ID: "dev-2", OtaStatus: otaapi.Ota{ID: "ota-2", Status: otaapi.StatusInProgress}})
+	j.record(Result{ID: "dev-3", Err: errors.New("connection reset")})
+	j.record(Result{ID: "dev-4", OtaStatus: otaapi.Ota{ID: "ota-4", Status: "Failed", ErrorReason: "flash failed"}})
+	require.NoError(t, j.save())
+
+	_, err = loadJournal(path, cloudFirmwareFilename, "arduino:samd:nano_33_iot")
+	assert.Error(t, err, "journal written for a different firmware")
+	_, err = loadJournal(path, testFilename, "arduino:samd:mkr1000")
+	assert.Error(t, err, "journal written for a different fqbn")
+
+	loaded, err := loadJournal(path, testFilename, "arduino:samd:nano_33_iot")
+	require.NoError(t, err)
+	retry, skipped := loaded.resume()
+	assert.Equal(t, []string{"dev-3", "dev-4", "dev-5"}, retry)
+
+	skippedIDs := make(map[string]string)
+	for _, r := range skipped {
+		skippedIDs[r.ID] = r.OtaStatus.ID
+		if r.ID == "dev-x" {
+			assert.EqualError(t, r.Err, "not found")
+		}
+	}
+	assert.Equal(t, map[string]string{"dev-1": "ota-1", "dev-2": "ota-2", "dev-x": ""}, skippedIDs)
+}
+
+func TestNewJournalUnfinished(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.json")
+	ids := []string{"dev-1", "dev-2"}
+	j, err := newJournal(path, testFilename, "arduino:samd:nano_33_iot", ids, nil)
+	require.NoError(t, err)
+
+	// dev-2 has not been sent the firmware yet
+	j.record(Result{ID: "dev-1", OtaStatus: otaapi.Ota{ID: "ota-1", Status: otaapi.StatusSucceeded}})
+	require.NoError(t, j.save())
+	_, err = newJournal(path, testFilename, "arduino:samd:nano_33_iot", ids, nil)
+	assert.ErrorContains(t, err, "unfinished mass upload")
+
+	// The journal of an unfinished mass upload of another firmware or FQBN is overwritten
+	_, err = newJournal(path, cloudFirmwareFilename, "arduino:samd:nano_33_iot", ids, nil)
+	require.NoError(t, err)
+	_, err = newJournal(path, testFilename, "arduino:samd:nano_33_iot", ids, nil)
+	assert.NoError(t, err)
+	_, err = newJournal(path, testFilename, "arduino:samd:mkr1000", ids, nil)
+	require.NoError(t, err)
+	j, err = newJournal(path, testFilename, "arduino:samd:nano_33_iot", ids, nil)
+	require.NoError(t, err)
+	j.record(Result{ID: "dev-1", OtaStatus: otaapi.Ota{ID: "ota-1", Status: otaapi.StatusSucceeded}})
+
+	// A finished journal can be overwritten
+	j.record(Result{ID: "dev-2", OtaStatus: otaapi.Ota{ID: "ota-2", Status: otaapi.StatusInProgress}})
+	require.NoError(t, j.save())
+	_, err = newJournal(path, testFilename, "arduino:samd:nano_33_iot", ids, nil)
+	assert.NoError(t, err)
+}
+
+func TestRunRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.json")
+	ids := []string{"dev-1", "dev-2", "dev-3"}
+	j, err := newJournal(path, testFilename, "arduino:samd:nano_33_iot", ids, nil)
+	require.NoError(t, err)
+
+	uploader := &deviceUploaderTest{deviceOTA: func(ctx context.Context, id string, firmware io.Reader, expireMins int) error {
+		if id == "dev-2" {
+			return errors.New("device offline")
+		}
+		return nil
+	}}
+	run(context.Background(), uploader, &otaStatusGetterTest{}, ids, nil, 0, uploadLimits{}, func(r Result) {
+		j.record(r)
+		require.NoError(t, j.save())
+	})
+
+	loaded, err := loadJournal(path, testFilename, "arduino:samd:nano_33_iot")
+	require.NoError(t, err)
+	retry, skipped := loaded.resume()
+	assert.Equal(t, []string{"dev-2"}, retry)
+	assert.Len(t, skipped, 2)
+}
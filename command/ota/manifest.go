@@ -0,0 +1,92 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ota
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/arduino/arduino-cloud-cli/internal/ota"
+	iotclient "github.com/arduino/iot-client-go/v3"
+)
+
+// FirmwareVersionTag is the device tag holding the version of the
+// firmware last installed on the device, taken from the firmware manifest.
+const FirmwareVersionTag = "firmware_version"
+
+// loadManifest returns the manifest of the passed firmware file,
+// or nil if the firmware has no manifest.
+func loadManifest(file string) (*ota.Manifest, error) {
+	path := ota.FindManifest(file)
+	if path == "" {
+		return nil, nil
+	}
+	return ota.LoadManifest(path)
+}
+
+// deviceFilter selects the devices that can be updated with a firmware.
+type deviceFilter struct {
+	fqbn            string
+	manifest        *ota.Manifest // Manifest of the firmware; optional
+	skipSameVersion bool          // Skip devices already running the version of the manifest
+	deltaBase       string        // SHA-256 of the firmware a delta OTA applies to; empty for full OTAs
+}
+
+// check returns an error if the device cannot be updated.
+// The version running on devices is read from their FirmwareVersionTag:
+// version rules are not applied to devices without it.
+// Delta OTAs are only sent to devices whose FirmwareHashTag proves
+// that they are running the firmware the patch applies to.
+func (f deviceFilter) check(dev *iotclient.ArduinoDevicev2) error {
+	fqbn := dereferenceString(dev.Fqbn)
+	// Device FQBN doesn't match the passed one
+	if fqbn != f.fqbn {
+		return fmt.Errorf("has FQBN '%s' instead of '%s'", fqbn, f.fqbn)
+	}
+	if f.deltaBase != "" {
+		running, _ := dev.Tags[FirmwareHashTag].(string)
+		if running == "" {
+			return fmt.Errorf("has no '%s' tag: the firmware the delta OTA applies to cannot be checked", FirmwareHashTag)
+		}
+		if !strings.EqualFold(running, f.deltaBase) {
+			return fmt.Errorf("runs firmware %s, not the one the delta OTA applies to (%s)", running, f.deltaBase)
+		}
+	}
+	if f.manifest == nil {
+		return nil
+	}
+	if !f.manifest.SupportsFQBN(fqbn) {
+		return fmt.Errorf("has FQBN '%s', not supported by firmware %s", fqbn, f.manifest.Version)
+	}
+	current, _ := dev.Tags[FirmwareVersionTag].(string)
+	if current == "" {
+		return nil
+	}
+	if f.skipSameVersion && f.manifest.IsVersion(current) {
+		return fmt.Errorf("already on version %s", current)
+	}
+	return f.manifest.CheckUpdateFrom(current)
+}
+
+// versionTags adds the version of the manifest, if any, to the passed tags.
+func versionTags(tags map[string]string, manifest *ota.Manifest) map[string]string {
+	if tags != nil && manifest != nil {
+		tags[FirmwareVersionTag] = manifest.Version
+	}
+	return tags
+}
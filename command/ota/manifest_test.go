@@ -0,0 +1,75 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ota
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/arduino/arduino-cloud-cli/internal/ota"
+	iotclient "github.com/arduino/iot-client-go/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeviceFilter(t *testing.T) {
+	nano, mkr := "arduino:samd:nano_33_iot", "arduino:samd:mkrwifi1010"
+	device := func(fqbn, version string) *iotclient.ArduinoDevicev2 {
+		dev := &iotclient.ArduinoDevicev2{Id: "dev", Fqbn: &fqbn}
+		if version != "" {
+			dev.Tags = map[string]interface{}{FirmwareVersionTag: version}
+		}
+		return dev
+	}
+	manifest := &ota.Manifest{Version: "1.2.0", FQBNs: []string{nano, mkr}, MinFromVersion: "1.0.0"}
+	base := strings.Repeat("0", 64)
+	deltaFilter := deviceFilter{fqbn: nano, deltaBase: base}
+	tagged := func(hash string) *iotclient.ArduinoDevicev2 {
+		dev := device(nano, "")
+		dev.Tags = map[string]interface{}{FirmwareHashTag: hash}
+		return dev
+	}
+
+	tests := []struct {
+		name   string
+		filter deviceFilter
+		dev    *iotclient.ArduinoDevicev2
+		valid  bool
+	}{
+		{"fqbn only", deviceFilter{fqbn: nano}, device(nano, "0.1.0"), true},
+		{"wrong fqbn", deviceFilter{fqbn: nano}, device(mkr, ""), false},
+		{"no version", deviceFilter{fqbn: nano, manifest: manifest}, device(nano, ""), true},
+		{"update allowed", deviceFilter{fqbn: nano, manifest: manifest}, device(nano, "1.1.0"), true},
+		{"too old", deviceFilter{fqbn: nano, manifest: manifest}, device(nano, "0.9.0"), false},
+		{"same version", deviceFilter{fqbn: nano, manifest: manifest}, device(nano, "1.2.0"), true},
+		{"skip same version", deviceFilter{fqbn: nano, manifest: manifest, skipSameVersion: true}, device(nano, "1.2.0"), false},
+		{"fqbn not in manifest", deviceFilter{fqbn: "arduino:samd:mkr1000", manifest: manifest}, device("arduino:samd:mkr1000", ""), false},
+		{"delta base", deltaFilter, tagged(base), true},
+		{"delta wrong base", deltaFilter, tagged(strings.Repeat("1", 64)), false},
+		{"delta untagged", deltaFilter, device(nano, ""), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.check(tt.dev)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
@@ -18,11 +18,15 @@
 package ota
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -35,9 +39,15 @@ import (
 )
 
 const (
-	numConcurrentUploads = 10
+	// DefaultConcurrency is the default maximum number
+	// of OTA uploads performed at the same time.
+	DefaultConcurrency = 10
 )
 
+// errNotSent is the error of the devices whose OTA has not been
+// uploaded because the mass upload has been interrupted.
+var errNotSent = errors.New("not sent: upload interrupted")
+
 // MassUploadParams contains the parameters needed to
 // perform a Mass OTA upload.
 type MassUploadParams struct {
@@ -47,6 +57,15 @@ type MassUploadParams struct {
 	Deferred         bool
 	DoNotApplyHeader bool
 	FQBN             string
+	Wait             bool              // Wait for the OTAs to end
+	Timeout          time.Duration     // Maximum time waited for the OTAs to end
+	SuccessTags      map[string]string // Tags applied to devices once their OTA succeeded, along with FirmwareHashTag; used with Wait
+	Journal          string            // File where the progress is recorded; if empty, progress is not recorded
+	Resume           bool              // Resume the mass upload recorded in Journal, retrying only devices not updated yet
+	Concurrency      int               // Maximum number of uploads performed at the same time; DefaultConcurrency if not positive
+	Rate             float64           // Maximum number of uploads started per second; no limit if not positive
+	SkipSameVersion  bool              // Skip devices already running the version in the firmware manifest, if any
+	VerifyKey        string            // Public key verifying the signature of File before the upload; optional
 }
 
 // Result of an ota upload on a device.
@@ -79,8 +98,14 @@ func buildOtaFile(params *MassUploadParams) (string, string, error) {
 
 // MassUpload command is used to mass upload a firmware OTA,
 // on devices of Arduino IoT Cloud.
+// If ctx is cancelled, no more uploads are started: the results of
+// the devices already reached are returned along with an error.
 func MassUpload(ctx context.Context, params *MassUploadParams, cred *config.Credentials) ([]Result, error) {
-	if params.DeviceIDs == nil && params.Tags == nil {
+	if params.Resume {
+		if params.Journal == "" {
+			return nil, errors.New("provide the journal to resume")
+		}
+	} else if params.DeviceIDs == nil && params.Tags == nil {
 		return nil, errors.New("provide either DeviceIDs or Tags")
 	} else if params.DeviceIDs != nil && params.Tags != nil {
 		return nil, errors.New("cannot use both DeviceIDs and Tags. only one of them should be not nil")
@@ -93,6 +118,21 @@ func MassUpload(ctx context.Context, params *MassUploadParams, cred *config.Cred
 		return nil, fmt.Errorf("file %s does not exists: %w", params.File, err)
 	}
 
+	// Firmwares whose signature is not valid are never sent
+	if params.VerifyKey != "" {
+		if err := verifyFirmware(params.File, params.VerifyKey); err != nil {
+			return nil, err
+		}
+	}
+
+	manifest, err := loadManifest(params.File)
+	if err != nil {
+		return nil, err
+	}
+	if manifest != nil && !manifest.SupportsFQBN(params.FQBN) {
+		return nil, fmt.Errorf("firmware %s does not support FQBN '%s'", manifest.Version, params.FQBN)
+	}
+
 	if !params.DoNotApplyHeader {
 		//Verify if file has already an OTA header
 		header, _ := ota.DecodeOtaFirmwareHeaderFromFile(params.File)
@@ -116,17 +156,40 @@ func MassUpload(ctx context.Context, params *MassUploadParams, cred *config.Cred
 	}
 	otapi := otaapi.NewClient(cred)
 
-	// Prepare the list of device-ids to update
-	d, err := idsGivenTags(ctx, iotClient, params.Tags)
-	if err != nil {
-		return nil, err
-	}
-	d = append(params.DeviceIDs, d...)
-	valid, invalid, err := validateDevices(ctx, iotClient, d, params.FQBN)
-	if err != nil {
-		return nil, fmt.Errorf("failed to validate devices: %w", err)
+	var valid []string
+	var invalid []Result
+	var jrn *journal
+	if params.Resume {
+		// Devices already updated, or being updated, are skipped
+		if jrn, err = loadJournal(params.Journal, params.File, params.FQBN); err != nil {
+			return nil, err
+		}
+		valid, invalid = jrn.resume()
+		logrus.Infof("Resuming mass upload: %d devices to update, %d skipped", len(valid), len(invalid))
+	} else {
+		// Prepare the list of device-ids to update
+		d, err := idsGivenTags(ctx, iotClient, params.Tags)
+		if err != nil {
+			return nil, err
+		}
+		d = append(params.DeviceIDs, d...)
+		filter := deviceFilter{
+			fqbn:            params.FQBN,
+			manifest:        manifest,
+			skipSameVersion: params.SkipSameVersion,
+			deltaBase:       deltaBase(params.File),
+		}
+		valid, invalid, err = validateDevices(ctx, iotClient, d, filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to validate devices: %w", err)
+		}
+		if params.Journal != "" {
+			if jrn, err = newJournal(params.Journal, params.File, params.FQBN, valid, invalid); err != nil {
+				return nil, err
+			}
+		}
 	}
-	if len(valid) == 0 {
+	if len(valid) == 0 && !params.Wait {
 		return invalid, nil
 	}
 
@@ -135,11 +198,86 @@ func MassUpload(ctx context.Context, params *MassUploadParams, cred *config.Cred
 		expiration = otaDeferredExpirationMins
 	}
 
-	res := run(ctx, iotClient, otapi, valid, otaFile, expiration)
+	var tags map[string]string
+	if params.Wait {
+		if tags, err = successTags(otaFile, params.SuccessTags); err != nil {
+			return nil, err
+		}
+		tags = versionTags(tags, manifest)
+	}
+
+	var record func(Result)
+	if jrn != nil {
+		record = func(r Result) {
+			jrn.record(r)
+			if err := jrn.save(); err != nil {
+				logrus.Warn(err)
+			}
+		}
+	}
+
+	payload, err := os.ReadFile(otaFile)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", "cannot read ota file", err)
+	}
+	limits := uploadLimits{concurrency: params.Concurrency, rate: params.Rate}
+	res := run(ctx, iotClient, otapi, valid, payload, expiration, limits, record)
+	if ctx.Err() != nil {
+		// Devices not sent yet are left in the journal, to be resumed
+		return append(res, invalid...), fmt.Errorf("mass upload interrupted: %w", ctx.Err())
+	}
+	if params.Wait {
+		// OTAs skipped when resuming may still be running
+		res = append(res, invalid...)
+		invalid = nil
+		err := waitResults(ctx, otapi, res, params.Timeout)
+		// Devices whose OTA is confirmed successful are tagged
+		// even if the wait has been interrupted
+		tagResults(context.WithoutCancel(ctx), iotClient, res, tags)
+		if record != nil {
+			for _, r := range res {
+				record(r)
+			}
+		}
+		if err != nil {
+			return res, fmt.Errorf("waiting for the OTAs: %w", err)
+		}
+	}
 	res = append(res, invalid...)
 	return res, nil
 }
 
+// tagResults tags the devices whose OTA succeeded.
+// Tagging failures are reported as errors of the devices.
+func tagResults(ctx context.Context, tagger deviceTagger, res []Result, tags map[string]string) {
+	for i := range res {
+		if res[i].Err != nil {
+			continue
+		}
+		res[i].Err = tagDevice(ctx, tagger, res[i].ID, res[i].OtaStatus, tags)
+	}
+}
+
+// waitResults waits for the started OTAs to end, updating their statuses
+// with the last ones retrieved, even if the wait fails.
+func waitResults(ctx context.Context, otapi otaDetailGetter, res []Result, timeout time.Duration) error {
+	var started []otaapi.Ota
+	for _, r := range res {
+		if r.Err == nil && r.OtaStatus.ID != "" {
+			started = append(started, r.OtaStatus)
+		}
+	}
+	final, err := waitOtas(ctx, otapi, started, timeout)
+	for _, o := range final {
+		for i := range res {
+			if res[i].OtaStatus.ID == o.ID {
+				res[i].OtaStatus = o
+			}
+		}
+	}
+	return err
+}
+
 type deviceLister interface {
 	DeviceList(ctx context.Context, tags map[string]string) ([]iotclient.ArduinoDevicev2, error)
 }
@@ -159,7 +297,7 @@ func idsGivenTags(ctx context.Context, lister deviceLister, tags map[string]stri
 	return devices, nil
 }
 
-func validateDevices(ctx context.Context, lister deviceLister, ids []string, fqbn string) (valid []string, invalid []Result, err error) {
+func validateDevices(ctx context.Context, lister deviceLister, ids []string, filter deviceFilter) (valid []string, invalid []Result, err error) {
 	devs, err := lister.DeviceList(ctx, nil)
 	if err != nil {
 		return nil, nil, fmt.Errorf("%s: %w", "cannot retrieve devices from cloud", err)
@@ -179,10 +317,8 @@ func validateDevices(ctx context.Context, lister deviceLister, ids []string, fqb
 			invalid = append(invalid, inv)
 			continue
 		}
-		// Device FQBN doesn't match the passed one
-		if dereferenceString(found.Fqbn) != fqbn {
-			inv := Result{ID: id, Err: fmt.Errorf("has FQBN '%s' instead of '%s'", dereferenceString(found.Fqbn), fqbn)}
-			invalid = append(invalid, inv)
+		if err := filter.check(found); err != nil {
+			invalid = append(invalid, Result{ID: id, Err: err})
 			continue
 		}
 		valid = append(valid, id)
@@ -191,44 +327,53 @@ func validateDevices(ctx context.Context, lister deviceLister, ids []string, fqb
 }
 
 type otaUploader interface {
-	DeviceOTA(ctx context.Context, id string, file *os.File, expireMins int) error
+	DeviceOTA(ctx context.Context, id string, firmware io.Reader, expireMins int) error
 }
 
 type otaStatusGetter interface {
 	GetOtaLastStatusByDeviceID(deviceID string) (*otaapi.OtaStatusList, error)
 }
 
-func run(ctx context.Context, uploader otaUploader, otapi otaStatusGetter, ids []string, otaFile string, expiration int) []Result {
-	type job struct {
-		id   string
-		file *os.File
+// uploadLimits bounds the load put on the cloud by run.
+type uploadLimits struct {
+	concurrency int     // Maximum number of uploads performed at the same time; DefaultConcurrency if not positive
+	rate        float64 // Maximum number of uploads started per second; no limit if not positive
+}
+
+// run uploads the ota payload to the devices, calling record, if not nil,
+// with the result of each upload as soon as it is available.
+// When ctx is cancelled no more uploads are started, while the ones
+// already started are completed: devices not reached get errNotSent,
+// and are not passed to record.
+func run(ctx context.Context, uploader otaUploader, otapi otaStatusGetter, ids []string, payload []byte, expiration int, limits uploadLimits, record func(Result)) []Result {
+	concurrency := limits.concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	var tick <-chan time.Time
+	if limits.rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / limits.rate))
+		defer ticker.Stop()
+		tick = ticker.C
 	}
-	jobs := make(chan job, len(ids))
 
+	jobs := make(chan string)
 	resCh := make(chan Result, len(ids))
 	results := make([]Result, 0, len(ids))
 
-	for _, id := range ids {
-		file, err := os.Open(otaFile)
-		if err != nil {
-			logrus.Error("cannot open ota file:", otaFile)
-			r := Result{ID: id, Err: fmt.Errorf("cannot open ota file")}
-			results = append(results, r)
-			continue
-		}
-		defer file.Close()
-		jobs <- job{id: id, file: file}
-	}
-	close(jobs)
-
+	// Started uploads are not aborted by ctx
+	uploadCtx := context.WithoutCancel(ctx)
+	var wg sync.WaitGroup
 	logrus.Infoln("Uploading firmware to devices...")
-	for i := 0; i < numConcurrentUploads; i++ {
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
 		go func() {
-			for job := range jobs {
-				err := uploader.DeviceOTA(ctx, job.id, job.file, expiration)
-				otaResult := Result{ID: job.id, Err: err}
+			defer wg.Done()
+			for id := range jobs {
+				err := uploader.DeviceOTA(uploadCtx, id, bytes.NewReader(payload), expiration)
+				otaResult := Result{ID: id, Err: err}
 
-				otaID, otaapierr := otapi.GetOtaLastStatusByDeviceID(job.id)
+				otaID, otaapierr := otapi.GetOtaLastStatusByDeviceID(id)
 				if otaapierr == nil && otaID != nil && len(otaID.Ota) > 0 {
 					otaResult.OtaStatus = otaID.Ota[0]
 				}
@@ -238,9 +383,49 @@ func run(ctx context.Context, uploader otaUploader, otapi otaStatusGetter, ids [
 		}()
 	}
 
-	for range ids {
-		r := <-resCh
+	sent := dispatch(ctx, ids, jobs, tick)
+	close(jobs)
+	go func() {
+		wg.Wait()
+		close(resCh)
+	}()
+
+	for r := range resCh {
+		if record != nil {
+			record(r)
+		}
 		results = append(results, r)
 	}
+	if sent < len(ids) {
+		logrus.Warnf("Upload interrupted: %d of %d devices not updated", len(ids)-sent, len(ids))
+		for _, id := range ids[sent:] {
+			results = append(results, Result{ID: id, Err: errNotSent})
+		}
+	}
 	return results
 }
+
+// dispatch sends the ids to the jobs channel, waiting for tick,
+// if not nil, before each one. It stops when ctx is cancelled,
+// returning the number of ids sent.
+func dispatch(ctx context.Context, ids []string, jobs chan<- string, tick <-chan time.Time) int {
+	for i, id := range ids {
+		if i > 0 && tick != nil {
+			select {
+			case <-tick:
+			case <-ctx.Done():
+				return i
+			}
+		}
+		// Don't pick a job when ctx is already cancelled
+		if ctx.Err() != nil {
+			return i
+		}
+		select {
+		case jobs <- id:
+		case <-ctx.Done():
+			return i
+		}
+	}
+	return len(ids)
+}
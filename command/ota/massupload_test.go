@@ -20,25 +20,30 @@ package ota
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"os"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	otaapi "github.com/arduino/arduino-cloud-cli/internal/ota-api"
 	iotclient "github.com/arduino/iot-client-go/v3"
 	"github.com/gofrs/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const testFilename = "testdata/empty.bin"
 const cloudFirmwareFilename = "testdata/cloud.bin"
 
 type deviceUploaderTest struct {
-	deviceOTA func(ctx context.Context, id string, file *os.File, expireMins int) error
+	deviceOTA func(ctx context.Context, id string, firmware io.Reader, expireMins int) error
 }
 
-func (d *deviceUploaderTest) DeviceOTA(ctx context.Context, id string, file *os.File, expireMins int) error {
-	return d.deviceOTA(ctx, id, file, expireMins)
+func (d *deviceUploaderTest) DeviceOTA(ctx context.Context, id string, firmware io.Reader, expireMins int) error {
+	return d.deviceOTA(ctx, id, firmware, expireMins)
 }
 
 type otaStatusGetterTest struct{}
@@ -66,7 +71,7 @@ func TestRun(t *testing.T) {
 		okID3      = okPrefix + "-dac4-4a6a-80a4-698062fe2af5"
 	)
 	mockClient := &deviceUploaderTest{
-		deviceOTA: func(ctx context.Context, id string, file *os.File, expireMins int) error {
+		deviceOTA: func(ctx context.Context, id string, firmware io.Reader, expireMins int) error {
 			if strings.Split(id, "-")[0] == failPrefix {
 				return errors.New("err")
 			}
@@ -76,7 +81,7 @@ func TestRun(t *testing.T) {
 	mockStatusClient := &otaStatusGetterTest{}
 
 	devs := []string{okID1, failID1, okID2, failID2, okID3}
-	res := run(context.TODO(), mockClient, mockStatusClient, devs, testFilename, 0)
+	res := run(context.TODO(), mockClient, mockStatusClient, devs, []byte("firmware"), 0, uploadLimits{}, nil)
 	if len(res) != len(devs) {
 		t.Errorf("expected %d results, got %d", len(devs), len(res))
 	}
@@ -92,6 +97,74 @@ func TestRun(t *testing.T) {
 	}
 }
 
+func TestRun_concurrency(t *testing.T) {
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	uploader := &deviceUploaderTest{
+		deviceOTA: func(ctx context.Context, id string, firmware io.Reader, expireMins int) error {
+			mu.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			mu.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			mu.Lock()
+			running--
+			mu.Unlock()
+			b, err := io.ReadAll(firmware)
+			assert.NoError(t, err)
+			assert.Equal(t, "firmware", string(b))
+			return nil
+		},
+	}
+
+	ids := make([]string, 20)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("dev-%d", i)
+	}
+	res := run(context.Background(), uploader, &otaStatusGetterTest{}, ids, []byte("firmware"), 0, uploadLimits{concurrency: 3}, nil)
+	assert.Len(t, res, len(ids))
+	assert.LessOrEqual(t, maxRunning, 3)
+	assert.Greater(t, maxRunning, 0)
+}
+
+func TestRun_rate(t *testing.T) {
+	uploader := &deviceUploaderTest{
+		deviceOTA: func(ctx context.Context, id string, firmware io.Reader, expireMins int) error { return nil },
+	}
+	start := time.Now()
+	ids := []string{"dev-1", "dev-2", "dev-3"}
+	res := run(context.Background(), uploader, &otaStatusGetterTest{}, ids, nil, 0, uploadLimits{rate: 50}, nil)
+	assert.Len(t, res, len(ids))
+	// Uploads after the first one wait 20ms each
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+}
+
+func TestRun_cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	uploader := &deviceUploaderTest{
+		deviceOTA: func(ctx context.Context, id string, firmware io.Reader, expireMins int) error {
+			// Started uploads are completed even if the mass upload is interrupted
+			cancel()
+			return ctx.Err()
+		},
+	}
+
+	var recorded []string
+	ids := []string{"dev-1", "dev-2", "dev-3"}
+	res := run(ctx, uploader, &otaStatusGetterTest{}, ids, nil, 0, uploadLimits{concurrency: 1}, func(r Result) {
+		recorded = append(recorded, r.ID)
+	})
+	require.Len(t, res, len(ids))
+	assert.Equal(t, []string{"dev-1"}, recorded)
+	assert.NoError(t, res[0].Err)
+	for _, r := range res[1:] {
+		assert.ErrorIs(t, r.Err, errNotSent)
+	}
+}
+
 type deviceListerTest struct {
 	list []iotclient.ArduinoDevicev2
 }
@@ -125,7 +198,7 @@ func TestValidateDevices(t *testing.T) {
 		idCorrect2,
 		idNotValid,
 	}
-	v, i, err := validateDevices(context.TODO(), &mockDeviceList, ids, correctFQBN)
+	v, i, err := validateDevices(context.TODO(), &mockDeviceList, ids, deviceFilter{fqbn: correctFQBN})
 	if err != nil {
 		t.Errorf("unexpected error: %s", err.Error())
 	}
@@ -139,6 +212,24 @@ func TestValidateDevices(t *testing.T) {
 	}
 }
 
+func TestValidateDevicesDelta(t *testing.T) {
+	fqbn := "arduino:samd:nano_33_iot"
+	deltaFile, base := writeDelta(t)
+	lister := &deviceListerTest{list: []iotclient.ArduinoDevicev2{
+		{Id: "dev-base", Fqbn: &fqbn, Tags: map[string]interface{}{FirmwareHashTag: base}},
+		{Id: "dev-other", Fqbn: &fqbn, Tags: map[string]interface{}{FirmwareHashTag: strings.Repeat("1", 64)}},
+		{Id: "dev-untagged", Fqbn: &fqbn},
+	}}
+
+	filter := deviceFilter{fqbn: fqbn, deltaBase: deltaBase(deltaFile)}
+	valid, invalid, err := validateDevices(context.TODO(), lister, []string{"dev-base", "dev-other", "dev-untagged"}, filter)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dev-base"}, valid)
+	require.Len(t, invalid, 2)
+	assert.ErrorContains(t, invalid[0].Err, "not the one the delta OTA applies to")
+	assert.ErrorContains(t, invalid[1].Err, "has no '"+FirmwareHashTag+"' tag")
+}
+
 func TestValidateBuildOtaFile(t *testing.T) {
 
 	file, tmp, err := buildOtaFile(&MassUploadParams{
@@ -45,6 +45,12 @@ func ReadHeader(params *ReadHeaderParams) error {
 		return fmt.Errorf("file %s does not contains a valid OTA header", params.File)
 	}
 
+	if path := ota.FindManifest(params.File); path != "" {
+		if header.Manifest, err = ota.LoadManifest(path); err != nil {
+			return err
+		}
+	}
+
 	feedback.PrintResult(header)
 
 	return nil
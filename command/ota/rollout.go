@@ -0,0 +1,429 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/arduino/arduino-cloud-cli/internal/iot"
+	"github.com/arduino/arduino-cloud-cli/internal/ota"
+	otaapi "github.com/arduino/arduino-cloud-cli/internal/ota-api"
+)
+
+// Statuses of a rollout.
+const (
+	RolloutRunning   = "running"
+	RolloutPaused    = "paused"
+	RolloutHalted    = "halted"
+	RolloutCompleted = "completed"
+)
+
+// Statuses of a rollout wave, and of its devices before
+// and after their OTA, in addition to the OTA statuses.
+const (
+	wavePending   = "pending"
+	waveCompleted = "completed"
+	deviceTimeout = "timeout"
+)
+
+const (
+	// DefaultRolloutStateFile is the default file where the state of a rollout is saved.
+	DefaultRolloutStateFile = "ota-rollout.json"
+	// DefaultPollInterval is the default interval between two checks of the OTA statuses.
+	DefaultPollInterval = 10 * time.Second
+)
+
+// DefaultWaves are the default waves of a rollout, as the cumulative
+// percentages of devices updated at the end of each wave.
+var DefaultWaves = []float64{1, 10, 50, 100}
+
+// RolloutParams contains the parameters needed to
+// perform a staged OTA rollout.
+type RolloutParams struct {
+	DeviceIDs        []string
+	Tags             map[string]string
+	File             string
+	FQBN             string
+	Deferred         bool
+	DoNotApplyHeader bool
+	Waves            []float64     // Cumulative percentages of devices updated at the end of each wave
+	HaltFailureRate  float64       // Failure percentage of a wave above which the rollout is halted
+	PauseFailureRate float64       // Failure percentage of a wave above which confirmation is needed to proceed
+	WaveTimeout      time.Duration // Maximum time waited for the OTAs of a wave; if zero, the OTA expiration is used
+	PollInterval     time.Duration
+	StateFile        string // File where the state of the rollout is saved
+	Resume           bool   // Resume the rollout saved in StateFile
+	Force            bool   // Allow resuming a halted rollout
+	// Confirm is called when a wave exceeds PauseFailureRate, to decide whether
+	// to proceed with the next wave. If nil or if it returns false, the rollout is paused.
+	Confirm func(wave *RolloutWave) bool
+}
+
+// Rollout command is used to upload a firmware OTA on devices
+// of Arduino IoT Cloud in waves of growing size, waiting for the OTAs
+// of each wave to end and evaluating their failure rate before proceeding.
+// The state of the rollout is saved after each step, so that
+// a paused, halted or interrupted rollout can be resumed.
+func Rollout(ctx context.Context, params *RolloutParams, cred *config.Credentials) (*RolloutState, error) {
+	iotClient, err := iot.NewClient(cred)
+	if err != nil {
+		return nil, err
+	}
+	otapi := otaapi.NewClient(cred)
+
+	var state *RolloutState
+	if params.Resume {
+		if state, err = resumeRollout(params.StateFile, params.Force); err != nil {
+			return nil, err
+		}
+	} else {
+		if state, err = newRollout(ctx, iotClient, params); err != nil {
+			return nil, err
+		}
+	}
+
+	// Generate .ota file
+	otaFile, otaDir, err := buildOtaFile(&MassUploadParams{
+		File:             state.File,
+		FQBN:             state.FQBN,
+		DoNotApplyHeader: state.DoNotApplyHeader,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if otaDir != "" {
+		defer os.RemoveAll(otaDir)
+	}
+
+	r := &rollout{
+		uploader: iotClient,
+		otapi:    otapi,
+		state:    state,
+		path:     params.StateFile,
+		otaFile:  otaFile,
+		poll:     params.PollInterval,
+		confirm:  params.Confirm,
+	}
+	if err := r.run(ctx); err != nil {
+		return state, err
+	}
+	return state, nil
+}
+
+// newRollout selects the devices to update and splits them in waves.
+func newRollout(ctx context.Context, lister deviceLister, params *RolloutParams) (*RolloutState, error) {
+	if params.DeviceIDs == nil && params.Tags == nil {
+		return nil, errors.New("provide either DeviceIDs or Tags")
+	} else if params.DeviceIDs != nil && params.Tags != nil {
+		return nil, errors.New("cannot use both DeviceIDs and Tags. only one of them should be not nil")
+	}
+	if err := validateWaves(params.Waves); err != nil {
+		return nil, err
+	}
+	if prev, err := loadRolloutState(params.StateFile); err == nil && prev.Status != RolloutCompleted {
+		return nil, fmt.Errorf("rollout saved in %s is %s: resume it or remove the file", params.StateFile, prev.Status)
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	if _, err := os.Stat(params.File); err != nil {
+		return nil, fmt.Errorf("file %s does not exists: %w", params.File, err)
+	}
+	if !params.DoNotApplyHeader {
+		//Verify if file has already an OTA header
+		header, _ := ota.DecodeOtaFirmwareHeaderFromFile(params.File)
+		if header != nil {
+			params.DoNotApplyHeader = true
+		}
+	}
+	hash, err := fileSHA256(params.File)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := loadManifest(params.File)
+	if err != nil {
+		return nil, err
+	}
+
+	// Prepare the list of device-ids to update
+	d, err := idsGivenTags(ctx, lister, params.Tags)
+	if err != nil {
+		return nil, err
+	}
+	d = append(params.DeviceIDs, d...)
+	valid, invalid, err := validateDevices(ctx, lister, d, deviceFilter{fqbn: params.FQBN, manifest: manifest, deltaBase: deltaBase(params.File)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate devices: %w", err)
+	}
+	if len(valid) == 0 {
+		return nil, errors.New("no valid devices to update")
+	}
+	for _, inv := range invalid {
+		logrus.Warnf("Skipping device %s: %v", inv.ID, inv.Err)
+	}
+	sort.Strings(valid)
+
+	expiration := otaExpirationMins
+	if params.Deferred {
+		expiration = otaDeferredExpirationMins
+	}
+	timeout := params.WaveTimeout
+	if timeout <= 0 {
+		timeout = time.Duration(expiration) * time.Minute
+	}
+
+	state := &RolloutState{
+		Status:           RolloutRunning,
+		File:             params.File,
+		FileSHA256:       hash,
+		FQBN:             params.FQBN,
+		DoNotApplyHeader: params.DoNotApplyHeader,
+		Expiration:       expiration,
+		HaltFailureRate:  params.HaltFailureRate,
+		PauseFailureRate: params.PauseFailureRate,
+		WaveTimeout:      int64(timeout / time.Second),
+		Waves:            splitWaves(valid, params.Waves),
+	}
+	if err := state.save(params.StateFile); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// resumeRollout loads a saved rollout, checking that it can be resumed.
+// A halted rollout is resumed only if force is set.
+func resumeRollout(path string, force bool) (*RolloutState, error) {
+	state, err := loadRolloutState(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no rollout to resume found in %s", path)
+	} else if err != nil {
+		return nil, err
+	}
+	if state.Status == RolloutCompleted {
+		return nil, errors.New("rollout already completed")
+	}
+	if state.Status == RolloutHalted && !force {
+		return nil, fmt.Errorf("rollout saved in %s was halted because of OTA failures: pass --force to resume it anyway", path)
+	}
+	hash, err := fileSHA256(state.File)
+	if err != nil {
+		return nil, err
+	}
+	if hash != state.FileSHA256 {
+		return nil, fmt.Errorf("file %s changed since the rollout started", state.File)
+	}
+	// Resuming a paused or halted rollout confirms the next wave
+	state.Status = RolloutRunning
+	return state, nil
+}
+
+func validateWaves(waves []float64) error {
+	if len(waves) == 0 {
+		return errors.New("no waves provided")
+	}
+	prev := 0.0
+	for _, w := range waves {
+		if w <= prev || w > 100 {
+			return fmt.Errorf("waves must be increasing percentages up to 100, got %v", waves)
+		}
+		prev = w
+	}
+	if prev != 100 {
+		return fmt.Errorf("last wave must update 100%% of the devices, got %v%%", prev)
+	}
+	return nil
+}
+
+// splitWaves assigns the devices to the waves, given the cumulative
+// percentages of devices updated at the end of each wave.
+// Each wave contains at least a device: waves left empty are dropped.
+func splitWaves(ids []string, percents []float64) []RolloutWave {
+	var waves []RolloutWave
+	start := 0
+	for _, p := range percents {
+		end := int(math.Ceil(float64(len(ids)) * p / 100))
+		if end > len(ids) {
+			end = len(ids)
+		}
+		if end <= start {
+			continue
+		}
+		w := RolloutWave{Percent: p, Status: wavePending}
+		for _, id := range ids[start:end] {
+			w.Devices = append(w.Devices, RolloutDevice{ID: id, Status: otaapi.StatusPending})
+		}
+		waves = append(waves, w)
+		start = end
+	}
+	return waves
+}
+
+type otaStatusesGetter interface {
+	otaStatusGetter
+	GetOtaStatusByOtaIDs(otaids string) (*otaapi.OtaStatusList, error)
+}
+
+type rollout struct {
+	uploader otaUploader
+	otapi    otaStatusesGetter
+	state    *RolloutState
+	path     string
+	otaFile  string
+	poll     time.Duration
+	confirm  func(wave *RolloutWave) bool
+}
+
+func (r *rollout) run(ctx context.Context) error {
+	for i := range r.state.Waves {
+		w := &r.state.Waves[i]
+		if w.Status == waveCompleted {
+			continue
+		}
+		logrus.Infof("Starting wave %d of %d: %d devices", i+1, len(r.state.Waves), len(w.Devices))
+		if w.StartedAt == nil {
+			now := time.Now()
+			w.StartedAt = &now
+		}
+		uploadErr := r.upload(ctx, w)
+		if err := r.state.save(r.path); err != nil {
+			return err
+		}
+		if uploadErr != nil {
+			return uploadErr
+		}
+		if err := r.wait(ctx, w); err != nil {
+			return err
+		}
+
+		w.Status = waveCompleted
+		w.FailureRate = w.failureRate()
+		logrus.Infof("Wave %d completed with %.1f%% failures", i+1, w.FailureRate)
+		last := i == len(r.state.Waves)-1
+		switch {
+		case w.FailureRate > r.state.HaltFailureRate:
+			r.state.Status = RolloutHalted
+		case !last && w.FailureRate > r.state.PauseFailureRate && (r.confirm == nil || !r.confirm(w)):
+			r.state.Status = RolloutPaused
+		}
+		if err := r.state.save(r.path); err != nil {
+			return err
+		}
+		if r.state.Status != RolloutRunning {
+			return nil
+		}
+	}
+	r.state.Status = RolloutCompleted
+	return r.state.save(r.path)
+}
+
+// upload starts the OTAs of the devices of the wave that have not been updated yet.
+func (r *rollout) upload(ctx context.Context, w *RolloutWave) error {
+	var ids []string
+	for _, d := range w.Devices {
+		if d.Status == otaapi.StatusPending && d.OtaID == "" {
+			ids = append(ids, d.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	payload, err := os.ReadFile(r.otaFile)
+	if err != nil {
+		return fmt.Errorf("%s: %w", "cannot read ota file", err)
+	}
+	results := run(ctx, r.uploader, r.otapi, ids, payload, r.state.Expiration, uploadLimits{}, nil)
+	for _, res := range results {
+		d := w.device(res.ID)
+		switch {
+		case errors.Is(res.Err, errNotSent):
+			// Left pending, to be uploaded when the rollout is resumed
+		case res.Err != nil:
+			d.Status, d.Error = otaapi.StatusFailed, res.Err.Error()
+		case res.OtaStatus.ID == "":
+			d.Status, d.Error = otaapi.StatusFailed, "cannot retrieve OTA status"
+		default:
+			d.OtaID = res.OtaStatus.ID
+			d.update(res.OtaStatus)
+		}
+	}
+	return nil
+}
+
+// maxPollFailures is the number of consecutive failed status polls
+// after which the wave timeout is applied without fresh statuses.
+const maxPollFailures = 5
+
+// wait polls the statuses of the OTAs of the wave until all of them are ended.
+// OTAs still running when the wave times out are considered failed.
+// The timeout is applied only after the statuses have been polled,
+// so that a wave resumed after its timeout is checked before being judged.
+func (r *rollout) wait(ctx context.Context, w *RolloutWave) error {
+	deadline := w.StartedAt.Add(time.Duration(r.state.WaveTimeout) * time.Second)
+	polled, failures := false, 0
+	for {
+		var running []string
+		for _, d := range w.Devices {
+			if d.OtaID != "" && !d.ended() {
+				running = append(running, d.OtaID)
+			}
+		}
+		if len(running) == 0 {
+			return nil
+		}
+		if (polled || failures >= maxPollFailures) && time.Now().After(deadline) {
+			for i := range w.Devices {
+				if d := &w.Devices[i]; !d.ended() {
+					d.Status, d.Error = deviceTimeout, "OTA not ended before the wave timeout"
+				}
+			}
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(r.poll):
+		}
+
+		res, err := r.otapi.GetOtaStatusByOtaIDs(strings.Join(running, ","))
+		if err != nil {
+			logrus.Warnf("Cannot retrieve OTA statuses: %v", err)
+			failures++
+			continue
+		}
+		polled, failures = true, 0
+		for _, o := range res.Ota {
+			if d := w.deviceByOta(o.ID); d != nil {
+				d.update(o)
+			}
+		}
+		if err := r.state.save(r.path); err != nil {
+			return err
+		}
+	}
+}
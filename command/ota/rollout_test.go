@@ -0,0 +1,240 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ota
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	otaapi "github.com/arduino/arduino-cloud-cli/internal/ota-api"
+	iotclient "github.com/arduino/iot-client-go/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rolloutClientTest simulates OTAs that are in progress the first time
+// their status is read, and then succeed unless their device is failing.
+type rolloutClientTest struct {
+	mu      sync.Mutex
+	failing map[string]bool
+	stuck   bool // OTAs never end
+	uploads []string
+	reads   map[string]int
+}
+
+func (c *rolloutClientTest) DeviceOTA(ctx context.Context, id string, firmware io.Reader, expireMins int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.uploads = append(c.uploads, id)
+	return nil
+}
+
+func (c *rolloutClientTest) GetOtaLastStatusByDeviceID(deviceID string) (*otaapi.OtaStatusList, error) {
+	return &otaapi.OtaStatusList{Ota: []otaapi.Ota{{ID: "ota-" + deviceID, DeviceID: deviceID, Status: otaapi.StatusPending}}}, nil
+}
+
+func (c *rolloutClientTest) GetOtaStatusByOtaIDs(otaids string) (*otaapi.OtaStatusList, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	res := &otaapi.OtaStatusList{}
+	for _, id := range strings.Split(otaids, ",") {
+		c.reads[id]++
+		o := otaapi.Ota{ID: id, Status: otaapi.StatusInProgress}
+		if !c.stuck && c.reads[id] > 1 {
+			o.Status = otaapi.StatusSucceeded
+			if c.failing[strings.TrimPrefix(id, "ota-")] {
+				o.Status, o.ErrorReason = otaapi.StatusFailed, "flash failed"
+			}
+		}
+		res.Ota = append(res.Ota, o)
+	}
+	return res, nil
+}
+
+func testRollout(t *testing.T, client *rolloutClientTest, devices int) *rollout {
+	var ids []string
+	for i := 0; i < devices; i++ {
+		ids = append(ids, fmt.Sprintf("dev-%02d", i))
+	}
+	client.reads = make(map[string]int)
+	return &rollout{
+		uploader: client,
+		otapi:    client,
+		state: &RolloutState{
+			Status:           RolloutRunning,
+			HaltFailureRate:  20,
+			PauseFailureRate: 5,
+			WaveTimeout:      60,
+			Waves:            splitWaves(ids, DefaultWaves),
+		},
+		path:    filepath.Join(t.TempDir(), "rollout.json"),
+		otaFile: testFilename,
+		poll:    time.Millisecond,
+	}
+}
+
+func TestSplitWaves(t *testing.T) {
+	ids := make([]string, 20)
+	for i := range ids {
+		ids[i] = fmt.Sprint(i)
+	}
+	var sizes []int
+	for _, w := range splitWaves(ids, DefaultWaves) {
+		sizes = append(sizes, len(w.Devices))
+	}
+	assert.Equal(t, []int{1, 1, 8, 10}, sizes)
+
+	// Waves left empty are dropped
+	sizes = nil
+	for _, w := range splitWaves(ids[:2], DefaultWaves) {
+		sizes = append(sizes, len(w.Devices))
+	}
+	assert.Equal(t, []int{1, 1}, sizes)
+
+	assert.Error(t, validateWaves([]float64{10, 5, 100}))
+	assert.Error(t, validateWaves([]float64{10, 50}))
+	assert.NoError(t, validateWaves([]float64{5, 100}))
+}
+
+func TestRollout(t *testing.T) {
+	client := &rolloutClientTest{}
+	r := testRollout(t, client, 20)
+	require.NoError(t, r.run(context.Background()))
+
+	assert.Equal(t, RolloutCompleted, r.state.Status)
+	assert.Len(t, client.uploads, 20)
+	assert.Equal(t, 20, r.state.Count(otaapi.StatusSucceeded))
+
+	saved, err := loadRolloutState(r.path)
+	require.NoError(t, err)
+	assert.Equal(t, RolloutCompleted, saved.Status)
+}
+
+func TestRolloutHalt(t *testing.T) {
+	// 2 failures out of 8 devices in the third wave
+	client := &rolloutClientTest{failing: map[string]bool{"dev-03": true, "dev-07": true}}
+	r := testRollout(t, client, 20)
+	require.NoError(t, r.run(context.Background()))
+
+	assert.Equal(t, RolloutHalted, r.state.Status)
+	assert.Len(t, client.uploads, 10)
+	assert.Equal(t, 25.0, r.state.Waves[2].FailureRate)
+	assert.Equal(t, wavePending, r.state.Waves[3].Status)
+}
+
+func TestRolloutPauseAndResume(t *testing.T) {
+	// 1 failure out of 8 devices in the third wave
+	client := &rolloutClientTest{failing: map[string]bool{"dev-05": true}}
+	r := testRollout(t, client, 20)
+	require.NoError(t, r.run(context.Background()))
+	assert.Equal(t, RolloutPaused, r.state.Status)
+	assert.Len(t, client.uploads, 10)
+
+	// The rollout is resumed from the saved state
+	state, err := loadRolloutState(r.path)
+	require.NoError(t, err)
+	state.Status = RolloutRunning
+	r.state = state
+	require.NoError(t, r.run(context.Background()))
+	assert.Equal(t, RolloutCompleted, r.state.Status)
+	assert.Len(t, client.uploads, 20)
+
+	// Confirmation lets the rollout proceed without pausing
+	client = &rolloutClientTest{failing: map[string]bool{"dev-05": true}}
+	r = testRollout(t, client, 20)
+	confirmed := 0
+	r.confirm = func(w *RolloutWave) bool {
+		confirmed++
+		return true
+	}
+	require.NoError(t, r.run(context.Background()))
+	assert.Equal(t, RolloutCompleted, r.state.Status)
+	assert.Equal(t, 1, confirmed)
+}
+
+func TestRolloutTimeout(t *testing.T) {
+	client := &rolloutClientTest{stuck: true}
+	r := testRollout(t, client, 20)
+	r.state.WaveTimeout = 0
+	require.NoError(t, r.run(context.Background()))
+
+	assert.Equal(t, RolloutHalted, r.state.Status)
+	assert.Len(t, client.uploads, 1)
+	assert.Equal(t, deviceTimeout, r.state.Waves[0].Devices[0].Status)
+}
+
+func TestRolloutResumedAfterTimeout(t *testing.T) {
+	// The first wave was interrupted long ago, while its OTA was in progress:
+	// the OTA has since succeeded and must not be judged as timed out
+	client := &rolloutClientTest{}
+	r := testRollout(t, client, 20)
+	client.reads["ota-dev-00"] = 1
+	startedAt := time.Now().Add(-time.Hour)
+	r.state.Waves[0].StartedAt = &startedAt
+	require.NoError(t, r.run(context.Background()))
+
+	assert.Equal(t, RolloutCompleted, r.state.Status)
+	assert.Equal(t, otaapi.StatusSucceeded, r.state.Waves[0].Devices[0].Status)
+}
+
+func TestResumeHaltedRollout(t *testing.T) {
+	client := &rolloutClientTest{failing: map[string]bool{"dev-00": true}}
+	r := testRollout(t, client, 20)
+	r.state.File = testFilename
+	hash, err := fileSHA256(testFilename)
+	require.NoError(t, err)
+	r.state.FileSHA256 = hash
+	require.NoError(t, r.run(context.Background()))
+	require.Equal(t, RolloutHalted, r.state.Status)
+
+	_, err = resumeRollout(r.path, false)
+	assert.ErrorContains(t, err, "--force")
+
+	state, err := resumeRollout(r.path, true)
+	require.NoError(t, err)
+	assert.Equal(t, RolloutRunning, state.Status)
+}
+
+func TestNewRolloutDelta(t *testing.T) {
+	fqbn := "arduino:samd:nano_33_iot"
+	deltaFile, base := writeDelta(t)
+	lister := &deviceListerTest{list: []iotclient.ArduinoDevicev2{
+		{Id: "dev-base", Fqbn: &fqbn, Tags: map[string]interface{}{FirmwareHashTag: base}},
+		{Id: "dev-other", Fqbn: &fqbn, Tags: map[string]interface{}{FirmwareHashTag: strings.Repeat("1", 64)}},
+		{Id: "dev-untagged", Fqbn: &fqbn},
+	}}
+
+	// Only the device running the base of the delta is updated
+	state, err := newRollout(context.Background(), lister, &RolloutParams{
+		DeviceIDs: []string{"dev-base", "dev-other", "dev-untagged"},
+		File:      deltaFile,
+		FQBN:      fqbn,
+		Waves:     []float64{100},
+		StateFile: filepath.Join(t.TempDir(), "rollout.json"),
+	})
+	require.NoError(t, err)
+	require.Len(t, state.Waves, 1)
+	require.Len(t, state.Waves[0].Devices, 1)
+	assert.Equal(t, "dev-base", state.Waves[0].Devices[0].ID)
+}
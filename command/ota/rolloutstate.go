@@ -0,0 +1,162 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ota
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	otaapi "github.com/arduino/arduino-cloud-cli/internal/ota-api"
+)
+
+// RolloutState is the state of a rollout, saved
+// to disk so that the rollout can be resumed.
+type RolloutState struct {
+	Status           string        `json:"status"`
+	File             string        `json:"file"`
+	FileSHA256       string        `json:"file_sha256"`
+	FQBN             string        `json:"fqbn"`
+	DoNotApplyHeader bool          `json:"no_header"`
+	Expiration       int           `json:"expiration_mins"`
+	HaltFailureRate  float64       `json:"halt_failure_rate"`
+	PauseFailureRate float64       `json:"pause_failure_rate"`
+	WaveTimeout      int64         `json:"wave_timeout_secs"`
+	Waves            []RolloutWave `json:"waves"`
+}
+
+// RolloutWave is a group of devices updated together.
+type RolloutWave struct {
+	Percent     float64         `json:"percent"`
+	Status      string          `json:"status"`
+	StartedAt   *time.Time      `json:"started_at,omitempty"`
+	FailureRate float64         `json:"failure_rate"`
+	Devices     []RolloutDevice `json:"devices"`
+}
+
+// RolloutDevice is the state of the OTA of a device.
+type RolloutDevice struct {
+	ID     string `json:"id"`
+	OtaID  string `json:"ota_id,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+func loadRolloutState(path string) (*RolloutState, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	state := &RolloutState{}
+	if err := json.Unmarshal(content, state); err != nil {
+		return nil, fmt.Errorf("parsing rollout state %s: %w", path, err)
+	}
+	return state, nil
+}
+
+// save writes the state atomically, so that an
+// interruption never leaves a truncated file.
+func (s *RolloutState) save(path string) error {
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0644); err != nil {
+		return fmt.Errorf("saving rollout state: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// Count returns the number of devices of the rollout with the passed status.
+func (s *RolloutState) Count(status string) int {
+	n := 0
+	for _, w := range s.Waves {
+		for _, d := range w.Devices {
+			if d.Status == status {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func (w *RolloutWave) device(id string) *RolloutDevice {
+	for i := range w.Devices {
+		if w.Devices[i].ID == id {
+			return &w.Devices[i]
+		}
+	}
+	return nil
+}
+
+func (w *RolloutWave) deviceByOta(otaID string) *RolloutDevice {
+	for i := range w.Devices {
+		if w.Devices[i].OtaID == otaID {
+			return &w.Devices[i]
+		}
+	}
+	return nil
+}
+
+// failureRate returns the percentage of devices of the wave whose OTA didn't succeed.
+func (w *RolloutWave) failureRate() float64 {
+	if len(w.Devices) == 0 {
+		return 0
+	}
+	failed := 0
+	for _, d := range w.Devices {
+		if d.Status != otaapi.StatusSucceeded {
+			failed++
+		}
+	}
+	return float64(failed) / float64(len(w.Devices)) * 100
+}
+
+func (d *RolloutDevice) update(o otaapi.Ota) {
+	d.Status = strings.ToLower(o.Status)
+	d.Error = o.ErrorReason
+}
+
+// Failed reports whether the OTA of the device ended without success.
+func (d RolloutDevice) Failed() bool {
+	return d.ended() && d.Status != otaapi.StatusSucceeded
+}
+
+// ended reports whether the OTA of the device is over.
+func (d RolloutDevice) ended() bool {
+	return d.Status == deviceTimeout || otaapi.Ota{Status: d.Status}.IsTerminal()
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
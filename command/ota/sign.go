@@ -0,0 +1,82 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ota
+
+import (
+	"fmt"
+
+	"github.com/arduino/arduino-cloud-cli/internal/ota"
+	"github.com/sirupsen/logrus"
+)
+
+// firmwareSHA256 returns the SHA-256 of the payload contained
+// in the passed file, as received by the device. For delta OTAs
+// it's the SHA-256 of the patch.
+func firmwareSHA256(file string) (string, error) {
+	if header, err := ota.DecodeOtaFirmwareHeaderFromFile(file); err == nil {
+		return header.PayloadSHA256, nil
+	}
+	// Files uploaded without header reach the device as they are,
+	// and files without header get one with the same payload
+	hash, err := fileSHA256(file)
+	if err != nil {
+		return "", fmt.Errorf("computing firmware hash: %w", err)
+	}
+	return hash, nil
+}
+
+// signFirmware signs the firmware contained in the passed file with
+// the passed private key, writing the signature in the sidecar file
+// returned by ota.SignatureFile.
+func signFirmware(file, keyFile string) error {
+	key, err := ota.LoadPrivateKey(keyFile)
+	if err != nil {
+		return err
+	}
+	hash, err := firmwareSHA256(file)
+	if err != nil {
+		return err
+	}
+	sig, err := ota.Sign(hash, key)
+	if err != nil {
+		return err
+	}
+	return ota.WriteSignature(ota.SignatureFile(file), sig)
+}
+
+// verifyFirmware checks the signature of the firmware contained in
+// the passed file, read from its sidecar file, with the passed public key.
+func verifyFirmware(file, keyFile string) error {
+	key, err := ota.LoadPublicKey(keyFile)
+	if err != nil {
+		return err
+	}
+	sig, err := ota.ReadSignature(ota.SignatureFile(file))
+	if err != nil {
+		return err
+	}
+	hash, err := firmwareSHA256(file)
+	if err != nil {
+		return err
+	}
+	if err := sig.Verify(hash, key); err != nil {
+		return fmt.Errorf("firmware %s not uploaded: %w", file, err)
+	}
+	logrus.Infof("Signature of firmware %s verified", file)
+	return nil
+}
@@ -0,0 +1,109 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ota
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/arduino/arduino-cloud-cli/internal/ota"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignVerifyFirmware(t *testing.T) {
+	dir := t.TempDir()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	require.NoError(t, err)
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+	privPath, pubPath := filepath.Join(dir, "key.pem"), filepath.Join(dir, "pub.pem")
+	require.NoError(t, os.WriteFile(privPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}), 0600))
+	require.NoError(t, os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0644))
+
+	otaFile := filepath.Join(dir, "cloud.ota")
+	require.NoError(t, Generate(cloudFirmwareFilename, otaFile, "arduino:samd:nano_33_iot"))
+	require.NoError(t, signFirmware(otaFile, privPath))
+	assert.NoError(t, verifyFirmware(otaFile, pubPath))
+
+	// The signature covers the firmware, so it is valid for the .bin too
+	binFile := filepath.Join(dir, "cloud.bin")
+	bin, err := os.ReadFile(cloudFirmwareFilename)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(binFile, bin, 0644))
+	sig, err := os.ReadFile(ota.SignatureFile(otaFile))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(ota.SignatureFile(binFile), sig, 0644))
+	assert.NoError(t, verifyFirmware(binFile, pubPath))
+
+	// Tampered firmware
+	bin[len(bin)-1] ^= 0xff
+	require.NoError(t, os.WriteFile(binFile, bin, 0644))
+	assert.ErrorIs(t, verifyFirmware(binFile, pubPath), ota.ErrSignatureMismatch)
+
+	// Missing signature
+	assert.Error(t, verifyFirmware(cloudFirmwareFilename, pubPath))
+}
+
+func TestVerifyTamperedDelta(t *testing.T) {
+	dir := t.TempDir()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	require.NoError(t, err)
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+	privPath, pubPath := filepath.Join(dir, "key.pem"), filepath.Join(dir, "pub.pem")
+	require.NoError(t, os.WriteFile(privPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}), 0600))
+	require.NoError(t, os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0644))
+
+	old, err := os.ReadFile(cloudFirmwareFilename)
+	require.NoError(t, err)
+	new := append([]byte{}, old...)
+	copy(new[1000:], "new firmware")
+	newFile := filepath.Join(dir, "new.bin")
+	require.NoError(t, os.WriteFile(newFile, new, 0644))
+	deltaFile := filepath.Join(dir, "patch.ota")
+	_, err = Delta(&DeltaParams{From: cloudFirmwareFilename, To: newFile, Out: deltaFile, FQBN: "arduino:samd:nano_33_iot", SignKey: privPath})
+	require.NoError(t, err)
+	require.NoError(t, verifyFirmware(deltaFile, pubPath))
+
+	// The patch is altered, keeping the SHA-256 of the new firmware it
+	// declares and a valid CRC: the signature must not match anymore
+	content, err := os.ReadFile(deltaFile)
+	require.NoError(t, err)
+	content[len(content)-1] ^= 0xff
+	binary.LittleEndian.PutUint32(content[4:8], crc32.ChecksumIEEE(content[8:]))
+	require.NoError(t, os.WriteFile(deltaFile, content, 0644))
+	header, err := ota.DecodeOtaFirmwareHeaderFromFile(deltaFile)
+	require.NoError(t, err)
+	require.NotNil(t, header.Delta)
+	hash, err := fileSHA256(newFile)
+	require.NoError(t, err)
+	require.Equal(t, hash, header.Delta.NewSHA256)
+	assert.ErrorIs(t, verifyFirmware(deltaFile, pubPath), ota.ErrSignatureMismatch)
+}
@@ -0,0 +1,70 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ota
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/arduino/arduino-cloud-cli/internal/ota"
+	otaapi "github.com/arduino/arduino-cloud-cli/internal/ota-api"
+	"github.com/sirupsen/logrus"
+)
+
+// FirmwareHashTag is the device tag holding the SHA-256 of the firmware
+// last installed on the device. It's applied once the OTA of a device
+// is confirmed successful, see successTags.
+const FirmwareHashTag = "firmware_sha256"
+
+type deviceTagger interface {
+	DeviceTagsCreate(ctx context.Context, id string, tags map[string]string) error
+}
+
+// successTags returns the tags to apply to the devices successfully
+// updated with the passed ota file: the passed ones and
+// the SHA-256 of the firmware, as seen by the device.
+func successTags(otaFile string, tags map[string]string) (map[string]string, error) {
+	hash, err := firmwareSHA256(otaFile)
+	if err != nil {
+		return nil, err
+	}
+	// A successful delta OTA leaves the device running the firmware
+	// produced by the patch, checked against the SHA-256 it declares
+	if header, err := ota.DecodeOtaFirmwareHeaderFromFile(otaFile); err == nil && header.Delta != nil {
+		hash = header.Delta.NewSHA256
+	}
+
+	all := map[string]string{FirmwareHashTag: hash}
+	for k, v := range tags {
+		all[k] = v
+	}
+	return all, nil
+}
+
+// tagDevice applies the tags to a device, if its OTA succeeded.
+func tagDevice(ctx context.Context, tagger deviceTagger, deviceID string, o otaapi.Ota, tags map[string]string) error {
+	if strings.ToLower(o.Status) != otaapi.StatusSucceeded {
+		return nil
+	}
+	logrus.Infof("Tagging device %s", deviceID)
+	if err := tagger.DeviceTagsCreate(ctx, deviceID, tags); err != nil {
+		return fmt.Errorf("OTA succeeded but tagging device failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ota
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	otaapi "github.com/arduino/arduino-cloud-cli/internal/ota-api"
+	"github.com/stretchr/testify/assert"
+)
+
+type deviceTaggerTest struct {
+	tagged map[string]map[string]string
+	fail   string
+}
+
+func (d *deviceTaggerTest) DeviceTagsCreate(ctx context.Context, id string, tags map[string]string) error {
+	if id == d.fail {
+		return errors.New("tag rejected")
+	}
+	d.tagged[id] = tags
+	return nil
+}
+
+func TestSuccessTags(t *testing.T) {
+	// Without header the hash of the file is used
+	tags, err := successTags(testFilename, map[string]string{"version": "2"})
+	assert.NoError(t, err)
+	hash, err := fileSHA256(testFilename)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{FirmwareHashTag: hash, "version": "2"}, tags)
+
+	// With header the hash of the payload is used
+	dir := t.TempDir()
+	otaFile := dir + "/fw.ota"
+	assert.NoError(t, Generate(cloudFirmwareFilename, otaFile, "arduino:samd:nano_33_iot"))
+	tags, err = successTags(otaFile, nil)
+	assert.NoError(t, err)
+	otaHash, _ := fileSHA256(otaFile)
+	assert.NotEqual(t, otaHash, tags[FirmwareHashTag])
+	assert.Len(t, tags[FirmwareHashTag], 64)
+
+	_, err = successTags(dir+"/missing.ota", nil)
+	assert.Error(t, err)
+}
+
+func TestTagResults(t *testing.T) {
+	tagger := &deviceTaggerTest{tagged: make(map[string]map[string]string), fail: "dev-3"}
+	tags := map[string]string{FirmwareHashTag: "abc", "version": "2"}
+	res := []Result{
+		{ID: "dev-1", OtaStatus: otaapi.Ota{ID: "ota-1", Status: otaapi.StatusSucceeded}},
+		{ID: "dev-2", OtaStatus: otaapi.Ota{ID: "ota-2", Status: otaapi.StatusFailed}},
+		{ID: "dev-3", OtaStatus: otaapi.Ota{ID: "ota-3", Status: otaapi.StatusSucceeded}},
+		{ID: "dev-4", OtaStatus: otaapi.Ota{ID: "ota-4", Status: StatusTimeout}},
+	}
+	tagResults(context.Background(), tagger, res, tags)
+
+	assert.Equal(t, map[string]map[string]string{"dev-1": tags}, tagger.tagged)
+	assert.NoError(t, res[0].Err)
+	assert.NoError(t, res[1].Err)
+	assert.Error(t, res[2].Err)
+	assert.NoError(t, res[3].Err)
+}
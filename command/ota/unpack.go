@@ -0,0 +1,98 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ota
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"github.com/arduino/arduino-cloud-cli/internal/ota"
+)
+
+// UnpackParams contains the parameters needed
+// to extract the binary contained in an OTA file.
+type UnpackParams struct {
+	File      string // OTA file (.ota) to be unpacked
+	Out       string // Binary file (.bin) to be written
+	Reference string // Binary file (.bin) to be compared with the unpacked one; optional
+}
+
+// UnpackResult describes an unpacked OTA file.
+type UnpackResult struct {
+	File      string
+	Size      int
+	SHA256    string
+	Reference string `json:",omitempty"` // Set if the binary matches the reference file
+}
+
+// Unpack command extracts the binary contained in an OTA file,
+// after verifying its CRC32 and length.
+// If a reference binary is passed, the extracted binary is compared with it:
+// an error is returned if they differ, after the binary has been written.
+func Unpack(params *UnpackParams) (*UnpackResult, error) {
+	file, err := os.Open(params.File)
+	if err != nil {
+		return nil, fmt.Errorf("file %s does not exists: %w", params.File, err)
+	}
+	defer file.Close()
+
+	bin, _, err := ota.Unpack(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot unpack %s: %w", params.File, err)
+	}
+	if err := os.WriteFile(params.Out, bin, 0644); err != nil {
+		return nil, fmt.Errorf("%s: %w", "cannot write binary file", err)
+	}
+
+	sum := sha256.Sum256(bin)
+	res := &UnpackResult{
+		File:   params.Out,
+		Size:   len(bin),
+		SHA256: hex.EncodeToString(sum[:]),
+	}
+	if params.Reference == "" {
+		return res, nil
+	}
+	ref, err := os.ReadFile(params.Reference)
+	if err != nil {
+		return nil, fmt.Errorf("file %s does not exists: %w", params.Reference, err)
+	}
+	if err := compareBinaries(bin, ref); err != nil {
+		return nil, fmt.Errorf("binary unpacked from %s differs from %s: %w", params.File, params.Reference, err)
+	}
+	res.Reference = params.Reference
+	return res, nil
+}
+
+// compareBinaries returns an error describing
+// the first difference between the passed binaries, if any.
+func compareBinaries(bin, ref []byte) error {
+	if bytes.Equal(bin, ref) {
+		return nil
+	}
+	n := min(len(bin), len(ref))
+	for i := 0; i < n; i++ {
+		if bin[i] != ref[i] {
+			return fmt.Errorf("first difference at offset 0x%X", i)
+		}
+	}
+	return fmt.Errorf("size is %d bytes instead of %d", len(bin), len(ref))
+}
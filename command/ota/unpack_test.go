@@ -0,0 +1,63 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ota
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnpack(t *testing.T) {
+	dir := t.TempDir()
+	otaFile := filepath.Join(dir, "cloud.ota")
+	require.NoError(t, Generate(cloudFirmwareFilename, otaFile, "arduino:samd:nano_33_iot"))
+
+	out := filepath.Join(dir, "cloud.bin")
+	res, err := Unpack(&UnpackParams{File: otaFile, Out: out, Reference: cloudFirmwareFilename})
+	require.NoError(t, err)
+	assert.Equal(t, cloudFirmwareFilename, res.Reference)
+	hash, err := fileSHA256(cloudFirmwareFilename)
+	require.NoError(t, err)
+	assert.Equal(t, hash, res.SHA256)
+	bin, err := os.ReadFile(out)
+	require.NoError(t, err)
+	ref, err := os.ReadFile(cloudFirmwareFilename)
+	require.NoError(t, err)
+	assert.Equal(t, ref, bin)
+
+	// The binary is written even if it differs from the reference
+	other := filepath.Join(dir, "other.bin")
+	ref[100] ^= 0xFF
+	require.NoError(t, os.WriteFile(other, ref, 0644))
+	require.NoError(t, os.Remove(out))
+	_, err = Unpack(&UnpackParams{File: otaFile, Out: out, Reference: other})
+	assert.ErrorContains(t, err, "offset 0x64")
+	assert.FileExists(t, out)
+
+	require.NoError(t, os.WriteFile(other, ref[:100], 0644))
+	_, err = Unpack(&UnpackParams{File: otaFile, Out: out, Reference: other})
+	assert.ErrorContains(t, err, "instead of 100")
+
+	// Binaries without OTA header are refused
+	_, err = Unpack(&UnpackParams{File: cloudFirmwareFilename, Out: out})
+	assert.Error(t, err)
+}
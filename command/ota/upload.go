@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/arduino/arduino-cli/cli/feedback"
 	"github.com/arduino/arduino-cloud-cli/config"
@@ -45,6 +46,10 @@ type UploadParams struct {
 	File             string
 	Deferred         bool
 	DoNotApplyHeader bool
+	Wait             bool              // Wait for the OTA to end
+	Timeout          time.Duration     // Maximum time waited for the OTA to end
+	SuccessTags      map[string]string // Tags applied to the device once the OTA succeeded, along with FirmwareHashTag; used with Wait
+	VerifyKey        string            // Public key verifying the signature of File before the upload; optional
 }
 
 // Upload command is used to upload a firmware OTA,
@@ -55,6 +60,18 @@ func Upload(ctx context.Context, params *UploadParams, cred *config.Credentials)
 		return fmt.Errorf("file %s does not exists: %w", params.File, err)
 	}
 
+	// Firmwares whose signature is not valid are never sent
+	if params.VerifyKey != "" {
+		if err := verifyFirmware(params.File, params.VerifyKey); err != nil {
+			return err
+		}
+	}
+
+	manifest, err := loadManifest(params.File)
+	if err != nil {
+		return err
+	}
+
 	iotClient, err := iot.NewClient(cred)
 	if err != nil {
 		return err
@@ -65,6 +82,10 @@ func Upload(ctx context.Context, params *UploadParams, cred *config.Credentials)
 	if err != nil {
 		return err
 	}
+	filter := deviceFilter{fqbn: dereferenceString(dev.Fqbn), manifest: manifest, deltaBase: deltaBase(params.File)}
+	if err := filter.check(dev); err != nil {
+		return fmt.Errorf("device %s cannot be updated: %w", params.DeviceID, err)
+	}
 
 	if !params.DoNotApplyHeader {
 		//Verify if file has already an OTA header
@@ -91,6 +112,14 @@ func Upload(ctx context.Context, params *UploadParams, cred *config.Credentials)
 		}
 	}
 
+	var tags map[string]string
+	if params.Wait {
+		if tags, err = successTags(otaFile, params.SuccessTags); err != nil {
+			return err
+		}
+		tags = versionTags(tags, manifest)
+	}
+
 	file, err := os.Open(otaFile)
 	if err != nil {
 		return fmt.Errorf("%s: %w", "cannot open ota file", err)
@@ -120,6 +149,23 @@ func Upload(ctx context.Context, params *UploadParams, cred *config.Credentials)
 	if err != nil {
 		return err
 	}
+	if params.Wait && otaID != nil && len(otaID.Ota) > 0 {
+		otas, err := waitOtas(ctx, otapi, otaID.Ota[:1], params.Timeout)
+		if err != nil {
+			return err
+		}
+		if conflictedOta == nil {
+			if err := tagDevice(ctx, iotClient, params.DeviceID, otas[0], tags); err != nil {
+				return err
+			}
+		}
+		if conflictedOta != nil {
+			otas = append([]otaapi.Ota{*conflictedOta}, otas...)
+		}
+		summary := WaitSummary{Otas: otas}
+		feedback.PrintResult(summary)
+		return summary.Err()
+	}
 	if otaID != nil && len(otaID.Ota) > 0 {
 		if conflictedOta != nil {
 			toPrint := otaapi.OtaStatusList{
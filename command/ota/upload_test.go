@@ -0,0 +1,74 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ota
+
+import (
+	"context"
+	"testing"
+
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/arduino/arduino-cloud-cli/internal/httpclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpload(t *testing.T) {
+	tests := []struct {
+		name     string
+		cassette string
+	}{
+		{
+			name:     "upload",
+			cassette: "testdata/upload.cassette.json",
+		},
+		{
+			name:     "ota-already-in-progress",
+			cassette: "testdata/upload-conflict.cassette.json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(httpclient.CassetteEnv, tt.cassette)
+			t.Setenv(httpclient.CassetteModeEnv, httpclient.CassetteReplay)
+			cred := &config.Credentials{Client: "test-client", Secret: "test-secret"}
+
+			params := &UploadParams{
+				DeviceID: "5e2b9a41-7c3d-4f60-8a1b-2c3d4e5f6a7b",
+				File:     cloudFirmwareFilename,
+			}
+			if err := Upload(context.Background(), params, cred); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestUploadDeltaUntagged(t *testing.T) {
+	t.Setenv(httpclient.CassetteEnv, "testdata/upload.cassette.json")
+	t.Setenv(httpclient.CassetteModeEnv, httpclient.CassetteReplay)
+	cred := &config.Credentials{Client: "test-client", Secret: "test-secret"}
+
+	// The device is not tagged with the firmware it runs
+	deltaFile, _ := writeDelta(t)
+	params := &UploadParams{
+		DeviceID: "5e2b9a41-7c3d-4f60-8a1b-2c3d4e5f6a7b",
+		File:     deltaFile,
+	}
+	err := Upload(context.Background(), params, cred)
+	assert.ErrorContains(t, err, "has no '"+FirmwareHashTag+"' tag")
+}
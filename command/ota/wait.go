@@ -0,0 +1,163 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ota
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/arduino/arduino-cli/cli/feedback"
+	"github.com/arduino/arduino-cli/table"
+	otaapi "github.com/arduino/arduino-cloud-cli/internal/ota-api"
+	"github.com/sirupsen/logrus"
+)
+
+// StatusTimeout is the status of the OTAs that
+// didn't end before the wait timeout expired.
+const StatusTimeout = "timeout"
+
+// StatusUnknown is the status of the OTAs whose
+// status could not be retrieved.
+const StatusUnknown = "unknown"
+
+// DefaultWaitTimeout is the default maximum time waited for OTAs to end.
+const DefaultWaitTimeout = 30 * time.Minute
+
+// waitPollInterval is the interval between two checks of the OTA statuses.
+var waitPollInterval = 5 * time.Second
+
+type otaDetailGetter interface {
+	GetOtaStatusByOtaID(otaid string, limit int, order string) (*otaapi.OtaStatusResponse, error)
+}
+
+// waitOtas polls the passed OTAs until all of them end or the timeout expires,
+// reporting the new states of each of them as they are received.
+// It returns the final statuses of the OTAs: the ones still
+// running when the timeout expires have status StatusTimeout,
+// the ones whose status could not be retrieved have status StatusUnknown.
+func waitOtas(ctx context.Context, otapi otaDetailGetter, otas []otaapi.Ota, timeout time.Duration) ([]otaapi.Ota, error) {
+	final := make([]otaapi.Ota, len(otas))
+	copy(final, otas)
+	seen := make([]int, len(otas))     // Number of states already reported, for each OTA
+	unread := make([]error, len(otas)) // Error of the last status retrieval, for each OTA
+	deadline := time.Now().Add(timeout)
+
+	for {
+		running := 0
+		for i := range final {
+			o := &final[i]
+			if o.ID == "" || o.IsTerminal() || o.Status == StatusTimeout {
+				continue
+			}
+			res, err := otapi.GetOtaStatusByOtaID(o.ID, 0, "asc")
+			if err != nil {
+				logrus.Warnf("Cannot retrieve status of OTA %s: %v", o.ID, err)
+				unread[i] = err
+				running++
+				continue
+			}
+			unread[i] = nil
+			for _, s := range res.States[min(seen[i], len(res.States)):] {
+				reportState(res.Ota, s)
+			}
+			seen[i] = len(res.States)
+			*o = res.Ota
+			if !o.IsTerminal() {
+				running++
+			}
+		}
+		if running == 0 {
+			return final, nil
+		}
+
+		if time.Now().After(deadline) {
+			for i := range final {
+				o := &final[i]
+				switch {
+				case o.ID == "" || o.IsTerminal():
+				case unread[i] != nil:
+					o.Status, o.ErrorReason = StatusUnknown, fmt.Sprintf("cannot retrieve status: %v", unread[i])
+				default:
+					o.Status, o.ErrorReason = StatusTimeout, "OTA not ended before the timeout"
+				}
+			}
+			return final, nil
+		}
+		select {
+		case <-ctx.Done():
+			return final, ctx.Err()
+		case <-time.After(waitPollInterval):
+		}
+	}
+}
+
+func reportState(o otaapi.Ota, s otaapi.State) {
+	if feedback.GetFormat() != feedback.Text {
+		logrus.Infof("Device %s: %s", o.DeviceID, s.Describe(o.FirmwareSize))
+		return
+	}
+	feedback.Printf("Device %s: %s", o.DeviceID, s.Describe(o.FirmwareSize))
+}
+
+// WaitSummary contains the final statuses of OTAs that have been waited for.
+type WaitSummary struct {
+	Otas []otaapi.Ota
+}
+
+func (r WaitSummary) Data() interface{} {
+	return r.Otas
+}
+
+func (r WaitSummary) String() string {
+	t := table.New()
+	t.SetHeader("Device ID", "Ota ID", "Result", "Error Reason")
+	for _, o := range r.Otas {
+		t.AddRow(o.DeviceID, o.ID, o.MapStatus(), o.ErrorReason)
+	}
+	succeeded, failed, timedOut, unknown := r.counts()
+	return t.Render() + fmt.Sprintf("\nSucceeded: %d, failed: %d, timed out: %d, unknown: %d", succeeded, failed, timedOut, unknown)
+}
+
+// counts returns the number of OTAs by outcome. OTAs not ended,
+// e.g. because the wait has been interrupted, have an unknown outcome.
+func (r WaitSummary) counts() (succeeded, failed, timedOut, unknown int) {
+	for _, o := range r.Otas {
+		switch strings.ToLower(o.Status) {
+		case otaapi.StatusSucceeded:
+			succeeded++
+		case StatusTimeout:
+			timedOut++
+		case StatusUnknown, otaapi.StatusPending, otaapi.StatusInProgress:
+			unknown++
+		default:
+			failed++
+		}
+	}
+	return succeeded, failed, timedOut, unknown
+}
+
+// Err returns an error if any of the OTAs didn't succeed.
+func (r WaitSummary) Err() error {
+	_, failed, timedOut, unknown := r.counts()
+	if failed+timedOut+unknown == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d OTAs failed, %d timed out and %d have an unknown outcome", failed, timedOut, unknown)
+}
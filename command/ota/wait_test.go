@@ -0,0 +1,134 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ota
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	otaapi "github.com/arduino/arduino-cloud-cli/internal/ota-api"
+	"github.com/stretchr/testify/assert"
+)
+
+// otaDetailGetterTest makes OTAs go through the passed statuses,
+// one each time they are read, adding a state at every read.
+type otaDetailGetterTest struct {
+	statuses    map[string][]string
+	reads       map[string]int
+	unreachable map[string]bool // OTAs whose status can't be retrieved
+}
+
+func (g *otaDetailGetterTest) GetOtaStatusByOtaID(otaid string, limit int, order string) (*otaapi.OtaStatusResponse, error) {
+	if g.unreachable[otaid] {
+		return nil, errors.New("service unavailable")
+	}
+	n := g.reads[otaid]
+	g.reads[otaid]++
+	statuses := g.statuses[otaid]
+	status := statuses[min(n, len(statuses)-1)]
+
+	res := &otaapi.OtaStatusResponse{Ota: otaapi.Ota{ID: otaid, DeviceID: "dev-" + otaid, Status: status}}
+	if status == otaapi.StatusFailed {
+		res.Ota.ErrorReason = "flash failed"
+	}
+	for i := 0; i <= n; i++ {
+		res.States = append(res.States, otaapi.State{OtaID: otaid, State: "fetch"})
+	}
+	return res, nil
+}
+
+func TestWaitOtas(t *testing.T) {
+	defer func(d time.Duration) { waitPollInterval = d }(waitPollInterval)
+	waitPollInterval = time.Millisecond
+
+	getter := &otaDetailGetterTest{
+		statuses: map[string][]string{
+			"1": {otaapi.StatusPending, otaapi.StatusInProgress, otaapi.StatusSucceeded},
+			"2": {otaapi.StatusInProgress, otaapi.StatusFailed},
+			"3": {otaapi.StatusInProgress},
+		},
+		reads: make(map[string]int),
+	}
+	otas := []otaapi.Ota{
+		{ID: "1", Status: otaapi.StatusPending},
+		{ID: "2", Status: otaapi.StatusPending},
+		{ID: "3", Status: otaapi.StatusPending},
+		{DeviceID: "dev-4", Status: "Skipped"}, // Not started
+	}
+
+	final, err := waitOtas(context.Background(), getter, otas, 50*time.Millisecond)
+	assert.NoError(t, err)
+	var statuses []string
+	for _, o := range final {
+		statuses = append(statuses, o.Status)
+	}
+	assert.Equal(t, []string{otaapi.StatusSucceeded, otaapi.StatusFailed, StatusTimeout, "Skipped"}, statuses)
+	// Ended OTAs are not polled anymore
+	assert.Equal(t, 3, getter.reads["1"])
+	assert.Equal(t, 2, getter.reads["2"])
+
+	summary := WaitSummary{Otas: final}
+	succeeded, failed, timedOut, unknown := summary.counts()
+	assert.Equal(t, []int{1, 2, 1, 0}, []int{succeeded, failed, timedOut, unknown})
+	assert.Error(t, summary.Err())
+	assert.NoError(t, WaitSummary{Otas: final[:1]}.Err())
+}
+
+func TestWaitResultsInterrupted(t *testing.T) {
+	defer func(d time.Duration) { waitPollInterval = d }(waitPollInterval)
+	waitPollInterval = time.Millisecond
+
+	getter := &otaDetailGetterTest{
+		statuses: map[string][]string{
+			"1": {otaapi.StatusSucceeded},
+			"2": {otaapi.StatusInProgress},
+		},
+		reads:       make(map[string]int),
+		unreachable: map[string]bool{"3": true},
+	}
+	res := []Result{
+		{ID: "dev-1", OtaStatus: otaapi.Ota{ID: "1", DeviceID: "dev-1", Status: otaapi.StatusPending}},
+		{ID: "dev-2", OtaStatus: otaapi.Ota{ID: "2", DeviceID: "dev-2", Status: otaapi.StatusPending}},
+		{ID: "dev-3", OtaStatus: otaapi.Ota{ID: "3", DeviceID: "dev-3", Status: otaapi.StatusPending}},
+	}
+
+	// The statuses retrieved before the wait is interrupted are kept
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := waitResults(ctx, getter, res, time.Hour)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, otaapi.StatusSucceeded, res[0].OtaStatus.Status)
+	assert.Equal(t, otaapi.StatusInProgress, res[1].OtaStatus.Status)
+
+	tagger := &deviceTaggerTest{tagged: make(map[string]map[string]string)}
+	tagResults(context.Background(), tagger, res, map[string]string{"version": "2"})
+	assert.Len(t, tagger.tagged, 1)
+	assert.Contains(t, tagger.tagged, "dev-1")
+
+	// When the wait times out, OTAs whose status can't be read are unknown
+	err = waitResults(context.Background(), getter, res, 10*time.Millisecond)
+	assert.NoError(t, err)
+	assert.Equal(t, StatusTimeout, res[1].OtaStatus.Status)
+	assert.Equal(t, StatusUnknown, res[2].OtaStatus.Status)
+	assert.Contains(t, res[2].OtaStatus.ErrorReason, "service unavailable")
+	summary := WaitSummary{Otas: []otaapi.Ota{res[0].OtaStatus, res[1].OtaStatus, res[2].OtaStatus}}
+	succeeded, failed, timedOut, unknown := summary.counts()
+	assert.Equal(t, []int{1, 0, 1, 1}, []int{succeeded, failed, timedOut, unknown})
+}
@@ -0,0 +1,114 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package property
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/arduino/arduino-cloud-cli/internal/iot"
+	"github.com/arduino/arduino-cloud-cli/internal/template"
+	iotclient "github.com/arduino/iot-client-go/v3"
+)
+
+// CreateParams contains the parameters needed
+// to create a new variable of a thing.
+type CreateParams struct {
+	ThingID         string
+	Name            string
+	VariableName    string // Name of the variable in the sketch; if empty, Name is used
+	Type            string
+	Permission      string
+	UpdateStrategy  string
+	UpdateParameter *float64 // Seconds between updates for TIMED strategy, minimum delta for ON_CHANGE
+	Persist         bool
+	Tag             *int64
+}
+
+// Create command is used to add a new variable to a thing.
+func Create(ctx context.Context, params *CreateParams, cred *config.Credentials) (*PropertyInfo, error) {
+	property := &iotclient.Property{
+		Name:            params.Name,
+		Type:            params.Type,
+		Permission:      params.Permission,
+		UpdateStrategy:  params.UpdateStrategy,
+		UpdateParameter: params.UpdateParameter,
+		Tag:             params.Tag,
+	}
+	variableName := params.VariableName
+	if variableName == "" {
+		variableName = params.Name
+	}
+	property.VariableName = &variableName
+	if params.Persist {
+		property.Persist = &params.Persist
+	}
+	if err := validate(property); err != nil {
+		return nil, err
+	}
+
+	iotClient, err := iot.NewClient(cred)
+	if err != nil {
+		return nil, err
+	}
+
+	newProperty, err := iotClient.PropertyCreate(ctx, params.ThingID, property)
+	if err != nil {
+		return nil, err
+	}
+	return getPropertyInfo(newProperty), nil
+}
+
+// CreateFromFileParams contains the parameters needed
+// to create the variables of a thing from a file.
+type CreateFromFileParams struct {
+	ThingID string
+	File    string // File containing a list of variables or a thing template
+}
+
+// CreateFromFile command is used to add to a thing all the
+// variables contained in a file. The file is validated before
+// creating any variable; if a creation fails, the variables
+// created so far are returned along with the error.
+func CreateFromFile(ctx context.Context, params *CreateFromFileParams, cred *config.Credentials) ([]PropertyInfo, error) {
+	properties, err := template.LoadVariables(params.File)
+	if err != nil {
+		return nil, err
+	}
+	for i := range properties {
+		if err := validate(&properties[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	iotClient, err := iot.NewClient(cred)
+	if err != nil {
+		return nil, err
+	}
+
+	created := make([]PropertyInfo, 0, len(properties))
+	for i := range properties {
+		newProperty, err := iotClient.PropertyCreate(ctx, params.ThingID, &properties[i])
+		if err != nil {
+			return created, fmt.Errorf("creating variable %s: %w", properties[i].Name, err)
+		}
+		created = append(created, *getPropertyInfo(newProperty))
+	}
+	return created, nil
+}
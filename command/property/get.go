@@ -0,0 +1,102 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package property
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/arduino/arduino-cloud-cli/internal/iot"
+	iotclient "github.com/arduino/iot-client-go/v3"
+)
+
+// ValueInfo contains the current value of a variable.
+type ValueInfo struct {
+	Name      string      `json:"name"`
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	Value     interface{} `json:"value"`
+	UpdatedAt *time.Time  `json:"updated_at,omitempty"`
+}
+
+// GetParams contains the parameters needed to
+// get the value of a variable of a thing.
+// The variable is identified either by ID or by variable name.
+type GetParams struct {
+	ThingID string
+	ID      string // Property ID
+	Name    string // Variable name
+}
+
+// Get command is used to retrieve the last value of a variable.
+func Get(ctx context.Context, params *GetParams, cred *config.Credentials) (*ValueInfo, error) {
+	iotClient, err := iot.NewClient(cred)
+	if err != nil {
+		return nil, err
+	}
+
+	property, err := findProperty(ctx, iotClient, params.ThingID, params.ID, params.Name)
+	if err != nil {
+		return nil, err
+	}
+	return getValueInfo(property), nil
+}
+
+type propertyFinder interface {
+	PropertyShow(ctx context.Context, thingId, variableId string) (*iotclient.ArduinoProperty, error)
+	PropertyList(ctx context.Context, thingId string) ([]iotclient.ArduinoProperty, error)
+}
+
+// findProperty retrieves a property given either its id or its variable name.
+func findProperty(ctx context.Context, finder propertyFinder, thingID, id, name string) (*iotclient.ArduinoProperty, error) {
+	if (id == "") == (name == "") {
+		return nil, errors.New("provide either a variable ID or a variable name")
+	}
+	if id != "" {
+		return finder.PropertyShow(ctx, thingID, id)
+	}
+
+	properties, err := finder.PropertyList(ctx, thingID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range properties {
+		p := &properties[i]
+		if (p.VariableName != nil && *p.VariableName == name) || (p.VariableName == nil && p.Name == name) {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("thing %s doesn't have variable with name %s", thingID, name)
+}
+
+func getValueInfo(property *iotclient.ArduinoProperty) *ValueInfo {
+	info := &ValueInfo{
+		Name:      property.Name,
+		ID:        property.Id,
+		Type:      property.Type,
+		Value:     property.LastValue,
+		UpdatedAt: property.ValueUpdatedAt,
+	}
+	if property.VariableName != nil {
+		info.Name = *property.VariableName
+	}
+	return info
+}
@@ -0,0 +1,51 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package property
+
+import (
+	"context"
+
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/arduino/arduino-cloud-cli/internal/iot"
+)
+
+// ListParams contains the parameters needed
+// to list the variables of a thing.
+type ListParams struct {
+	ThingID string
+}
+
+// List command is used to list
+// the variables of a thing.
+func List(ctx context.Context, params *ListParams, cred *config.Credentials) ([]PropertyInfo, error) {
+	iotClient, err := iot.NewClient(cred)
+	if err != nil {
+		return nil, err
+	}
+
+	properties, err := iotClient.PropertyList(ctx, params.ThingID)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]PropertyInfo, 0, len(properties))
+	for i := range properties {
+		infos = append(infos, *getPropertyInfo(&properties[i]))
+	}
+	return infos, nil
+}
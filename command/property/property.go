@@ -0,0 +1,85 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package property
+
+import (
+	"fmt"
+
+	iotclient "github.com/arduino/iot-client-go/v3"
+)
+
+// Valid values of property permissions and update strategies.
+var (
+	Permissions      = []string{"READ_ONLY", "READ_WRITE"}
+	UpdateStrategies = []string{"ON_CHANGE", "TIMED"}
+)
+
+// PropertyInfo contains the main parameters of
+// a variable of an Arduino IoT Cloud thing.
+type PropertyInfo struct {
+	Name            string      `json:"name"`
+	VariableName    string      `json:"variable_name"`
+	ID              string      `json:"id"`
+	ThingID         string      `json:"thing_id"`
+	Type            string      `json:"type"`
+	Permission      string      `json:"permission"`
+	UpdateStrategy  string      `json:"update_strategy"`
+	UpdateParameter *float64    `json:"update_parameter,omitempty"`
+	Persist         bool        `json:"persist"`
+	Tag             *int64      `json:"tag,omitempty"`
+	LastValue       interface{} `json:"last_value,omitempty"`
+}
+
+func getPropertyInfo(property *iotclient.ArduinoProperty) *PropertyInfo {
+	info := &PropertyInfo{
+		Name:            property.Name,
+		ID:              property.Id,
+		ThingID:         property.ThingId,
+		Type:            property.Type,
+		Permission:      property.Permission,
+		UpdateStrategy:  property.UpdateStrategy,
+		UpdateParameter: property.UpdateParameter,
+		Persist:         property.Persist != nil && *property.Persist,
+		Tag:             property.Tag,
+		LastValue:       property.LastValue,
+	}
+	if property.VariableName != nil {
+		info.VariableName = *property.VariableName
+	}
+	return info
+}
+
+// validate checks the permission and the update strategy of a property.
+func validate(property *iotclient.Property) error {
+	if !contains(Permissions, property.Permission) {
+		return fmt.Errorf("variable %s: permission '%s' not valid, use one of %v", property.Name, property.Permission, Permissions)
+	}
+	if !contains(UpdateStrategies, property.UpdateStrategy) {
+		return fmt.Errorf("variable %s: update strategy '%s' not valid, use one of %v", property.Name, property.UpdateStrategy, UpdateStrategies)
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
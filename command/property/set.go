@@ -0,0 +1,67 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package property
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/arduino/arduino-cloud-cli/internal/iot"
+)
+
+// SetParams contains the parameters needed to
+// publish a new value of a variable of a thing.
+// The variable is identified either by ID or by variable name.
+type SetParams struct {
+	ThingID string
+	ID      string // Property ID
+	Name    string // Variable name
+	Value   string // Textual representation of the value, parsed according to the variable type
+}
+
+// Set command is used to publish a new value of a variable.
+// Only variables with READ_WRITE permission can be set.
+func Set(ctx context.Context, params *SetParams, cred *config.Credentials) (*ValueInfo, error) {
+	iotClient, err := iot.NewClient(cred)
+	if err != nil {
+		return nil, err
+	}
+
+	property, err := findProperty(ctx, iotClient, params.ThingID, params.ID, params.Name)
+	if err != nil {
+		return nil, err
+	}
+	if property.Permission != "READ_WRITE" {
+		return nil, fmt.Errorf("variable %s is %s: only READ_WRITE variables can be set", property.Name, property.Permission)
+	}
+
+	value, err := ParseValue(property.Type, params.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = iotClient.PropertyPublish(ctx, params.ThingID, property.Id, value); err != nil {
+		return nil, err
+	}
+
+	info := getValueInfo(property)
+	info.Value = value
+	info.UpdatedAt = nil
+	return info, nil
+}
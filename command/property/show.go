@@ -0,0 +1,47 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package property
+
+import (
+	"context"
+
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/arduino/arduino-cloud-cli/internal/iot"
+)
+
+// ShowParams contains the parameters needed
+// to show a variable of a thing.
+type ShowParams struct {
+	ThingID string
+	ID      string // Property ID
+}
+
+// Show command is used to show
+// a variable of a thing.
+func Show(ctx context.Context, params *ShowParams, cred *config.Credentials) (*PropertyInfo, error) {
+	iotClient, err := iot.NewClient(cred)
+	if err != nil {
+		return nil, err
+	}
+
+	property, err := iotClient.PropertyShow(ctx, params.ThingID, params.ID)
+	if err != nil {
+		return nil, err
+	}
+	return getPropertyInfo(property), nil
+}
@@ -0,0 +1,101 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package property
+
+import (
+	"context"
+
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/arduino/arduino-cloud-cli/internal/iot"
+	iotclient "github.com/arduino/iot-client-go/v3"
+)
+
+// UpdateParams contains the parameters needed
+// to update a variable of a thing.
+// Nil fields are left unchanged.
+type UpdateParams struct {
+	ThingID         string
+	ID              string // Property ID
+	Name            *string
+	VariableName    *string
+	Type            *string
+	Permission      *string
+	UpdateStrategy  *string
+	UpdateParameter *float64
+	Persist         *bool
+	Tag             *int64
+}
+
+// Update command is used to update a variable of a thing.
+func Update(ctx context.Context, params *UpdateParams, cred *config.Credentials) (*PropertyInfo, error) {
+	iotClient, err := iot.NewClient(cred)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := iotClient.PropertyShow(ctx, params.ThingID, params.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	property := &iotclient.Property{
+		Name:            current.Name,
+		VariableName:    current.VariableName,
+		Type:            current.Type,
+		Permission:      current.Permission,
+		UpdateStrategy:  current.UpdateStrategy,
+		UpdateParameter: current.UpdateParameter,
+		Persist:         current.Persist,
+		MinValue:        current.MinValue,
+		MaxValue:        current.MaxValue,
+		Tag:             current.Tag,
+	}
+	if params.Name != nil {
+		property.Name = *params.Name
+	}
+	if params.VariableName != nil {
+		property.VariableName = params.VariableName
+	}
+	if params.Type != nil {
+		property.Type = *params.Type
+	}
+	if params.Permission != nil {
+		property.Permission = *params.Permission
+	}
+	if params.UpdateStrategy != nil {
+		property.UpdateStrategy = *params.UpdateStrategy
+	}
+	if params.UpdateParameter != nil {
+		property.UpdateParameter = params.UpdateParameter
+	}
+	if params.Persist != nil {
+		property.Persist = params.Persist
+	}
+	if params.Tag != nil {
+		property.Tag = params.Tag
+	}
+	if err := validate(property); err != nil {
+		return nil, err
+	}
+
+	updated, err := iotClient.PropertyUpdate(ctx, params.ThingID, params.ID, property)
+	if err != nil {
+		return nil, err
+	}
+	return getPropertyInfo(updated), nil
+}
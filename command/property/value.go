@@ -0,0 +1,135 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package property
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type valueKind int
+
+const (
+	kindFloat valueKind = iota
+	kindBool
+	kindInt
+	kindString
+	kindLocation
+	kindColor
+	kindColoredLight
+	kindDimmedLight
+	kindObject
+)
+
+// valueKinds maps the property types to the kind of their values.
+// Types not listed here, like the measurement ones
+// (e.g. TEMPERATURE_C, HUMIDITY), hold floating point values.
+var valueKinds = map[string]valueKind{
+	"FLOAT":               kindFloat,
+	"STATUS":              kindBool,
+	"HOME_SWITCH":         kindBool,
+	"HOME_SMART_PLUG":     kindBool,
+	"HOME_CONTACT_SENSOR": kindBool,
+	"HOME_MOTION_SENSOR":  kindBool,
+	"INT":                 kindInt,
+	"TIME":                kindInt,
+	"CHARSTRING":          kindString,
+	"LOCATION":            kindLocation,
+	"COLOR":               kindColor,
+	"HOME_COLORED_LIGHT":  kindColoredLight,
+	"HOME_DIMMED_LIGHT":   kindDimmedLight,
+	"SCHEDULE":            kindObject,
+	"HOME_TELEVISION":     kindObject,
+}
+
+// ParseValue converts the textual representation of a value
+// into the value of a property of the passed type.
+// Structured values are passed as json objects; locations and colors
+// can also be passed as comma-separated lists: "<lat>,<lon>" and "<hue>,<sat>,<bri>".
+func ParseValue(propertyType, raw string) (interface{}, error) {
+	switch valueKinds[strings.ToUpper(propertyType)] {
+	case kindBool:
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("value '%s' is not a valid %s: true or false expected", raw, propertyType)
+		}
+		return v, nil
+	case kindInt:
+		v, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("value '%s' is not a valid %s: integer expected", raw, propertyType)
+		}
+		return v, nil
+	case kindString:
+		return raw, nil
+	case kindLocation:
+		return parseObject(propertyType, raw, []string{"lat", "lon"}, nil)
+	case kindColor:
+		return parseObject(propertyType, raw, []string{"hue", "sat", "bri"}, nil)
+	case kindColoredLight:
+		return parseObject(propertyType, raw, []string{"hue", "sat", "bri"}, []string{"swi"})
+	case kindDimmedLight:
+		return parseObject(propertyType, raw, []string{"bri"}, []string{"swi"})
+	case kindObject:
+		return parseObject(propertyType, raw, nil, nil)
+	default:
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("value '%s' is not a valid %s: number expected", raw, propertyType)
+		}
+		return v, nil
+	}
+}
+
+// parseObject parses a structured value, checking that it contains
+// the passed numeric and boolean fields.
+// If the value only has numeric fields, it can be passed as a comma-separated list.
+func parseObject(propertyType, raw string, numbers, bools []string) (map[string]interface{}, error) {
+	obj := make(map[string]interface{})
+	if !strings.HasPrefix(strings.TrimSpace(raw), "{") && len(bools) == 0 && len(numbers) > 0 {
+		parts := strings.Split(raw, ",")
+		if len(parts) != len(numbers) {
+			return nil, fmt.Errorf("value '%s' is not a valid %s: '%s' expected", raw, propertyType, strings.Join(numbers, ","))
+		}
+		for i, p := range parts {
+			v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+			if err != nil {
+				return nil, fmt.Errorf("value '%s' is not a valid %s: %s must be a number", raw, propertyType, numbers[i])
+			}
+			obj[numbers[i]] = v
+		}
+		return obj, nil
+	}
+
+	if err := json.Unmarshal([]byte(raw), &obj); err != nil {
+		return nil, fmt.Errorf("value '%s' is not a valid %s: json object expected", raw, propertyType)
+	}
+	for _, field := range numbers {
+		if _, ok := obj[field].(float64); !ok {
+			return nil, fmt.Errorf("value '%s' is not a valid %s: numeric field '%s' expected", raw, propertyType, field)
+		}
+	}
+	for _, field := range bools {
+		if _, ok := obj[field].(bool); !ok {
+			return nil, fmt.Errorf("value '%s' is not a valid %s: boolean field '%s' expected", raw, propertyType, field)
+		}
+	}
+	return obj, nil
+}
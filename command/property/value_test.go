@@ -0,0 +1,76 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package property
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		typ     string
+		raw     string
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "bool", typ: "STATUS", raw: "true", want: true},
+		{name: "bool-invalid", typ: "HOME_SWITCH", raw: "maybe", wantErr: true},
+		{name: "int", typ: "INT", raw: "-42", want: int64(-42)},
+		{name: "int-invalid", typ: "INT", raw: "4.2", wantErr: true},
+		{name: "float", typ: "FLOAT", raw: "4.2", want: 4.2},
+		{name: "measurement", typ: "TEMPERATURE_C", raw: "21.5", want: 21.5},
+		{name: "measurement-invalid", typ: "HUMIDITY", raw: "wet", wantErr: true},
+		{name: "string", typ: "CHARSTRING", raw: "hello, world", want: "hello, world"},
+		{name: "location-list", typ: "LOCATION", raw: "45.07, 7.68", want: map[string]interface{}{"lat": 45.07, "lon": 7.68}},
+		{name: "location-json", typ: "LOCATION", raw: `{"lat": 45.07, "lon": 7.68}`, want: map[string]interface{}{"lat": 45.07, "lon": 7.68}},
+		{name: "location-missing-field", typ: "LOCATION", raw: `{"lat": 45.07}`, wantErr: true},
+		{name: "location-wrong-length", typ: "LOCATION", raw: "45.07", wantErr: true},
+		{name: "color-list", typ: "COLOR", raw: "120,50,100", want: map[string]interface{}{"hue": 120.0, "sat": 50.0, "bri": 100.0}},
+		{
+			name: "colored-light",
+			typ:  "HOME_COLORED_LIGHT",
+			raw:  `{"swi": true, "hue": 120, "sat": 50, "bri": 100}`,
+			want: map[string]interface{}{"swi": true, "hue": 120.0, "sat": 50.0, "bri": 100.0},
+		},
+		{name: "colored-light-list", typ: "HOME_COLORED_LIGHT", raw: "120,50,100", wantErr: true},
+		{name: "dimmed-light-wrong-switch", typ: "HOME_DIMMED_LIGHT", raw: `{"swi": 1, "bri": 10}`, wantErr: true},
+		{name: "schedule", typ: "SCHEDULE", raw: `{"frm": 1, "to": 2}`, want: map[string]interface{}{"frm": 1.0, "to": 2.0}},
+		{name: "schedule-invalid", typ: "SCHEDULE", raw: "tomorrow", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseValue(tt.typ, tt.raw)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error, got value %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("Wrong value, diff:\n%s", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
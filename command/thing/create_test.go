@@ -0,0 +1,48 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package thing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/arduino/arduino-cloud-cli/internal/httpclient"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCreate(t *testing.T) {
+	t.Setenv(httpclient.CassetteEnv, "testdata/create.cassette.json")
+	t.Setenv(httpclient.CassetteModeEnv, httpclient.CassetteReplay)
+	cred := &config.Credentials{Client: "test-client", Secret: "test-secret"}
+
+	got, err := Create(context.Background(), &CreateParams{Template: "testdata/thing.yaml"}, cred)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &ThingInfo{
+		Name:      "Greenhouse",
+		ID:        "8b10ee4e-1a1a-4b6e-a5a9-5f6b0a3e4c01",
+		Variables: []string{"temperature"},
+		Tags:      []string{"room=greenhouse"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected thing created:\n%s", diff)
+	}
+}
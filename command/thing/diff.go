@@ -0,0 +1,59 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package thing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/arduino/arduino-cloud-cli/internal/iot"
+	"github.com/arduino/arduino-cloud-cli/internal/template"
+)
+
+// DiffParams contains the parameters needed to
+// compare a thing with a thing template.
+type DiffParams struct {
+	ID       string // Thing ID
+	Template string // Path of the template file
+}
+
+// Diff compares a thing on Arduino IoT Cloud with a thing template.
+// An empty list is returned if the thing matches the template.
+func Diff(ctx context.Context, params *DiffParams, cred *config.Credentials) ([]template.Change, error) {
+	iotClient, err := iot.NewClient(cred)
+	if err != nil {
+		return nil, err
+	}
+
+	want, err := template.LoadThing(params.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	got, err := iotClient.ThingShow(ctx, params.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", "cannot retrieve thing", err)
+	}
+
+	diff, err := template.DiffThing(want, got)
+	if err != nil {
+		return nil, err
+	}
+	return diff.Changes, nil
+}
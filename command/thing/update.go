@@ -0,0 +1,134 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package thing
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/arduino/arduino-cloud-cli/internal/iot"
+	"github.com/arduino/arduino-cloud-cli/internal/template"
+	iotclient "github.com/arduino/iot-client-go/v3"
+)
+
+// UpdateParams contains the parameters needed to
+// update a thing from a template.
+type UpdateParams struct {
+	ID       string // ID of the thing to be updated
+	Template string // Path of the template file
+	Prune    bool   // Delete the variables and tags missing from the template
+}
+
+type thingUpdater interface {
+	ThingShow(ctx context.Context, id string) (*iotclient.ArduinoThing, error)
+	ThingUpdate(ctx context.Context, id string, thing *iotclient.ThingUpdate, force bool) error
+	ThingTagsCreate(ctx context.Context, id string, tags map[string]string) error
+	ThingTagsDelete(ctx context.Context, id string, keys []string) error
+	PropertyCreate(ctx context.Context, thingId string, property *iotclient.Property) (*iotclient.ArduinoProperty, error)
+	PropertyUpdate(ctx context.Context, thingId, propertyId string, property *iotclient.Property) (*iotclient.ArduinoProperty, error)
+	PropertyDelete(ctx context.Context, thingId, propertyId string) error
+}
+
+// Update aligns a thing to a template, in place.
+// Variables are matched by variable name, so that the ids of
+// existing variables are preserved and the dashboards using them keep working.
+// It returns the applied changes.
+func Update(ctx context.Context, params *UpdateParams, cred *config.Credentials) ([]template.Change, error) {
+	iotClient, err := iot.NewClient(cred)
+	if err != nil {
+		return nil, err
+	}
+
+	want, err := template.LoadThing(params.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	return update(ctx, iotClient, params, want)
+}
+
+func update(ctx context.Context, client thingUpdater, params *UpdateParams, want *iotclient.ThingCreate) ([]template.Change, error) {
+	got, err := client.ThingShow(ctx, params.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", "cannot retrieve thing", err)
+	}
+
+	diff, err := template.DiffThing(want, got)
+	if err != nil {
+		return nil, err
+	}
+
+	if diff.Name != nil || diff.Timezone != nil {
+		thing := &iotclient.ThingUpdate{
+			Name:     diff.Name,
+			Timezone: diff.Timezone,
+		}
+		if err = client.ThingUpdate(ctx, params.ID, thing, true); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range diff.CreateVariables {
+		if _, err = client.PropertyCreate(ctx, params.ID, &diff.CreateVariables[i]); err != nil {
+			return nil, fmt.Errorf("cannot create variable %s: %w", diff.CreateVariables[i].Name, err)
+		}
+	}
+
+	ids := make([]string, 0, len(diff.UpdateVariables))
+	for id := range diff.UpdateVariables {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		prop := diff.UpdateVariables[id]
+		if _, err = client.PropertyUpdate(ctx, params.ID, id, &prop); err != nil {
+			return nil, fmt.Errorf("cannot update variable %s: %w", prop.Name, err)
+		}
+	}
+
+	if len(diff.SetTags) > 0 {
+		if err = client.ThingTagsCreate(ctx, params.ID, diff.SetTags); err != nil {
+			return nil, err
+		}
+	}
+
+	if params.Prune {
+		for _, id := range diff.DeleteVariables {
+			if err = client.PropertyDelete(ctx, params.ID, id); err != nil {
+				return nil, fmt.Errorf("cannot delete variable %s: %w", id, err)
+			}
+		}
+		if len(diff.DeleteTags) > 0 {
+			if err = client.ThingTagsDelete(ctx, params.ID, diff.DeleteTags); err != nil {
+				return nil, err
+			}
+		}
+		return diff.Changes, nil
+	}
+
+	// Without prune, removals are not applied
+	changes := make([]template.Change, 0, len(diff.Changes))
+	for _, c := range diff.Changes {
+		if c.Op != template.OpRemove {
+			changes = append(changes, c)
+		}
+	}
+	return changes, nil
+}
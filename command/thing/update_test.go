@@ -0,0 +1,136 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package thing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/arduino/arduino-cloud-cli/internal/template"
+	iotclient "github.com/arduino/iot-client-go/v3"
+	"github.com/google/go-cmp/cmp"
+)
+
+type thingUpdaterTest struct {
+	thing *iotclient.ArduinoThing
+	calls []string
+}
+
+func (c *thingUpdaterTest) ThingShow(ctx context.Context, id string) (*iotclient.ArduinoThing, error) {
+	return c.thing, nil
+}
+
+func (c *thingUpdaterTest) ThingUpdate(ctx context.Context, id string, thing *iotclient.ThingUpdate, force bool) error {
+	c.calls = append(c.calls, "ThingUpdate "+dereferenceString(thing.Name))
+	return nil
+}
+
+func (c *thingUpdaterTest) ThingTagsCreate(ctx context.Context, id string, tags map[string]string) error {
+	for k, v := range tags {
+		c.calls = append(c.calls, "ThingTagsCreate "+k+"="+v)
+	}
+	return nil
+}
+
+func (c *thingUpdaterTest) ThingTagsDelete(ctx context.Context, id string, keys []string) error {
+	for _, k := range keys {
+		c.calls = append(c.calls, "ThingTagsDelete "+k)
+	}
+	return nil
+}
+
+func (c *thingUpdaterTest) PropertyCreate(ctx context.Context, thingId string, property *iotclient.Property) (*iotclient.ArduinoProperty, error) {
+	c.calls = append(c.calls, "PropertyCreate "+property.Name)
+	return &iotclient.ArduinoProperty{}, nil
+}
+
+func (c *thingUpdaterTest) PropertyUpdate(ctx context.Context, thingId, propertyId string, property *iotclient.Property) (*iotclient.ArduinoProperty, error) {
+	c.calls = append(c.calls, "PropertyUpdate "+propertyId)
+	return &iotclient.ArduinoProperty{}, nil
+}
+
+func (c *thingUpdaterTest) PropertyDelete(ctx context.Context, thingId, propertyId string) error {
+	c.calls = append(c.calls, "PropertyDelete "+propertyId)
+	return nil
+}
+
+func TestUpdate(t *testing.T) {
+	want, err := template.LoadThing("testdata/thing.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	variableName := func(s string) *string { return &s }
+
+	tests := []struct {
+		name        string
+		prune       bool
+		wantCalls   []string
+		wantChanges int
+	}{
+		{
+			name: "keep",
+			wantCalls: []string{
+				"ThingUpdate Greenhouse",
+				"PropertyUpdate temperature-id",
+				"ThingTagsCreate room=greenhouse",
+			},
+			wantChanges: 3,
+		},
+		{
+			name:  "prune",
+			prune: true,
+			wantCalls: []string{
+				"ThingUpdate Greenhouse",
+				"PropertyUpdate temperature-id",
+				"ThingTagsCreate room=greenhouse",
+				"PropertyDelete humidity-id",
+				"ThingTagsDelete owner",
+			},
+			wantChanges: 5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &thingUpdaterTest{
+				thing: &iotclient.ArduinoThing{
+					Id:   "thing-id",
+					Name: "Old greenhouse",
+					Properties: []iotclient.ArduinoProperty{
+						{Id: "temperature-id", Name: "temperature", VariableName: variableName("temperature"),
+							Type: "TEMPERATURE_C", Permission: "READ_WRITE", UpdateStrategy: "ON_CHANGE"},
+						{Id: "humidity-id", Name: "humidity", VariableName: variableName("humidity"),
+							Type: "HUMIDITY", Permission: "READ_ONLY", UpdateStrategy: "ON_CHANGE"},
+					},
+					Tags: map[string]interface{}{"room": "garden", "owner": "me"},
+				},
+			}
+
+			changes, err := update(context.TODO(), client, &UpdateParams{ID: "thing-id", Prune: tt.prune}, want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(client.calls, tt.wantCalls) {
+				t.Errorf("Wrong calls, diff:\n%s", cmp.Diff(tt.wantCalls, client.calls))
+			}
+			if len(changes) != tt.wantChanges {
+				t.Errorf("Expected %d changes, got %v", tt.wantChanges, changes)
+			}
+		})
+	}
+}
@@ -0,0 +1,362 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package thing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/arduino/arduino-cloud-cli/internal/iot"
+	"github.com/arduino/arduino-cloud-cli/internal/realtime"
+	iotclient "github.com/arduino/iot-client-go/v3"
+	"github.com/sirupsen/logrus"
+)
+
+// maxWatchBackoff is the maximum time waited before reconnecting after a failure.
+const maxWatchBackoff = time.Minute
+
+// minWatchBackoff is the time waited before reconnecting after the first failure.
+var minWatchBackoff = time.Second
+
+// WatchParams contains the parameters needed to watch
+// the variables of one or more things.
+type WatchParams struct {
+	ID        string            // ID of the thing to watch, mutually exclusive with Tags
+	Tags      map[string]string // Tags of the things to watch
+	Variables []string          // Variable names to watch; if empty, all the variables are watched
+}
+
+// ValueUpdate is a new value of a thing variable.
+type ValueUpdate struct {
+	Time       time.Time   `json:"time"`
+	ThingID    string      `json:"thing_id"`
+	ThingName  string      `json:"thing_name"`
+	PropertyID string      `json:"property_id"`
+	Variable   string      `json:"variable"`
+	Type       string      `json:"type"`
+	Value      interface{} `json:"value"`
+}
+
+type thingWatcher interface {
+	ThingList(ctx context.Context, ids []string, device *string, props bool, tags map[string]string) ([]iotclient.ArduinoThing, error)
+}
+
+// subscription receives the messages published on the realtime channel.
+type subscription interface {
+	Subscribe(ctx context.Context, topics ...string) error
+	ReadMessage() (realtime.Message, error)
+	Close() error
+}
+
+// errWatchNotFound is returned when there are no things to watch.
+var errWatchNotFound = errors.New("no thing found")
+
+// Watch streams the updates of the variables of the things, calling
+// onUpdate for each of them, until the context is cancelled.
+// The last values of the variables are streamed first, then updates
+// are received from the realtime channel of Arduino Cloud as they are published.
+// When the connection is lost, the watch reconnects with a growing delay and
+// streams the values changed in the meantime.
+func Watch(ctx context.Context, params *WatchParams, cred *config.Credentials, onUpdate func(*ValueUpdate)) error {
+	if (params.ID == "") == (len(params.Tags) == 0) {
+		return errors.New("provide either a thing ID or tags")
+	}
+	iotClient, err := iot.NewClient(cred)
+	if err != nil {
+		return err
+	}
+	connect := func(ctx context.Context) (subscription, error) {
+		conn, err := iotClient.RealtimeConnect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return conn, nil
+	}
+	return watch(ctx, iotClient, connect, params, onUpdate)
+}
+
+// lastValue is the last value streamed for a variable.
+type lastValue struct {
+	time  time.Time
+	value interface{}
+}
+
+// propertyInfo describes a variable of a watched thing.
+type propertyInfo struct {
+	id  string
+	typ string
+}
+
+type watcher struct {
+	client    thingWatcher
+	params    *WatchParams
+	variables map[string]bool
+	onUpdate  func(*ValueUpdate)
+
+	things map[string]*iotclient.ArduinoThing // Watched things, keyed by id
+	props  map[string]map[string]propertyInfo // Variables of the watched things, keyed by thing id and variable name
+	last   map[string]lastValue               // Last value of each variable, keyed by property id
+}
+
+func watch(ctx context.Context, client thingWatcher, connect func(context.Context) (subscription, error), params *WatchParams, onUpdate func(*ValueUpdate)) error {
+	w := &watcher{
+		client:    client,
+		params:    params,
+		variables: make(map[string]bool, len(params.Variables)),
+		onUpdate:  onUpdate,
+		last:      make(map[string]lastValue),
+	}
+	for _, v := range params.Variables {
+		w.variables[v] = true
+	}
+
+	failures := 0
+	for {
+		connected, err := w.session(ctx, connect)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if errors.Is(err, errWatchNotFound) {
+			return err
+		}
+		if connected {
+			logrus.Info("Realtime connection lost")
+			failures = 0
+		}
+		failures++
+		wait := backoff(minWatchBackoff, failures)
+		logrus.Warnf("Watching variables failed, retrying in %s: %v", wait, err)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(wait):
+		}
+	}
+}
+
+// session connects to the realtime channel and streams the updates
+// of the variables until the connection is lost.
+// connected reports whether the subscription succeeded.
+func (w *watcher) session(ctx context.Context, connect func(context.Context) (subscription, error)) (connected bool, err error) {
+	// Things are listed before subscribing, to know the topics,
+	// and after it, to stream the values that changed in the meantime
+	things, err := w.list(ctx)
+	if err != nil {
+		return false, err
+	}
+	conn, err := connect(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	// Closing the connection unblocks the reads on cancellation
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	topics := make([]string, 0, len(things))
+	for _, t := range things {
+		topics = append(topics, thingTopic(t.Id))
+	}
+	if err := conn.Subscribe(ctx, topics...); err != nil {
+		return false, err
+	}
+	logrus.Infof("Subscribed to the updates of %d things", len(topics))
+
+	things, err = w.list(ctx)
+	if err != nil {
+		return true, err
+	}
+	for _, u := range newUpdates(things, w.variables, w.last) {
+		w.onUpdate(u)
+	}
+
+	for {
+		msg, err := conn.ReadMessage()
+		if err != nil {
+			return true, err
+		}
+		for _, u := range w.decode(msg) {
+			w.onUpdate(u)
+		}
+	}
+}
+
+// list retrieves the watched things, with their variables.
+func (w *watcher) list(ctx context.Context) ([]iotclient.ArduinoThing, error) {
+	var ids []string
+	if w.params.ID != "" {
+		ids = []string{w.params.ID}
+	}
+	things, err := w.client.ThingList(ctx, ids, nil, true, w.params.Tags)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving variables: %w", err)
+	}
+	if len(things) == 0 {
+		return nil, errWatchNotFound
+	}
+
+	w.things = make(map[string]*iotclient.ArduinoThing, len(things))
+	w.props = make(map[string]map[string]propertyInfo, len(things))
+	for i, t := range things {
+		w.things[t.Id] = &things[i]
+		props := make(map[string]propertyInfo, len(t.Properties))
+		for _, p := range t.Properties {
+			props[variableName(p)] = propertyInfo{id: p.Id, typ: p.Type}
+		}
+		w.props[t.Id] = props
+	}
+	return things, nil
+}
+
+// decode returns the updates contained in a message of the realtime channel.
+// Values already streamed are skipped.
+func (w *watcher) decode(msg realtime.Message) []*ValueUpdate {
+	thingID, ok := strings.CutPrefix(msg.Topic, "/a/t/")
+	thingID, ok2 := strings.CutSuffix(thingID, "/e/o")
+	thing := w.things[thingID]
+	if !ok || !ok2 || thing == nil {
+		logrus.Debugf("Ignoring message on topic %s", msg.Topic)
+		return nil
+	}
+	records, err := realtime.DecodeSenML(msg.Payload)
+	if err != nil {
+		logrus.Warnf("Ignoring message of thing %s: %v", thing.Name, err)
+		return nil
+	}
+
+	// Variables with multiple values, like locations and colors,
+	// are published as a record for each field named <variable>:<field>
+	var updates []*ValueUpdate
+	fields := make(map[*ValueUpdate]map[string]interface{})
+	byVariable := make(map[string]*ValueUpdate)
+	for _, r := range records {
+		variable, field, multi := strings.Cut(r.Name, ":")
+		if len(w.variables) > 0 && !w.variables[variable] {
+			continue
+		}
+		u := byVariable[variable]
+		if u == nil {
+			at := r.Time
+			if at.IsZero() {
+				at = time.Now().UTC()
+			}
+			info := w.props[thingID][variable]
+			u = &ValueUpdate{
+				Time:       at,
+				ThingID:    thingID,
+				ThingName:  thing.Name,
+				PropertyID: info.id,
+				Variable:   variable,
+				Type:       info.typ,
+				Value:      r.Value,
+			}
+			byVariable[variable] = u
+			updates = append(updates, u)
+		}
+		if multi {
+			if fields[u] == nil {
+				fields[u] = make(map[string]interface{})
+				u.Value = fields[u]
+			}
+			fields[u][field] = r.Value
+		} else {
+			u.Value = r.Value
+		}
+	}
+
+	n := 0
+	for _, u := range updates {
+		key := u.PropertyID
+		if key == "" {
+			key = thingID + "/" + u.Variable
+		}
+		// The same value can be received from the realtime
+		// channel and from the list done after subscribing
+		last, seen := w.last[key]
+		if seen && u.Time.Equal(last.time) && reflect.DeepEqual(u.Value, last.value) {
+			continue
+		}
+		if !seen || !u.Time.Before(last.time) {
+			w.last[key] = lastValue{time: u.Time, value: u.Value}
+		}
+		updates[n] = u
+		n++
+	}
+	return updates[:n]
+}
+
+func thingTopic(thingID string) string {
+	return "/a/t/" + thingID + "/e/o"
+}
+
+func variableName(p iotclient.ArduinoProperty) string {
+	if p.VariableName != nil {
+		return *p.VariableName
+	}
+	return p.Name
+}
+
+// backoff returns the time to wait after the passed number of consecutive failures.
+func backoff(interval time.Duration, failures int) time.Duration {
+	wait := interval
+	for i := 1; i < failures && wait < maxWatchBackoff; i++ {
+		wait *= 2
+	}
+	if wait > maxWatchBackoff {
+		wait = maxWatchBackoff
+	}
+	return wait
+}
+
+// newUpdates returns the values of the properties that changed
+// since they were last seen, sorted by time.
+func newUpdates(things []iotclient.ArduinoThing, variables map[string]bool, last map[string]lastValue) []*ValueUpdate {
+	var updates []*ValueUpdate
+	for _, t := range things {
+		for _, p := range t.Properties {
+			variable := variableName(p)
+			if len(variables) > 0 && !variables[variable] {
+				continue
+			}
+			if p.ValueUpdatedAt == nil {
+				continue
+			}
+			if l, ok := last[p.Id]; ok && !p.ValueUpdatedAt.After(l.time) {
+				continue
+			}
+			last[p.Id] = lastValue{time: *p.ValueUpdatedAt, value: p.LastValue}
+			updates = append(updates, &ValueUpdate{
+				Time:       *p.ValueUpdatedAt,
+				ThingID:    t.Id,
+				ThingName:  t.Name,
+				PropertyID: p.Id,
+				Variable:   variable,
+				Type:       p.Type,
+				Value:      p.LastValue,
+			})
+		}
+	}
+	sort.SliceStable(updates, func(i, j int) bool { return updates[i].Time.Before(updates[j].Time) })
+	return updates
+}
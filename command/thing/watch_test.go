@@ -0,0 +1,258 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package thing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/arduino/arduino-cloud-cli/internal/realtime"
+	iotclient "github.com/arduino/iot-client-go/v3"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/google/go-cmp/cmp"
+)
+
+// thingWatcherTest returns the passed thing lists in order,
+// and opens the passed realtime sessions in order.
+// The watch is cancelled once the lists or the sessions are over.
+type thingWatcherTest struct {
+	lists     [][]iotclient.ArduinoThing // A nil list is a failure
+	sessions  []*testSession             // A nil session is a connection failure
+	cancel    context.CancelFunc
+	subscribe [][]string // Topics subscribed by each session
+}
+
+func (c *thingWatcherTest) ThingList(ctx context.Context, ids []string, device *string, props bool, tags map[string]string) ([]iotclient.ArduinoThing, error) {
+	if len(c.lists) == 0 {
+		c.cancel()
+		return nil, ctx.Err()
+	}
+	res := c.lists[0]
+	c.lists = c.lists[1:]
+	if res == nil {
+		return nil, errors.New("connection reset")
+	}
+	return res, nil
+}
+
+func (c *thingWatcherTest) connect(ctx context.Context) (subscription, error) {
+	if len(c.sessions) == 0 {
+		c.cancel()
+		return nil, ctx.Err()
+	}
+	s := c.sessions[0]
+	c.sessions = c.sessions[1:]
+	if s == nil {
+		return nil, errors.New("broker unreachable")
+	}
+	s.client = c
+	return s, nil
+}
+
+// testSession delivers the passed messages, then the connection is lost.
+type testSession struct {
+	client   *thingWatcherTest
+	messages []realtime.Message
+}
+
+func (s *testSession) Subscribe(ctx context.Context, topics ...string) error {
+	s.client.subscribe = append(s.client.subscribe, topics)
+	return nil
+}
+
+func (s *testSession) ReadMessage() (realtime.Message, error) {
+	if len(s.messages) == 0 {
+		return realtime.Message{}, io.EOF
+	}
+	msg := s.messages[0]
+	s.messages = s.messages[1:]
+	return msg, nil
+}
+
+func (s *testSession) Close() error { return nil }
+
+var watchStart = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func at(sec int) *time.Time {
+	t := watchStart.Add(time.Duration(sec) * time.Second)
+	return &t
+}
+
+// senml returns a message published by a thing, with a record for each
+// passed name and value, at the passed second.
+func senml(t *testing.T, thingID string, sec int, namesAndValues ...interface{}) realtime.Message {
+	var pack []map[int]interface{}
+	for i := 0; i < len(namesAndValues); i += 2 {
+		pack = append(pack, map[int]interface{}{0: namesAndValues[i], 2: namesAndValues[i+1], 6: at(sec).Unix()})
+	}
+	payload, err := cbor.Marshal(pack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return realtime.Message{Topic: "/a/t/" + thingID + "/e/o", Payload: payload}
+}
+
+func TestWatch(t *testing.T) {
+	name := func(s string) *string { return &s }
+	thing := func(temp, hum interface{}, tempAt, humAt *time.Time) []iotclient.ArduinoThing {
+		return []iotclient.ArduinoThing{{
+			Id:   "thing-1",
+			Name: "station",
+			Properties: []iotclient.ArduinoProperty{
+				{Id: "p1", Name: "temperature", VariableName: name("temperature"), Type: "FLOAT", LastValue: temp, ValueUpdatedAt: tempAt},
+				{Id: "p2", Name: "humidity", VariableName: name("humidity"), Type: "FLOAT", LastValue: hum, ValueUpdatedAt: humAt},
+				{Id: "p3", Name: "position", VariableName: name("position"), Type: "LOCATION"},
+			},
+		}}
+	}
+
+	tests := []struct {
+		name      string
+		variables []string
+		lists     [][]iotclient.ArduinoThing
+		sessions  []*testSession
+		want      []string
+	}{
+		{
+			name: "initial-values-and-updates",
+			lists: [][]iotclient.ArduinoThing{
+				thing(20.5, 40.0, at(2), at(1)),
+				thing(20.5, 40.0, at(2), at(1)),
+			},
+			sessions: []*testSession{{messages: []realtime.Message{
+				// Published before the second list: already streamed
+				senml(t, "thing-1", 2, "temperature", 20.5),
+				senml(t, "thing-1", 3, "temperature", 21.0, "humidity", uint64(41)),
+				// Same time, but different value
+				senml(t, "thing-1", 3, "temperature", 21.5),
+				senml(t, "other-thing", 3, "temperature", 30.0),
+			}}},
+			want: []string{"humidity=40@1", "temperature=20.5@2", "temperature=21@3", "humidity=41@3", "temperature=21.5@3"},
+		},
+		{
+			name: "reconnect-after-failures",
+			lists: [][]iotclient.ArduinoThing{
+				thing(20.5, 40.0, at(2), at(1)),
+				thing(20.5, 40.0, at(2), at(1)),
+				nil,
+				thing(21.0, 40.0, at(5), at(1)),
+				thing(21.0, 40.0, at(5), at(1)),
+				thing(22.0, 40.0, at(8), at(1)),
+			},
+			sessions: []*testSession{
+				{messages: []realtime.Message{senml(t, "thing-1", 5, "temperature", 21.0)}},
+				nil,
+				// Values changed while disconnected are streamed after reconnecting
+				{messages: []realtime.Message{senml(t, "thing-1", 9, "temperature", 23.0)}},
+			},
+			want: []string{"humidity=40@1", "temperature=20.5@2", "temperature=21@5", "temperature=22@8", "temperature=23@9"},
+		},
+		{
+			name:      "filter-variables",
+			variables: []string{"humidity"},
+			lists: [][]iotclient.ArduinoThing{
+				thing(20.5, 40.0, at(2), at(1)),
+				thing(20.5, 40.0, at(2), at(1)),
+			},
+			sessions: []*testSession{{messages: []realtime.Message{
+				senml(t, "thing-1", 5, "temperature", 21.0, "humidity", 41.0),
+			}}},
+			want: []string{"humidity=40@1", "humidity=41@5"},
+		},
+		{
+			name: "multiple-values",
+			lists: [][]iotclient.ArduinoThing{
+				thing(nil, nil, nil, nil),
+				thing(nil, nil, nil, nil),
+			},
+			sessions: []*testSession{{messages: []realtime.Message{
+				senml(t, "thing-1", 5, "position:lat", 45.0, "position:lon", 7.5),
+			}}},
+			want: []string{"position=map[lat:45 lon:7.5]@5"},
+		},
+	}
+
+	defer func(d time.Duration) { minWatchBackoff = d }(minWatchBackoff)
+	minWatchBackoff = time.Millisecond
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			client := &thingWatcherTest{lists: tt.lists, sessions: tt.sessions, cancel: cancel}
+			params := &WatchParams{ID: "thing-1", Variables: tt.variables}
+
+			var got []string
+			err := watch(ctx, client, client.connect, params, func(u *ValueUpdate) {
+				got = append(got, fmt.Sprintf("%s=%s@%d", u.Variable, formatTestValue(u.Value), u.Time.Unix()-watchStart.Unix()))
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected updates (-want +got):\n%s", diff)
+			}
+			if len(client.lists) > 0 {
+				t.Errorf("%d thing lists not performed", len(client.lists))
+			}
+			for _, topics := range client.subscribe {
+				if diff := cmp.Diff([]string{"/a/t/thing-1/e/o"}, topics); diff != "" {
+					t.Errorf("unexpected topics (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestWatchNotFound(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := &thingWatcherTest{lists: [][]iotclient.ArduinoThing{{}}, cancel: cancel}
+	err := watch(ctx, client, client.connect, &WatchParams{ID: "thing-1"}, func(u *ValueUpdate) {})
+	if !errors.Is(err, errWatchNotFound) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+func formatTestValue(v interface{}) string {
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{1, 2 * time.Second},
+		{2, 4 * time.Second},
+		{3, 8 * time.Second},
+		{10, maxWatchBackoff},
+	}
+	for _, tt := range tests {
+		if got := backoff(2*time.Second, tt.failures); got != tt.want {
+			t.Errorf("backoff after %d failures: expected %s, got %s", tt.failures, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package timeseries
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/arduino/arduino-cloud-cli/internal/iot"
+	iotclient "github.com/arduino/iot-client-go/v3"
+	"github.com/sirupsen/logrus"
+)
+
+// Without an aggregation interval, the server returns the values of
+// a time range aggregated over an interval of its choice, depending
+// on their density: time ranges are kept short, to get values
+// as close as possible to the raw ones.
+const (
+	// pageSize is the number of values requested at once:
+	// time ranges yielding more values are split.
+	pageSize = 1000
+	// defaultWindow is the initial time range requested without an aggregation interval.
+	defaultWindow = time.Hour
+	// maxDefaultWindow is the maximum time range requested without an aggregation interval.
+	maxDefaultWindow = 7 * 24 * time.Hour
+)
+
+// mergedFilename is the name, without extension,
+// of the output file when the export is merged.
+const mergedFilename = "timeseries"
+
+// ExportParams contains the parameters needed to
+// export the time series of the properties of things.
+type ExportParams struct {
+	ThingIDs []string          // Things to export, mutually exclusive with Tags
+	Tags     map[string]string // Tags of the things to export
+	From     time.Time
+	To       time.Time
+	Interval time.Duration // Aggregation interval; if zero, it's chosen by the server
+	Format   string        // One of Formats
+	OutDir   string        // Directory where files are written
+	Merge    bool          // Write a single file instead of one per thing
+	Resume   bool          // Resume an interrupted export
+}
+
+// ExportResult contains the files written by an export.
+type ExportResult struct {
+	Files []string `json:"files"`
+	Rows  int      `json:"rows"`
+}
+
+type timeseriesClient interface {
+	ThingList(ctx context.Context, ids []string, device *string, props bool, tags map[string]string) ([]iotclient.ArduinoThing, error)
+	PropertyTimeseries(ctx context.Context, thingId, propertyId string, from, to time.Time, interval int32) (*iotclient.ArduinoTimeseriesmedia, error)
+}
+
+// Export writes the values of all the properties of the passed things
+// in the [From, To) time range, with one file per thing or a single merged file.
+// Progress is saved in the output directory after each request,
+// so that an interrupted export can be resumed.
+func Export(ctx context.Context, params *ExportParams, cred *config.Credentials) (*ExportResult, error) {
+	if err := validate(params); err != nil {
+		return nil, err
+	}
+	iotClient, err := iot.NewClient(cred)
+	if err != nil {
+		return nil, err
+	}
+	return export(ctx, iotClient, params)
+}
+
+func validate(params *ExportParams) error {
+	if (len(params.ThingIDs) == 0) == (len(params.Tags) == 0) {
+		return errors.New("provide either thing IDs or tags")
+	}
+	if !params.To.After(params.From) {
+		return errors.New("the end of the time range must follow its start")
+	}
+	if params.Interval < 0 || params.Interval%time.Second != 0 {
+		return errors.New("the interval must be a positive number of seconds")
+	}
+	for _, f := range Formats {
+		if params.Format == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("format '%s' not valid, use one of %v", params.Format, Formats)
+}
+
+func export(ctx context.Context, client timeseriesClient, params *ExportParams) (*ExportResult, error) {
+	var ids []string
+	if len(params.ThingIDs) > 0 {
+		ids = params.ThingIDs
+	}
+	things, err := client.ThingList(ctx, ids, nil, true, params.Tags)
+	if err != nil {
+		return nil, err
+	}
+	if len(things) == 0 {
+		return nil, errors.New("no things found")
+	}
+	sort.Slice(things, func(i, j int) bool { return things[i].Id < things[j].Id })
+
+	pp := progressParams{
+		From:     params.From.UTC().Truncate(time.Second),
+		To:       params.To.UTC().Truncate(time.Second),
+		Interval: int32(params.Interval / time.Second),
+		Format:   params.Format,
+		Merge:    params.Merge,
+	}
+	for _, t := range things {
+		pp.ThingIDs = append(pp.ThingIDs, t.Id)
+	}
+
+	if err = os.MkdirAll(params.OutDir, 0755); err != nil {
+		return nil, err
+	}
+	prog := &progress{
+		Params:    pp,
+		Exported:  make(map[string]time.Time),
+		FileSizes: make(map[string]int64),
+		Completed: make(map[string]bool),
+	}
+	if params.Resume {
+		if prog, err = loadProgress(params.OutDir, pp); err != nil {
+			return nil, err
+		}
+	}
+
+	e := &exporter{client: client, params: pp, dir: params.OutDir, progress: prog}
+	res := &ExportResult{}
+	var out *rowFile
+	for _, t := range things {
+		path := filepath.Join(params.OutDir, mergedFilename+"."+params.Format)
+		if !params.Merge {
+			path = filepath.Join(params.OutDir, t.Id+"."+params.Format)
+		}
+		if prog.Completed[path] {
+			if len(res.Files) == 0 || res.Files[len(res.Files)-1] != path {
+				res.Files = append(res.Files, path)
+			}
+			continue
+		}
+		if out == nil || out.path != path {
+			if out != nil {
+				if err := e.finish(out, res); err != nil {
+					return nil, err
+				}
+			}
+			if out, err = openRowFile(path, params.Format, prog.FileSizes[path]); err != nil {
+				return nil, err
+			}
+		}
+		if err := e.exportThing(ctx, out, &t, res); err != nil {
+			out.close()
+			return nil, err
+		}
+	}
+	if out != nil {
+		if err := e.finish(out, res); err != nil {
+			return nil, err
+		}
+	}
+
+	// The export is complete, it can't be resumed anymore
+	if err = os.Remove(progressPath(params.OutDir)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return res, nil
+}
+
+type exporter struct {
+	client   timeseriesClient
+	params   progressParams
+	dir      string
+	progress *progress
+}
+
+// finish closes an output file, once all its rows have been written.
+func (e *exporter) finish(out *rowFile, res *ExportResult) error {
+	if err := out.close(); err != nil {
+		return err
+	}
+	if err := out.finalize(); err != nil {
+		return err
+	}
+	res.Files = append(res.Files, out.path)
+	e.progress.Completed[out.path] = true
+	return e.progress.save(e.dir)
+}
+
+func (e *exporter) exportThing(ctx context.Context, out *rowFile, thing *iotclient.ArduinoThing, res *ExportResult) error {
+	props := append([]iotclient.ArduinoProperty(nil), thing.Properties...)
+	sort.Slice(props, func(i, j int) bool { return props[i].Name < props[j].Name })
+
+	for _, p := range props {
+		variable := p.Name
+		if p.VariableName != nil {
+			variable = *p.VariableName
+		}
+		key := thing.Id + "/" + p.Id
+		from := e.params.From
+		if done, ok := e.progress.Exported[key]; ok {
+			from = done
+		}
+
+		window := defaultWindow
+		if e.params.Interval > 0 {
+			window = time.Duration(e.params.Interval) * time.Second * pageSize
+		}
+		for from.Before(e.params.To) {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			to := from.Add(window)
+			if to.After(e.params.To) {
+				to = e.params.To
+			}
+			logrus.Infof("Exporting variable %s of thing %s from %s to %s", variable, thing.Id, from.Format(time.RFC3339), to.Format(time.RFC3339))
+			series, err := e.client.PropertyTimeseries(ctx, thing.Id, p.Id, from, to, e.params.Interval)
+			if err != nil {
+				return fmt.Errorf("exporting variable %s of thing %s: %w", variable, thing.Id, err)
+			}
+
+			// Too many values may have been truncated: split the time range
+			if len(series.Data) >= pageSize && to.Sub(from) > time.Second {
+				window = (to.Sub(from) / 2).Truncate(time.Second)
+				if window < time.Second {
+					window = time.Second
+				}
+				continue
+			}
+
+			for _, d := range series.Data {
+				row := Row{
+					ThingID:    thing.Id,
+					ThingName:  thing.Name,
+					PropertyID: p.Id,
+					Variable:   variable,
+					Time:       d.Time,
+					Value:      d.Value,
+				}
+				if err := out.write(row); err != nil {
+					return err
+				}
+			}
+			res.Rows += len(series.Data)
+
+			size, err := out.flush()
+			if err != nil {
+				return err
+			}
+			e.progress.FileSizes[out.path] = size
+			e.progress.Exported[key] = to
+			if err := e.progress.save(e.dir); err != nil {
+				return err
+			}
+
+			// Few values: request a wider time range next time
+			if e.params.Interval == 0 && len(series.Data) < pageSize/4 && window < maxDefaultWindow {
+				window *= 2
+			}
+			from = to
+		}
+	}
+	return nil
+}
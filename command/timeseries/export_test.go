@@ -0,0 +1,280 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package timeseries
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	iotclient "github.com/arduino/iot-client-go/v3"
+	"github.com/parquet-go/parquet-go"
+)
+
+var errInterrupted = errors.New("interrupted")
+
+// timeseriesTest serves a value every minute for each property,
+// returning at most pageSize values per request.
+type timeseriesTest struct {
+	things    []iotclient.ArduinoThing
+	requests  int
+	failAt    int // Request failing to simulate an interruption, if not zero
+	buckets   int // If not zero, values requested without interval are aggregated in this many buckets
+	intervals []int32
+}
+
+func (c *timeseriesTest) ThingList(ctx context.Context, ids []string, device *string, props bool, tags map[string]string) ([]iotclient.ArduinoThing, error) {
+	return c.things, nil
+}
+
+func (c *timeseriesTest) PropertyTimeseries(ctx context.Context, thingId, propertyId string, from, to time.Time, interval int32) (*iotclient.ArduinoTimeseriesmedia, error) {
+	c.requests++
+	if c.requests == c.failAt {
+		return nil, errInterrupted
+	}
+	c.intervals = append(c.intervals, interval)
+	step := time.Minute
+	if interval > 0 {
+		step = time.Duration(interval) * time.Second
+	} else if c.buckets > 0 {
+		step = to.Sub(from) / time.Duration(c.buckets)
+	}
+	series := &iotclient.ArduinoTimeseriesmedia{Id: propertyId}
+	for t := from.Truncate(step); t.Before(to) && len(series.Data) < pageSize; t = t.Add(step) {
+		if t.Before(from) {
+			continue
+		}
+		series.Data = append(series.Data, iotclient.TimeseriesDataPoint{Time: t, Value: float64(t.Unix() % 100)})
+	}
+	return series, nil
+}
+
+func testThings() []iotclient.ArduinoThing {
+	name := func(s string) *string { return &s }
+	return []iotclient.ArduinoThing{
+		{
+			Id:   "thing-2",
+			Name: "second",
+			Properties: []iotclient.ArduinoProperty{
+				{Id: "prop-3", Name: "humidity", VariableName: name("humidity")},
+			},
+		},
+		{
+			Id:   "thing-1",
+			Name: "first",
+			Properties: []iotclient.ArduinoProperty{
+				{Id: "prop-2", Name: "temperature", VariableName: name("temperature")},
+				{Id: "prop-1", Name: "pressure", VariableName: name("pressure")},
+			},
+		},
+	}
+}
+
+func countLines(t *testing.T, path string) int {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Count(string(content), "\n")
+}
+
+func TestExport(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// 3 days of values, one per minute: exporting them without interval needs paging
+	to := from.Add(72 * time.Hour)
+	const perProperty = 72 * 60
+
+	tests := []struct {
+		name      string
+		format    string
+		interval  time.Duration
+		merge     bool
+		wantFiles []string
+		wantRows  int
+	}{
+		{
+			name:      "csv-per-thing",
+			format:    FormatCSV,
+			wantFiles: []string{"thing-1.csv", "thing-2.csv"},
+			wantRows:  3 * perProperty,
+		},
+		{
+			name:      "jsonl-merged",
+			format:    FormatJSONL,
+			merge:     true,
+			wantFiles: []string{"timeseries.jsonl"},
+			wantRows:  3 * perProperty,
+		},
+		{
+			name:      "parquet-aggregated",
+			format:    FormatParquet,
+			interval:  time.Hour,
+			wantFiles: []string{"thing-1.parquet", "thing-2.parquet"},
+			wantRows:  3 * 72,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			client := &timeseriesTest{things: testThings()}
+			params := &ExportParams{
+				ThingIDs: []string{"thing-1", "thing-2"},
+				From:     from,
+				To:       to,
+				Interval: tt.interval,
+				Format:   tt.format,
+				OutDir:   dir,
+				Merge:    tt.merge,
+			}
+			if err := validate(params); err != nil {
+				t.Fatal(err)
+			}
+			res, err := export(context.Background(), client, params)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if res.Rows != tt.wantRows {
+				t.Errorf("expected %d rows, got %d", tt.wantRows, res.Rows)
+			}
+			if len(res.Files) != len(tt.wantFiles) {
+				t.Fatalf("expected files %v, got %v", tt.wantFiles, res.Files)
+			}
+			for i, f := range tt.wantFiles {
+				if res.Files[i] != filepath.Join(dir, f) {
+					t.Errorf("expected file %s, got %s", f, res.Files[i])
+				}
+			}
+			entries, _ := os.ReadDir(dir)
+			if len(entries) != len(tt.wantFiles) {
+				t.Errorf("expected only the exported files in the output directory, got %d entries", len(entries))
+			}
+
+			if tt.format == FormatCSV {
+				// Header plus a line per value
+				if n := countLines(t, res.Files[0]); n != 2*perProperty+1 {
+					t.Errorf("expected %d lines, got %d", 2*perProperty+1, n)
+				}
+			}
+			if tt.format == FormatParquet {
+				rows, err := parquet.ReadFile[parquetRow](res.Files[0])
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(rows) != 2*72 {
+					t.Errorf("expected %d rows, got %d", 2*72, len(rows))
+				}
+				if r := rows[0]; r.ThingID != "thing-1" || !r.Time.Equal(from) {
+					t.Errorf("unexpected first row: %+v", r)
+				}
+			}
+		})
+	}
+}
+
+func TestExportServerAggregation(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(72 * time.Hour)
+	dir := t.TempDir()
+
+	// Without interval, the values aggregated by the server are exported as they are
+	client := &timeseriesTest{things: testThings(), buckets: 10}
+	params := &ExportParams{
+		ThingIDs: []string{"thing-1", "thing-2"},
+		From:     from,
+		To:       to,
+		Format:   FormatJSONL,
+		OutDir:   dir,
+		Merge:    true,
+	}
+	res, err := export(context.Background(), client, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, i := range client.intervals {
+		if i != 0 {
+			t.Fatalf("expected no interval to be requested, got %d", i)
+		}
+	}
+	if res.Rows != 10*client.requests {
+		t.Errorf("expected %d rows, got %d", 10*client.requests, res.Rows)
+	}
+	if n := countLines(t, res.Files[0]); n != res.Rows {
+		t.Errorf("expected %d lines, got %d", res.Rows, n)
+	}
+}
+
+func TestExportResume(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	const perProperty = 24 * 60
+
+	for _, format := range Formats {
+		t.Run(format, func(t *testing.T) {
+			dir := t.TempDir()
+			params := &ExportParams{
+				ThingIDs: []string{"thing-1", "thing-2"},
+				From:     from,
+				To:       to,
+				Format:   format,
+				OutDir:   dir,
+				Merge:    true,
+			}
+
+			client := &timeseriesTest{things: testThings(), failAt: 7}
+			if _, err := export(context.Background(), client, params); !errors.Is(err, errInterrupted) {
+				t.Fatalf("expected interruption, got %v", err)
+			}
+			if _, err := os.Stat(progressPath(dir)); err != nil {
+				t.Fatalf("progress not saved: %v", err)
+			}
+
+			// Resuming with different parameters is refused
+			params.Resume = true
+			params.To = to.Add(time.Hour)
+			if _, err := export(context.Background(), client, params); err == nil {
+				t.Fatal("expected an error resuming with different parameters")
+			}
+
+			params.To = to
+			client.failAt = 0
+			if _, err := export(context.Background(), client, params); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Stat(progressPath(dir)); !errors.Is(err, os.ErrNotExist) {
+				t.Error("progress should be removed once the export is complete")
+			}
+
+			if format == FormatParquet {
+				return
+			}
+			want := 3 * perProperty
+			if format == FormatCSV {
+				want++
+			}
+			if n := countLines(t, filepath.Join(dir, "timeseries."+format)); n != want {
+				t.Errorf("expected %d lines, got %d", want, n)
+			}
+		})
+	}
+}
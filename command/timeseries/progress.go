@@ -0,0 +1,96 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package timeseries
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// progressFilename is the name of the file, in the output directory,
+// recording the progress of an export so that it can be resumed.
+const progressFilename = ".timeseries-export.json"
+
+// progress records what has been exported so far.
+type progress struct {
+	Params    progressParams       `json:"params"`
+	Exported  map[string]time.Time `json:"exported"`   // Time up to which each property has been exported, keyed by thing id and property id
+	FileSizes map[string]int64     `json:"file_sizes"` // Size of the output files when the progress was saved
+	Completed map[string]bool      `json:"completed"`  // Output files already written entirely
+}
+
+// progressParams are the parameters an export can be resumed with:
+// they must match the ones of the interrupted export.
+type progressParams struct {
+	ThingIDs []string  `json:"thing_ids"`
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+	Interval int32     `json:"interval"`
+	Format   string    `json:"format"`
+	Merge    bool      `json:"merge"`
+}
+
+func progressPath(dir string) string {
+	return filepath.Join(dir, progressFilename)
+}
+
+// loadProgress reads the progress of an interrupted export.
+func loadProgress(dir string, params progressParams) (*progress, error) {
+	content, err := os.ReadFile(progressPath(dir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errors.New("no interrupted export found in the output directory")
+	}
+	if err != nil {
+		return nil, err
+	}
+	p := &progress{}
+	if err = json.Unmarshal(content, p); err != nil {
+		return nil, fmt.Errorf("parsing export progress: %w", err)
+	}
+	want, _ := json.Marshal(params)
+	got, _ := json.Marshal(p.Params)
+	if string(want) != string(got) {
+		return nil, errors.New("the interrupted export was started with different parameters")
+	}
+	if p.Exported == nil {
+		p.Exported = make(map[string]time.Time)
+	}
+	if p.FileSizes == nil {
+		p.FileSizes = make(map[string]int64)
+	}
+	if p.Completed == nil {
+		p.Completed = make(map[string]bool)
+	}
+	return p, nil
+}
+
+func (p *progress) save(dir string) error {
+	content, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := progressPath(dir) + ".tmp"
+	if err = os.WriteFile(tmp, content, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, progressPath(dir))
+}
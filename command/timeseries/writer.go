@@ -0,0 +1,210 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package timeseries
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Supported export formats.
+const (
+	FormatCSV     = "csv"
+	FormatJSONL   = "jsonl"
+	FormatParquet = "parquet"
+)
+
+// Formats lists the supported export formats.
+var Formats = []string{FormatCSV, FormatJSONL, FormatParquet}
+
+// Row is a value of a property, as exported.
+type Row struct {
+	ThingID    string    `json:"thing_id"`
+	ThingName  string    `json:"thing_name"`
+	PropertyID string    `json:"property_id"`
+	Variable   string    `json:"variable"`
+	Time       time.Time `json:"time"`
+	Value      float64   `json:"value"`
+}
+
+var columns = []string{"thing_id", "thing_name", "property_id", "variable", "time", "value"}
+
+// rowFile is an output file opened for appending rows.
+// Parquet files can't be appended to, so their rows are staged
+// in a jsonl file which is converted once the export is complete.
+type rowFile struct {
+	path   string // Path of the final file
+	format string
+	file   *os.File
+	buf    *bufio.Writer
+	csv    *csv.Writer
+}
+
+// stagingPath returns the path of the file rows are appended to.
+func stagingPath(path, format string) string {
+	if format == FormatParquet {
+		return path + ".partial.jsonl"
+	}
+	return path
+}
+
+// openRowFile opens an output file, truncating it to the passed size:
+// rows written after the last saved progress are discarded.
+func openRowFile(path, format string, size int64) (*rowFile, error) {
+	f, err := os.OpenFile(stagingPath(path, format), os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err = f.Truncate(size); err == nil {
+		_, err = f.Seek(size, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	rf := &rowFile{path: path, format: format, file: f, buf: bufio.NewWriter(f)}
+	if format == FormatCSV {
+		rf.csv = csv.NewWriter(rf.buf)
+		if size == 0 {
+			if err := rf.csv.Write(columns); err != nil {
+				f.Close()
+				return nil, err
+			}
+		}
+	}
+	return rf, nil
+}
+
+func (rf *rowFile) write(r Row) error {
+	if rf.csv != nil {
+		return rf.csv.Write([]string{
+			r.ThingID, r.ThingName, r.PropertyID, r.Variable,
+			r.Time.UTC().Format(time.RFC3339Nano),
+			strconv.FormatFloat(r.Value, 'g', -1, 64),
+		})
+	}
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	_, err = rf.buf.Write(append(line, '\n'))
+	return err
+}
+
+// flush writes the buffered rows and returns the size of the file.
+func (rf *rowFile) flush() (int64, error) {
+	if rf.csv != nil {
+		rf.csv.Flush()
+		if err := rf.csv.Error(); err != nil {
+			return 0, err
+		}
+	}
+	if err := rf.buf.Flush(); err != nil {
+		return 0, err
+	}
+	return rf.file.Seek(0, io.SeekCurrent)
+}
+
+func (rf *rowFile) close() error {
+	_, err := rf.flush()
+	if cerr := rf.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// finalize converts the staged rows of a parquet file into the final file.
+func (rf *rowFile) finalize() error {
+	if rf.format != FormatParquet {
+		return nil
+	}
+	staging := stagingPath(rf.path, rf.format)
+	in, err := os.Open(staging)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := rf.path + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err = writeParquet(in, out); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("writing %s: %w", rf.path, err)
+	}
+	if err = out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err = os.Rename(tmp, rf.path); err != nil {
+		return err
+	}
+	in.Close()
+	return os.Remove(staging)
+}
+
+// parquetRow is the schema of the exported parquet files.
+type parquetRow struct {
+	ThingID    string    `parquet:"thing_id"`
+	ThingName  string    `parquet:"thing_name"`
+	PropertyID string    `parquet:"property_id"`
+	Variable   string    `parquet:"variable"`
+	Time       time.Time `parquet:"time,timestamp(millisecond)"`
+	Value      float64   `parquet:"value"`
+}
+
+// parquetBatchSize is the number of rows passed at once to the parquet writer.
+const parquetBatchSize = 1024
+
+func writeParquet(in io.Reader, out io.Writer) error {
+	pw := parquet.NewGenericWriter[parquetRow](out)
+	batch := make([]parquetRow, 0, parquetBatchSize)
+	dec := json.NewDecoder(bufio.NewReader(in))
+	for {
+		var r Row
+		if err := dec.Decode(&r); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return err
+		}
+		batch = append(batch, parquetRow(r))
+		if len(batch) == cap(batch) {
+			if _, err := pw.Write(batch); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	if _, err := pw.Write(batch); err != nil {
+		return err
+	}
+	return pw.Close()
+}
@@ -19,7 +19,7 @@ package config
 
 import (
 	"fmt"
-	"strings"
+	"net/url"
 
 	"github.com/arduino/go-paths-helper"
 	"github.com/sirupsen/logrus"
@@ -50,6 +50,8 @@ func SetEmptyCredentials(settings *viper.Viper) {
 	settings.SetDefault("secret", "")
 	// OrganizationID
 	settings.SetDefault("organization", "")
+	// API base URL
+	settings.SetDefault("base_url", "")
 }
 
 // Credentials contains the parameters of Arduino IoT Cloud credentials.
@@ -57,6 +59,8 @@ type Credentials struct {
 	Client       string `mapstructure:"client"`       // Client ID of the user; mandatory.
 	Secret       string `mapstructure:"secret"`       // Secret ID of the user, unique for each Client ID; mandatory.
 	Organization string `mapstructure:"organization"` // Organization ID of the user; this is considered optional.
+	BaseURL      string `mapstructure:"base_url"`     // Base URL of Arduino Cloud API; this is considered optional.
+	SecretStore  string `mapstructure:"secret_store"` // Store keeping the secret when it's not in the credentials file; optional.
 }
 
 // Validate the credentials.
@@ -83,6 +87,21 @@ func (c *Credentials) Validate() error {
 			len(c.Organization),
 		)
 	}
+	if len(c.BaseURL) != 0 {
+		if err := ValidateBaseURL(c.BaseURL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateBaseURL checks that the passed string is a valid
+// base URL for Arduino Cloud API.
+func ValidateBaseURL(baseURL string) error {
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("base url not valid, expected an http or https url but got '%s'", baseURL)
+	}
 	return nil
 }
 
@@ -94,31 +113,43 @@ func (c *Credentials) IsEmpty() bool {
 
 // FindCredentials looks for credentials in
 // environment variables or in credentials file.
-// Returns the source of found credentials (env or filepath).
+// Returns the source of found credentials (env or filepath)
+// and, if credentials are found in a file, the name of the selected profile.
 // Returns an error if credentials are not found
 // specifying paths where the credentials are searched.
-func FindCredentials() (source string, err error) {
-	// Credentials extracted from environment has highest priority
-	logrus.Info("Looking for credentials in environment variables")
-	c, err := fromEnv()
-	if err != nil {
-		return "", fmt.Errorf("looking for credentials in environment variables: %w", err)
-	}
-	if !c.IsEmpty() {
-		logrus.Infof("Credentials found in environment variables with prefix '%s'", EnvPrefix)
-		return "environment variables", nil
+func FindCredentials() (source, profile string, err error) {
+	// Credentials extracted from environment has highest priority,
+	// unless a profile has been explicitly requested
+	profile = SelectedProfile()
+	if profile == "" {
+		logrus.Info("Looking for credentials in environment variables")
+		c, err := fromEnv()
+		if err != nil {
+			return "", "", fmt.Errorf("looking for credentials in environment variables: %w", err)
+		}
+		if !c.IsEmpty() {
+			logrus.Infof("Credentials found in environment variables with prefix '%s'", EnvPrefix)
+			return "environment variables", "", nil
+		}
 	}
 
 	logrus.Info("Looking for credentials in file system")
 	path, found, err := searchConfigFile(CredentialsFilename)
 	if err != nil {
-		return "", fmt.Errorf("looking for credentials files: %w", err)
+		return "", "", fmt.Errorf("looking for credentials files: %w", err)
 	}
 	if found {
-		return path, nil
+		file, err := LoadCredentialsFile(path)
+		if err != nil {
+			return "", "", fmt.Errorf("reading credentials from file %s: %w", path, err)
+		}
+		if _, profile, err = file.Resolve(profile); err != nil {
+			return "", "", fmt.Errorf("reading credentials from file %s: %w", path, err)
+		}
+		return path, profile, nil
 	}
 
-	return "", fmt.Errorf(
+	return "", "", fmt.Errorf(
 		"credentials have not been found neither in environment variables " +
 			"nor in the current directory, its parents or in arduino15",
 	)
@@ -126,25 +157,31 @@ func FindCredentials() (source string, err error) {
 
 // RetrieveCredentials retrieves credentials from
 // environment variables or credentials file.
+// If a profile has been selected, credentials are only
+// retrieved from that profile of the credentials file.
 // Returns error if credentials are not found or
 // if found credentials are invalid.
 func RetrieveCredentials() (cred *Credentials, err error) {
-	// Credentials extracted from environment has highest priority
-	logrus.Info("Looking for credentials in environment variables")
-	cred, err = fromEnv()
-	if err != nil {
-		return nil, fmt.Errorf("reading credentials from environment variables: %w", err)
-	}
-	// Returns credentials if found in env
-	if !cred.IsEmpty() {
-		// Returns error if credentials are found but are not valid
-		if err := cred.Validate(); err != nil {
-			return nil, fmt.Errorf(
-				"credentials retrieved from environment variables with prefix '%s' are not valid: %w", EnvPrefix, err,
-			)
+	// Credentials extracted from environment has highest priority,
+	// unless a profile has been explicitly requested
+	profile := SelectedProfile()
+	if profile == "" {
+		logrus.Info("Looking for credentials in environment variables")
+		cred, err = fromEnv()
+		if err != nil {
+			return nil, fmt.Errorf("reading credentials from environment variables: %w", err)
+		}
+		// Returns credentials if found in env
+		if !cred.IsEmpty() {
+			// Returns error if credentials are found but are not valid
+			if err := cred.Validate(); err != nil {
+				return nil, fmt.Errorf(
+					"credentials retrieved from environment variables with prefix '%s' are not valid: %w", EnvPrefix, err,
+				)
+			}
+			logrus.Infof("Credentials found in environment variables with prefix '%s'", EnvPrefix)
+			return cred, nil
 		}
-		logrus.Infof("Credentials found in environment variables with prefix '%s'", EnvPrefix)
-		return cred, nil
 	}
 
 	logrus.Info("Looking for credentials in file system")
@@ -154,15 +191,16 @@ func RetrieveCredentials() (cred *Credentials, err error) {
 	}
 	// Returns credentials if found in a file
 	if found {
-		if cred, err = fromFile(filepath); err != nil {
+		if cred, profile, err = fromFile(filepath, profile); err != nil {
 			return nil, fmt.Errorf("reading credentials from file %s: %w", filepath, err)
 		}
 		// Returns error if credentials are found but are not valid
 		if err := cred.Validate(); err != nil {
 			return nil, fmt.Errorf(
-				"credentials retrieved from file %s are not valid: %w", filepath, err,
+				"credentials of profile '%s' retrieved from file %s are not valid: %w", profile, filepath, err,
 			)
 		}
+		logrus.Infof("Using credentials of profile '%s' found in %s", profile, filepath)
 		return cred, nil
 	}
 
@@ -172,23 +210,24 @@ func RetrieveCredentials() (cred *Credentials, err error) {
 	)
 }
 
-// fromFile retrieves credentials from a credentials file.
+// fromFile retrieves the credentials of a profile from a credentials file.
+// If profile is empty, the default profile of the file is used.
+// If the secret is kept in a secret store, it's retrieved from there.
 // Returns error if credentials are not found or cannot be fetched.
-func fromFile(filepath string) (*Credentials, error) {
-	v := viper.New()
-	v.SetConfigFile(filepath)
-	v.SetConfigType(strings.TrimLeft(paths.New(filepath).Ext(), "."))
-	err := v.ReadInConfig()
+func fromFile(filepath, profile string) (*Credentials, string, error) {
+	file, err := LoadCredentialsFile(filepath)
 	if err != nil {
-		return nil, fmt.Errorf("cannot read credentials file: %w", err)
+		return nil, "", err
 	}
-
-	cred := &Credentials{}
-	err = v.Unmarshal(cred)
+	cred, profile, err := file.Resolve(profile)
 	if err != nil {
-		return nil, fmt.Errorf("cannot unmarshal credentials file: %w", err)
+		return nil, "", err
 	}
-	return cred, nil
+	c := *cred
+	if err := resolveSecret(&c, paths.New(filepath).Parent().String()); err != nil {
+		return nil, "", err
+	}
+	return &c, profile, nil
 }
 
 // fromEnv retrieves credentials from environment variables.
@@ -0,0 +1,246 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/arduino/go-paths-helper"
+	"github.com/spf13/viper"
+)
+
+const (
+	// DefaultProfile is the name of the profile used when no profile
+	// is selected and the credentials file doesn't specify a default one.
+	// Legacy credentials files, containing a single set of credentials,
+	// are exposed as a file with this profile only.
+	DefaultProfile = "default"
+
+	// ProfileEnv is the environment variable that can be used
+	// to select a credentials profile when the '--profile' flag is not passed.
+	ProfileEnv = EnvPrefix + "_PROFILE"
+)
+
+var (
+	// selectedProfile is the profile explicitly requested by the user.
+	selectedProfile string
+
+	profileNameRegexp = regexp.MustCompile(`^[a-z0-9_-]+$`)
+)
+
+// SetProfile selects the credentials profile to be used
+// when credentials are retrieved.
+func SetProfile(name string) {
+	selectedProfile = strings.ToLower(name)
+}
+
+// SelectedProfile returns the profile explicitly requested by the user,
+// either with SetProfile or with the ProfileEnv environment variable.
+// Returns an empty string if no profile has been requested.
+func SelectedProfile() string {
+	if selectedProfile != "" {
+		return selectedProfile
+	}
+	return strings.ToLower(os.Getenv(ProfileEnv))
+}
+
+// ValidateProfileName checks that the passed name can be used as a profile name.
+// Profile names are case insensitive and can only contain
+// letters, digits, dashes and underscores.
+func ValidateProfileName(name string) error {
+	if !profileNameRegexp.MatchString(strings.ToLower(name)) {
+		return fmt.Errorf("profile name '%s' not valid: only letters, digits, '-' and '_' are allowed", name)
+	}
+	return nil
+}
+
+// CredentialsFile represents a credentials file, containing
+// one or more credentials profiles.
+type CredentialsFile struct {
+	Path           string                  // Path of the credentials file.
+	DefaultProfile string                  // Profile used when no profile is explicitly requested; optional.
+	Profiles       map[string]*Credentials // Credentials profiles, keyed by name.
+}
+
+// FindCredentialsFile looks for a credentials file in the current directory,
+// its parents and in arduino15 default directory.
+// Returns false if no credentials file has been found.
+func FindCredentialsFile() (path string, found bool, err error) {
+	return searchConfigFile(CredentialsFilename)
+}
+
+// NewCredentialsFile returns an empty credentials file
+// that will be written at the passed path.
+func NewCredentialsFile(path string) *CredentialsFile {
+	return &CredentialsFile{
+		Path:     path,
+		Profiles: make(map[string]*Credentials),
+	}
+}
+
+// LoadCredentialsFile reads the credentials file at the passed path.
+// Credentials files written before the introduction of profiles
+// are loaded as a file containing the DefaultProfile only.
+func LoadCredentialsFile(path string) (*CredentialsFile, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType(strings.TrimLeft(paths.New(path).Ext(), "."))
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("cannot read credentials file: %w", err)
+	}
+
+	file := NewCredentialsFile(path)
+	if !v.IsSet("profiles") {
+		cred := &Credentials{}
+		if err := v.Unmarshal(cred); err != nil {
+			return nil, fmt.Errorf("cannot unmarshal credentials file: %w", err)
+		}
+		if !cred.IsEmpty() {
+			file.Profiles[DefaultProfile] = cred
+		}
+		return file, nil
+	}
+
+	if err := v.UnmarshalKey("profiles", &file.Profiles); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal profiles of credentials file: %w", err)
+	}
+	for name, cred := range file.Profiles {
+		if cred == nil {
+			file.Profiles[name] = &Credentials{}
+		}
+	}
+	file.DefaultProfile = strings.ToLower(v.GetString("default_profile"))
+	return file, nil
+}
+
+// Names returns the sorted names of the profiles contained in the file.
+func (f *CredentialsFile) Names() []string {
+	names := make([]string, 0, len(f.Profiles))
+	for name := range f.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolve returns the credentials of the passed profile, along with its name.
+// If name is empty, the profile is chosen in the following order:
+// the default profile of the file, the DefaultProfile, the only profile of the file.
+func (f *CredentialsFile) Resolve(name string) (*Credentials, string, error) {
+	name = strings.ToLower(name)
+	if name == "" {
+		name = f.DefaultProfile
+	}
+	if name == "" {
+		if _, ok := f.Profiles[DefaultProfile]; ok {
+			name = DefaultProfile
+		} else if len(f.Profiles) == 1 {
+			name = f.Names()[0]
+		}
+	}
+	if name == "" {
+		if len(f.Profiles) == 0 {
+			return nil, "", errors.New("no profile found")
+		}
+		return nil, "", fmt.Errorf(
+			"multiple profiles found (%s): select one with '--profile' or 'credentials use'",
+			strings.Join(f.Names(), ", "),
+		)
+	}
+
+	cred, ok := f.Profiles[name]
+	if !ok {
+		return nil, "", fmt.Errorf("profile '%s' not found", name)
+	}
+	return cred, name, nil
+}
+
+// Set adds the passed profile to the file, overwriting
+// the existing profile with the same name, if any.
+func (f *CredentialsFile) Set(name string, cred *Credentials) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+	f.Profiles[strings.ToLower(name)] = cred
+	return nil
+}
+
+// Remove deletes the passed profile from the file.
+// If the removed profile was the default one, the file
+// is left without a default profile.
+func (f *CredentialsFile) Remove(name string) error {
+	name = strings.ToLower(name)
+	if _, ok := f.Profiles[name]; !ok {
+		return fmt.Errorf("profile '%s' not found", name)
+	}
+	delete(f.Profiles, name)
+	if f.DefaultProfile == name {
+		f.DefaultProfile = ""
+	}
+	return nil
+}
+
+// Use sets the passed profile as the default one of the file.
+func (f *CredentialsFile) Use(name string) error {
+	name = strings.ToLower(name)
+	if _, ok := f.Profiles[name]; !ok {
+		return fmt.Errorf("profile '%s' not found", name)
+	}
+	f.DefaultProfile = name
+	return nil
+}
+
+// Write saves the credentials file, using the profiles format.
+// The format of the file (json or yaml) is inferred from its extension.
+func (f *CredentialsFile) Write() error {
+	profiles := make(map[string]interface{}, len(f.Profiles))
+	for name, cred := range f.Profiles {
+		p := map[string]interface{}{
+			"client": cred.Client,
+		}
+		if cred.Secret != "" {
+			p["secret"] = cred.Secret
+		}
+		if cred.SecretStore != "" {
+			p["secret_store"] = cred.SecretStore
+		}
+		if cred.Organization != "" {
+			p["organization"] = cred.Organization
+		}
+		if cred.BaseURL != "" {
+			p["base_url"] = cred.BaseURL
+		}
+		profiles[name] = p
+	}
+
+	v := viper.New()
+	v.SetConfigPermissions(os.FileMode(0600))
+	if f.DefaultProfile != "" {
+		v.Set("default_profile", f.DefaultProfile)
+	}
+	v.Set("profiles", profiles)
+	if err := v.WriteConfigAs(f.Path); err != nil {
+		return fmt.Errorf("cannot write credentials file: %w", err)
+	}
+	return nil
+}
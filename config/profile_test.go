@@ -0,0 +1,207 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const (
+	testSecret       = "qaRZGEbnQNNvmaeTLqy8Bxs22wLZ6H7obIiNSveTLPdoQuylANnuy6WBOw16XoqH"
+	testClient       = "CQ4iZ5sebOfhGRwUn3IV0r1YFMNrMTIx"
+	testOrganization = "dc6a6159-3cd5-41a2-b391-553b1351cd98"
+)
+
+func TestCredentialsFileResolve(t *testing.T) {
+	legacy := "client: " + testClient + "\nsecret: " + testSecret + "\n"
+	profiles := "profiles:\n" +
+		"  staging:\n    client: " + testClient + "\n    secret: " + testSecret + "\n    organization: " + testOrganization + "\n" +
+		"  Prod:\n    client: " + testClient + "\n    secret: " + testSecret + "\n    base_url: https://api.example.com\n"
+	profilesWithDefault := "default_profile: prod\n" + profiles
+
+	tests := []struct {
+		name        string
+		content     string
+		profile     string
+		wantProfile string
+		wantCred    *Credentials
+		wantErr     bool
+	}{
+		{
+			name:        "legacy file is exposed as default profile",
+			content:     legacy,
+			wantProfile: DefaultProfile,
+			wantCred:    &Credentials{Client: testClient, Secret: testSecret},
+		},
+		{
+			name:    "legacy file doesn't contain the requested profile",
+			content: legacy,
+			profile: "staging",
+			wantErr: true,
+		},
+		{
+			name:        "requested profile, case insensitive",
+			content:     profiles,
+			profile:     "PROD",
+			wantProfile: "prod",
+			wantCred:    &Credentials{Client: testClient, Secret: testSecret, BaseURL: "https://api.example.com"},
+		},
+		{
+			name:    "multiple profiles without default",
+			content: profiles,
+			wantErr: true,
+		},
+		{
+			name:        "default profile of the file",
+			content:     profilesWithDefault,
+			wantProfile: "prod",
+			wantCred:    &Credentials{Client: testClient, Secret: testSecret, BaseURL: "https://api.example.com"},
+		},
+		{
+			name:        "requested profile has priority over default one",
+			content:     profilesWithDefault,
+			profile:     "staging",
+			wantProfile: "staging",
+			wantCred:    &Credentials{Client: testClient, Secret: testSecret, Organization: testOrganization},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), CredentialsFilename+".yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			file, err := LoadCredentialsFile(path)
+			if err != nil {
+				t.Fatalf("Unexpected error loading file: %v", err)
+			}
+
+			cred, profile, err := file.Resolve(tt.profile)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error, but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected nil error, but got: %v", err)
+			}
+			if profile != tt.wantProfile {
+				t.Errorf("Expected profile %s, but got %s", tt.wantProfile, profile)
+			}
+			if !cmp.Equal(cred, tt.wantCred) {
+				t.Errorf("Wrong credentials received, diff:\n%s", cmp.Diff(tt.wantCred, cred))
+			}
+		})
+	}
+}
+
+func TestCredentialsFileWrite(t *testing.T) {
+	for _, ext := range []string{"yaml", "json"} {
+		t.Run(ext, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), CredentialsFilename+"."+ext)
+			file := NewCredentialsFile(path)
+			if err := file.Set("Staging", &Credentials{Client: testClient, Secret: testSecret, Organization: testOrganization}); err != nil {
+				t.Fatal(err)
+			}
+			if err := file.Set("prod", &Credentials{Client: testClient, Secret: testSecret}); err != nil {
+				t.Fatal(err)
+			}
+			if err := file.Set("not.valid", &Credentials{}); err == nil {
+				t.Errorf("Expected an error for invalid profile name, but got nil")
+			}
+			if err := file.Use("prod"); err != nil {
+				t.Fatal(err)
+			}
+			if err := file.Write(); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := LoadCredentialsFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(got, file) {
+				t.Errorf("Wrong file loaded, diff:\n%s", cmp.Diff(file, got))
+			}
+
+			if err := got.Remove("prod"); err != nil {
+				t.Fatal(err)
+			}
+			if got.DefaultProfile != "" {
+				t.Errorf("Expected default profile to be reset, but got %s", got.DefaultProfile)
+			}
+			if err := got.Remove("prod"); err == nil {
+				t.Errorf("Expected an error removing a missing profile, but got nil")
+			}
+		})
+	}
+}
+
+func TestRetrieveCredentialsWithProfile(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	content := "profiles:\n" +
+		"  staging:\n    client: " + testClient + "\n    secret: " + testSecret + "\n" +
+		"  prod:\n    client: " + testClient + "\n    secret: " + testSecret + "\n    organization: " + testOrganization + "\n"
+	if err := os.WriteFile(filepath.Join(dir, CredentialsFilename+".yaml"), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Chdir(dir)
+	defer os.Chdir(cwd)
+
+	// Env credentials are ignored when a profile is explicitly selected
+	t.Setenv(EnvPrefix+"_CLIENT", "wrong")
+	t.Setenv(EnvPrefix+"_SECRET", "wrong")
+
+	t.Setenv(ProfileEnv, "staging")
+	got, err := RetrieveCredentials()
+	if err != nil {
+		t.Fatalf("Expected nil error, but got: %v", err)
+	}
+	if want := (&Credentials{Client: testClient, Secret: testSecret}); !cmp.Equal(got, want) {
+		t.Errorf("Wrong credentials received, diff:\n%s", cmp.Diff(want, got))
+	}
+
+	// The flag has priority over the environment variable
+	SetProfile("prod")
+	defer SetProfile("")
+	got, err = RetrieveCredentials()
+	if err != nil {
+		t.Fatalf("Expected nil error, but got: %v", err)
+	}
+	if want := (&Credentials{Client: testClient, Secret: testSecret, Organization: testOrganization}); !cmp.Equal(got, want) {
+		t.Errorf("Wrong credentials received, diff:\n%s", cmp.Diff(want, got))
+	}
+
+	src, profile, err := FindCredentials()
+	if err != nil {
+		t.Fatalf("Expected nil error, but got: %v", err)
+	}
+	if profile != "prod" || filepath.Base(src) != CredentialsFilename+".yaml" {
+		t.Errorf("Wrong credentials source: %s, profile %s", src, profile)
+	}
+}
@@ -0,0 +1,96 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/arduino/arduino-cloud-cli/internal/secret"
+	"github.com/manifoldco/promptui"
+)
+
+// PassphraseEnv is the environment variable that can be used to pass
+// the passphrase of the encrypted secrets file, for example in CI.
+const PassphraseEnv = EnvPrefix + "_SECRET_PASSPHRASE"
+
+// passphrase caches the passphrase typed by the user,
+// so that it is asked at most once per execution.
+var passphrase string
+
+// SecretStore returns the secret store with the passed name.
+// The encrypted file store keeps its file in the passed directory,
+// that should be the directory of the credentials file.
+func SecretStore(name, dir string) (secret.Store, error) {
+	switch name {
+	case secret.Keyring:
+		return secret.NewKeyringStore()
+	case secret.EncryptedFile:
+		return secret.NewEncryptedFileStore(filepath.Join(dir, secret.EncryptedFilename), readPassphrase), nil
+	default:
+		return nil, secret.ValidateStore(name)
+	}
+}
+
+// readPassphrase returns the passphrase of the encrypted secrets file,
+// taking it from the environment or asking it to the user.
+func readPassphrase() (string, error) {
+	if p := os.Getenv(PassphraseEnv); p != "" {
+		return p, nil
+	}
+	if passphrase != "" {
+		return passphrase, nil
+	}
+
+	prompt := promptui.Prompt{
+		Mask:  '*',
+		Label: "Please enter the passphrase of the encrypted secrets file",
+		Validate: func(s string) error {
+			if len(s) == 0 {
+				return errors.New("passphrase cannot be empty")
+			}
+			return nil
+		},
+	}
+	p, err := prompt.Run()
+	if err != nil {
+		return "", fmt.Errorf("passphrase prompt fail, you can pass it with the %s environment variable: %w", PassphraseEnv, err)
+	}
+	passphrase = p
+	return passphrase, nil
+}
+
+// resolveSecret retrieves the secret of the credentials from
+// their secret store, if the secret is not stored in the credentials file.
+func resolveSecret(cred *Credentials, dir string) error {
+	if cred.Secret != "" || cred.SecretStore == "" || cred.SecretStore == secret.Plaintext {
+		return nil
+	}
+	store, err := SecretStore(cred.SecretStore, dir)
+	if err != nil {
+		return err
+	}
+	sec, err := store.Get(cred.Client)
+	if err != nil {
+		return fmt.Errorf("retrieving secret of client %s from %s store: %w", cred.Client, cred.SecretStore, err)
+	}
+	cred.Secret = sec
+	return nil
+}
@@ -0,0 +1,72 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/arduino/arduino-cloud-cli/internal/secret"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRetrieveCredentialsFromEncryptedFile(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	t.Setenv(EnvPrefix+"_CLIENT", "")
+	t.Setenv(EnvPrefix+"_SECRET", "")
+	t.Setenv(PassphraseEnv, "passphrase")
+
+	store, err := SecretStore(secret.EncryptedFile, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set(testClient, testSecret); err != nil {
+		t.Fatal(err)
+	}
+
+	file := NewCredentialsFile(filepath.Join(dir, CredentialsFilename+".yaml"))
+	file.Set(DefaultProfile, &Credentials{Client: testClient, SecretStore: secret.EncryptedFile})
+	if err := file.Write(); err != nil {
+		t.Fatal(err)
+	}
+	os.Chdir(dir)
+	defer os.Chdir(cwd)
+
+	got, err := RetrieveCredentials()
+	if err != nil {
+		t.Fatalf("Expected nil error, but got: %v", err)
+	}
+	want := &Credentials{Client: testClient, Secret: testSecret, SecretStore: secret.EncryptedFile}
+	if !cmp.Equal(got, want) {
+		t.Errorf("Wrong credentials received, diff:\n%s", cmp.Diff(want, got))
+	}
+
+	// The secret is not saved in the credentials file
+	loaded, err := LoadCredentialsFile(file.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Profiles[DefaultProfile].Secret != "" {
+		t.Errorf("Secret should not be saved in the credentials file")
+	}
+}
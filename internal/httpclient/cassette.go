@@ -0,0 +1,377 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package httpclient
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// CassetteEnv is the environment variable containing the path of the
+	// cassette file used to record or replay the requests to Arduino Cloud.
+	CassetteEnv = config.EnvPrefix + "_CASSETTE"
+	// CassetteModeEnv is the environment variable selecting whether the
+	// cassette is recorded or replayed. Defaults to CassetteReplay.
+	CassetteModeEnv = config.EnvPrefix + "_CASSETTE_MODE"
+
+	// CassetteRecord performs real requests and saves them in the cassette.
+	CassetteRecord = "record"
+	// CassetteReplay serves the requests from the cassette, without any network access.
+	CassetteReplay = "replay"
+
+	// Redacted replaces the secrets saved in cassettes.
+	Redacted = "REDACTED"
+)
+
+var (
+	// redactedHeaders are the headers whose value is never saved in cassettes.
+	redactedHeaders = []string{"Authorization", "Cookie", "Set-Cookie"}
+	// redactedFields are the json and form fields whose value is never saved in cassettes.
+	redactedFields = map[string]bool{
+		"access_token":  true,
+		"refresh_token": true,
+		"id_token":      true,
+		"client_secret": true,
+		"secret":        true,
+		"password":      true,
+	}
+
+	cassettesMutex sync.Mutex
+	cassettes      = make(map[string]*Cassette)
+)
+
+// Cassette contains the interactions with Arduino Cloud
+// recorded during a session.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+
+	path  string
+	mutex sync.Mutex
+}
+
+// Interaction is a recorded request along with its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+
+	replayed bool
+}
+
+// Request is a recorded http request.
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Response is a recorded http response.
+// Binary bodies are base64 encoded.
+type Response struct {
+	StatusCode   int         `json:"status_code"`
+	Header       http.Header `json:"header,omitempty"`
+	Body         string      `json:"body,omitempty"`
+	BodyEncoding string      `json:"body_encoding,omitempty"`
+}
+
+// CassetteMode returns the cassette mode selected with
+// the environment variables, or an empty string if no
+// cassette should be used.
+func CassetteMode() string {
+	if os.Getenv(CassetteEnv) == "" {
+		return ""
+	}
+	if mode := strings.ToLower(os.Getenv(CassetteModeEnv)); mode != "" {
+		return mode
+	}
+	return CassetteReplay
+}
+
+// wrapCassette wraps the passed transport with the cassette
+// selected by the environment variables, if any.
+func wrapCassette(base http.RoundTripper) http.RoundTripper {
+	mode := CassetteMode()
+	if mode == "" {
+		return base
+	}
+	path := os.Getenv(CassetteEnv)
+	c, err := loadCassette(path, mode)
+	if err != nil {
+		return errorTransport{err}
+	}
+
+	switch mode {
+	case CassetteRecord:
+		logrus.Infof("Recording requests in cassette %s", path)
+		return &recorder{cassette: c, base: base}
+	case CassetteReplay:
+		logrus.Infof("Replaying requests from cassette %s", path)
+		return &replayer{cassette: c}
+	}
+	return errorTransport{fmt.Errorf("invalid cassette mode '%s', expected %s or %s", mode, CassetteRecord, CassetteReplay)}
+}
+
+// loadCassette returns the cassette at the passed path. Cassettes are shared
+// by all the clients of the process, so that every interaction is recorded
+// in the same file and replayed only once.
+func loadCassette(path, mode string) (*Cassette, error) {
+	cassettesMutex.Lock()
+	defer cassettesMutex.Unlock()
+	if c, ok := cassettes[path]; ok {
+		return c, nil
+	}
+
+	c := &Cassette{path: path}
+	if mode != CassetteRecord {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading cassette: %w", err)
+		}
+		if err := json.Unmarshal(content, c); err != nil {
+			return nil, fmt.Errorf("parsing cassette %s: %w", path, err)
+		}
+	}
+	cassettes[path] = c
+	return c, nil
+}
+
+func (c *Cassette) save() error {
+	content, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding cassette: %w", err)
+	}
+	if err := os.WriteFile(c.path, content, os.FileMode(0600)); err != nil {
+		return fmt.Errorf("writing cassette: %w", err)
+	}
+	return nil
+}
+
+// recorder performs the requests with the base transport
+// and saves them in the cassette, redacting their secrets.
+type recorder struct {
+	cassette *Cassette
+	base     http.RoundTripper
+}
+
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if reqBody, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(reqBody)), nil
+		}
+	}
+
+	res, err := r.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resBody, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(resBody))
+
+	i := &Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: redactHeader(req.Header),
+		},
+		Response: Response{
+			StatusCode: res.StatusCode,
+			Header:     redactHeader(res.Header),
+		},
+	}
+	// Binary request bodies, like firmwares, are not needed to replay the request
+	if utf8.Valid(reqBody) {
+		i.Request.Body = redactBody(req.Header.Get("Content-Type"), reqBody)
+	}
+	if utf8.Valid(resBody) {
+		i.Response.Body = redactBody(res.Header.Get("Content-Type"), resBody)
+	} else {
+		i.Response.Body = base64.StdEncoding.EncodeToString(resBody)
+		i.Response.BodyEncoding = "base64"
+	}
+
+	r.cassette.mutex.Lock()
+	defer r.cassette.mutex.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, i)
+	if err := r.cassette.save(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// replayer serves the requests from the cassette.
+// Requests are matched by method and path, in the recorded order;
+// once all the matching interactions have been replayed,
+// the last one is served again.
+type replayer struct {
+	cassette *Cassette
+}
+
+func (r *replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	r.cassette.mutex.Lock()
+	var found *Interaction
+	for _, i := range r.cassette.Interactions {
+		if !matchRequest(i.Request, req) {
+			continue
+		}
+		found = i
+		if !i.replayed {
+			break
+		}
+	}
+	if found != nil {
+		found.replayed = true
+	}
+	r.cassette.mutex.Unlock()
+
+	if found == nil {
+		return nil, fmt.Errorf("no interaction for %s %s in cassette %s", req.Method, req.URL.Path, r.cassette.path)
+	}
+
+	body := []byte(found.Response.Body)
+	if found.Response.BodyEncoding == "base64" {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(found.Response.Body); err != nil {
+			return nil, fmt.Errorf("decoding body of %s %s in cassette: %w", req.Method, req.URL.Path, err)
+		}
+	}
+	header := found.Response.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", found.Response.StatusCode, http.StatusText(found.Response.StatusCode)),
+		StatusCode:    found.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+func matchRequest(recorded Request, req *http.Request) bool {
+	if recorded.Method != req.Method {
+		return false
+	}
+	u, err := url.Parse(recorded.URL)
+	if err != nil {
+		return false
+	}
+	return strings.TrimRight(u.Path, "/") == strings.TrimRight(req.URL.Path, "/")
+}
+
+func redactHeader(header http.Header) http.Header {
+	h := header.Clone()
+	for _, k := range redactedHeaders {
+		if _, ok := h[k]; ok {
+			h.Set(k, Redacted)
+		}
+	}
+	return h
+}
+
+// redactBody replaces the value of the secret fields of json and form bodies.
+func redactBody(contentType string, body []byte) string {
+	switch {
+	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return string(body)
+		}
+		for k := range values {
+			if redactedFields[k] {
+				values.Set(k, Redacted)
+			}
+		}
+		return values.Encode()
+
+	case strings.Contains(contentType, "json"):
+		var v interface{}
+		if err := json.Unmarshal(body, &v); err != nil {
+			return string(body)
+		}
+		redacted, err := json.Marshal(redactJSON(v))
+		if err != nil {
+			return string(body)
+		}
+		return string(redacted)
+	}
+	return string(body)
+}
+
+func redactJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, val := range v {
+			if redactedFields[k] {
+				v[k] = Redacted
+			} else {
+				v[k] = redactJSON(val)
+			}
+		}
+	case []interface{}:
+		for i, val := range v {
+			v[i] = redactJSON(val)
+		}
+	}
+	return v
+}
+
+// errorTransport fails every request, reporting
+// an invalid cassette configuration.
+type errorTransport struct {
+	err error
+}
+
+func (t errorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	return nil, t.err
+}
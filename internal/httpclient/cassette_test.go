@@ -0,0 +1,109 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package httpclient
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCassetteRecordReplay(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"access_token":"secret-token","token_type":"Bearer","expires_in":300}`))
+		case "/things":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":"thing-id","name":"thing"}`))
+		case "/binary":
+			w.Write([]byte{0x00, 0xff, 0xfe})
+		}
+	}))
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	t.Setenv(CassetteEnv, path)
+
+	// Record
+	t.Setenv(CassetteModeEnv, CassetteRecord)
+	cl := New()
+	form := url.Values{"client_id": {"client"}, "client_secret": {"client-secret"}}
+	res, err := cl.PostForm(srv.URL+"/token", form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/things", nil)
+	req.Header.Set("Authorization", "Bearer secret-token")
+	if res, err = cl.Do(req); err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res, err = cl.Get(srv.URL + "/binary"); err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	srv.Close()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"client-secret", "secret-token"} {
+		if strings.Contains(string(content), secret) {
+			t.Errorf("Cassette contains the secret '%s':\n%s", secret, content)
+		}
+	}
+
+	// Replay from file, without network access
+	cassettesMutex.Lock()
+	delete(cassettes, path)
+	cassettesMutex.Unlock()
+	t.Setenv(CassetteModeEnv, CassetteReplay)
+	cl = New()
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/things", want: `{"id":"thing-id","name":"thing"}`},
+		{path: "/binary", want: "\x00\xff\xfe"},
+		// Interactions already replayed are served again
+		{path: "/things", want: `{"id":"thing-id","name":"thing"}`},
+	}
+	for _, tt := range tests {
+		res, err := cl.Get(srv.URL + tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		if string(body) != tt.want {
+			t.Errorf("Expected body %q for %s, but got %q", tt.want, tt.path, body)
+		}
+	}
+
+	if _, err := cl.Get(srv.URL + "/unknown"); err == nil {
+		t.Error("Expected an error replaying a request not in the cassette")
+	}
+}
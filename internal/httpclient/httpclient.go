@@ -0,0 +1,264 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package httpclient provides the HTTP client shared by all the
+// Arduino Cloud API clients. Requests are retried with an exponential
+// backoff on transient failures, honoring the Retry-After header.
+package httpclient
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultTimeout is the default timeout of a single request attempt.
+	DefaultTimeout = 60 * time.Second
+	// DefaultMaxRetries is the default number of retries of a failed request.
+	DefaultMaxRetries = 3
+
+	defaultBaseDelay = 500 * time.Millisecond
+	defaultMaxDelay  = 30 * time.Second
+	// maxRetryAfter caps the delay requested by the server with Retry-After.
+	maxRetryAfter = 2 * time.Minute
+)
+
+var (
+	mutex      sync.RWMutex
+	timeout    = DefaultTimeout
+	maxRetries = DefaultMaxRetries
+)
+
+// Configure sets the timeout and the maximum number of retries
+// used by the clients returned by New.
+// A zero timeout means no timeout.
+func Configure(t time.Duration, retries int) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	timeout = t
+	maxRetries = retries
+}
+
+type attemptTimeoutKey struct{}
+
+// WithAttemptTimeout returns a copy of ctx overriding, for the requests
+// performed with it, the timeout of a single attempt. A zero timeout means no timeout.
+// It's meant for requests whose duration depends on the size of their body,
+// like firmware uploads, that can't be bound by the global timeout.
+func WithAttemptTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, attemptTimeoutKey{}, timeout)
+}
+
+// New returns an http client using a retrying transport
+// configured with the global settings.
+// If a cassette is selected with CassetteEnv, requests
+// are recorded in or replayed from it.
+func New() *http.Client {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return &http.Client{
+		Transport: wrapCassette(&Transport{
+			Base:       http.DefaultTransport,
+			Timeout:    timeout,
+			MaxRetries: maxRetries,
+			BaseDelay:  defaultBaseDelay,
+			MaxDelay:   defaultMaxDelay,
+		}),
+	}
+}
+
+// Transport is an http.RoundTripper that retries requests
+// failed because of network errors or transient server errors.
+//
+// Requests rejected with 429 Too Many Requests are always retried,
+// since the server didn't process them. Network errors and 5xx errors
+// are only retried for idempotent requests: GET, HEAD, OPTIONS, PUT,
+// DELETE and requests with an Idempotency-Key header.
+// Requests whose body cannot be rewound are never retried.
+type Transport struct {
+	Base       http.RoundTripper
+	Timeout    time.Duration // Timeout of a single attempt, zero means no timeout.
+	MaxRetries int
+	BaseDelay  time.Duration // Delay before the first retry, doubled at each retry.
+	MaxDelay   time.Duration
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		r, err := rewind(req, attempt)
+		if err != nil {
+			return nil, err
+		}
+		res, cancel, err := t.try(r)
+
+		if attempt >= t.MaxRetries || !shouldRetry(req, res, err) {
+			if cancel != nil {
+				if res != nil {
+					res.Body = &cancelBody{ReadCloser: res.Body, cancel: cancel}
+				} else {
+					cancel()
+				}
+			}
+			return res, err
+		}
+
+		delay := t.backoff(attempt)
+		if res != nil {
+			if d, ok := retryAfter(res); ok {
+				delay = d
+			}
+			logrus.Warnf("Request %s %s failed with status %s, retrying in %v", req.Method, req.URL.Path, res.Status, delay)
+			// Drain the body to allow the connection reuse
+			io.Copy(io.Discard, io.LimitReader(res.Body, 4096))
+			res.Body.Close()
+		} else {
+			logrus.Warnf("Request %s %s failed: %v, retrying in %v", req.Method, req.URL.Path, err, delay)
+		}
+		if cancel != nil {
+			cancel()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// try performs a single attempt of the request, applying the timeout
+// set in the transport or, if any, the one set in the request context.
+// The returned cancel function releases the timeout context, if any.
+func (t *Transport) try(req *http.Request) (*http.Response, context.CancelFunc, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	timeout := t.Timeout
+	if d, ok := req.Context().Value(attemptTimeoutKey{}).(time.Duration); ok {
+		timeout = d
+	}
+	if timeout <= 0 {
+		res, err := base.RoundTrip(req)
+		return res, nil, err
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	res, err := base.RoundTrip(req.WithContext(ctx))
+	return res, cancel, err
+}
+
+func (t *Transport) backoff(attempt int) time.Duration {
+	delay := t.BaseDelay << attempt
+	if delay <= 0 || (t.MaxDelay > 0 && delay > t.MaxDelay) {
+		delay = t.MaxDelay
+	}
+	// Add up to 20% of jitter to spread the retries of concurrent requests
+	if delay > 0 {
+		delay += time.Duration(rand.Int63n(int64(delay)/5 + 1))
+	}
+	return delay
+}
+
+// rewind returns the request to be sent at the passed attempt,
+// with a fresh copy of the body.
+func rewind(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 0 || req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	r := req.Clone(req.Context())
+	r.Body = body
+	return r, nil
+}
+
+func shouldRetry(req *http.Request, res *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	if err != nil {
+		return isIdempotent(req)
+	}
+	switch res.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return isIdempotent(req)
+	}
+	return false
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+// retryAfter parses the Retry-After header of the response,
+// expressed either in seconds or as an http date.
+func retryAfter(res *http.Response) (time.Duration, bool) {
+	header := res.Header.Get("Retry-After")
+	if header == "" {
+		return 0, false
+	}
+	var delay time.Duration
+	if secs, err := strconv.Atoi(header); err == nil {
+		delay = time.Duration(secs) * time.Second
+	} else if date, err := http.ParseTime(header); err == nil {
+		delay = time.Until(date)
+	} else {
+		return 0, false
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	if delay > maxRetryAfter {
+		delay = maxRetryAfter
+	}
+	return delay, true
+}
+
+// cancelBody releases the timeout context of a request
+// once its response body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,215 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestClient(retries int) *http.Client {
+	return &http.Client{
+		Transport: &Transport{
+			Base:       http.DefaultTransport,
+			MaxRetries: retries,
+			BaseDelay:  time.Millisecond,
+			MaxDelay:   10 * time.Millisecond,
+		},
+	}
+}
+
+func TestTransportRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		header     http.Header
+		statuses   []int
+		wantStatus int
+		wantCalls  int
+	}{
+		{
+			name:       "get-retried-on-server-error",
+			method:     http.MethodGet,
+			statuses:   []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK},
+			wantStatus: http.StatusOK,
+			wantCalls:  3,
+		},
+		{
+			name:       "post-not-retried-on-server-error",
+			method:     http.MethodPost,
+			statuses:   []int{http.StatusServiceUnavailable, http.StatusOK},
+			wantStatus: http.StatusServiceUnavailable,
+			wantCalls:  1,
+		},
+		{
+			name:       "post-with-idempotency-key-retried",
+			method:     http.MethodPost,
+			header:     http.Header{"Idempotency-Key": {"key"}},
+			statuses:   []int{http.StatusServiceUnavailable, http.StatusOK},
+			wantStatus: http.StatusOK,
+			wantCalls:  2,
+		},
+		{
+			name:       "post-retried-on-too-many-requests",
+			method:     http.MethodPost,
+			statuses:   []int{http.StatusTooManyRequests, http.StatusOK},
+			wantStatus: http.StatusOK,
+			wantCalls:  2,
+		},
+		{
+			name:       "client-error-not-retried",
+			method:     http.MethodGet,
+			statuses:   []int{http.StatusNotFound, http.StatusOK},
+			wantStatus: http.StatusNotFound,
+			wantCalls:  1,
+		},
+		{
+			name:       "retries-exhausted",
+			method:     http.MethodGet,
+			statuses:   []int{500, 500, 500, 500, 500},
+			wantStatus: http.StatusInternalServerError,
+			wantCalls:  4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if string(body) != "payload" {
+					t.Errorf("Expected body 'payload' at call %d, but got '%s'", calls, body)
+				}
+				w.WriteHeader(tt.statuses[calls])
+				calls++
+			}))
+			defer srv.Close()
+
+			req, err := http.NewRequest(tt.method, srv.URL, strings.NewReader("payload"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+			res, err := newTestClient(3).Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+
+			if res.StatusCode != tt.wantStatus {
+				t.Errorf("Expected status %d, but got %d", tt.wantStatus, res.StatusCode)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("Expected %d calls, but got %d", tt.wantCalls, calls)
+			}
+		})
+	}
+}
+
+func TestTransportRetryAfter(t *testing.T) {
+	calls := 0
+	var first time.Time
+	var delay time.Duration
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			first = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		delay = time.Since(first)
+	}))
+	defer srv.Close()
+
+	res, err := newTestClient(1).Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if calls != 2 {
+		t.Fatalf("Expected 2 calls, but got %d", calls)
+	}
+	if delay < time.Second {
+		t.Errorf("Expected the retry to honor Retry-After, but it was sent after %v", delay)
+	}
+}
+
+func TestTransportTimeout(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	cl := newTestClient(1)
+	cl.Transport.(*Transport).Timeout = 50 * time.Millisecond
+	res, err := cl.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "ok" || calls != 2 {
+		t.Errorf("Expected the timed out request to be retried, got body '%s' after %d calls", body, calls)
+	}
+}
+
+func TestTransportAttemptTimeoutOverride(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	cl := newTestClient(0)
+	cl.Transport.(*Transport).Timeout = 50 * time.Millisecond
+	req, err := http.NewRequestWithContext(WithAttemptTimeout(context.Background(), 0), http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := cl.Do(req)
+	if err != nil {
+		t.Fatalf("Expected the request without timeout to succeed, got %v", err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "ok" || calls != 1 {
+		t.Errorf("Expected a single successful call, got body '%s' after %d calls", body, calls)
+	}
+}
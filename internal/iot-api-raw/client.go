@@ -26,6 +26,7 @@ import (
 	"net/http"
 
 	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/arduino/arduino-cloud-cli/internal/httpclient"
 	"github.com/arduino/arduino-cloud-cli/internal/iot"
 	"github.com/arduino/go-paths-helper"
 	"golang.org/x/oauth2"
@@ -39,10 +40,10 @@ type IoTApiRawClient struct {
 }
 
 func NewClient(credentials *config.Credentials) *IoTApiRawClient {
-	host := iot.GetArduinoAPIBaseURL()
+	host := iot.GetArduinoAPIBaseURLFor(credentials)
 	tokenSource := iot.NewUserTokenSource(credentials.Client, credentials.Secret, host, credentials.Organization)
 	return &IoTApiRawClient{
-		client:       &http.Client{},
+		client:       httpclient.New(),
 		src:          tokenSource,
 		host:         host,
 		organization: credentials.Organization,
@@ -18,11 +18,18 @@
 package iot
 
 import (
+	"bytes"
 	"context"
 	"fmt"
-	"os"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/arduino/arduino-cloud-cli/internal/httpclient"
 	iotclient "github.com/arduino/iot-client-go/v3"
 	"golang.org/x/oauth2"
 )
@@ -33,13 +40,18 @@ var ErrOtaAlreadyInProgress = fmt.Errorf("ota already in progress")
 type Client struct {
 	api   *iotclient.APIClient
 	token oauth2.TokenSource
+
+	// Used for the requests not supported by api
+	http         *http.Client
+	host         string
+	organization string
 }
 
 // NewClient returns a new client implementing the Client interface.
 // It needs client Credentials for cloud authentication.
 func NewClient(cred *config.Credentials) (*Client, error) {
 	cl := &Client{}
-	err := cl.setup(cred.Client, cred.Secret, cred.Organization)
+	err := cl.setup(cred.Client, cred.Secret, cred.Organization, GetArduinoAPIBaseURLFor(cred))
 	if err != nil {
 		err = fmt.Errorf("instantiate new iot client: %w", err)
 		return nil, err
@@ -195,6 +207,27 @@ func (cl *Client) DeviceShow(ctx context.Context, id string) (*iotclient.Arduino
 	return dev, nil
 }
 
+// DeviceStatusEvents retrieves the connection and disconnection events
+// of a device, starting from the passed time. At most limit events are returned.
+func (cl *Client) DeviceStatusEvents(ctx context.Context, id string, start time.Time, limit int32) ([]iotclient.ArduinoDevicev2StatusEvent, error) {
+	ctx, err := ctxWithToken(ctx, cl.token)
+	if err != nil {
+		return nil, err
+	}
+
+	req := cl.api.DevicesV2API.DevicesV2GetStatusEvents(ctx, id)
+	req = req.Start(start.UTC().Format(time.RFC3339Nano)).Limit(limit)
+	events, _, err := cl.api.DevicesV2API.DevicesV2GetStatusEventsExecute(req)
+	if err != nil {
+		err = fmt.Errorf("retrieving device status events, %w", errorDetail(err))
+		return nil, err
+	}
+	if events == nil {
+		return nil, nil
+	}
+	return events.Events, nil
+}
+
 // DeviceNetworkCredentials allows to retrieve a specific device network credentials configuration options
 func (cl *Client) DeviceNetworkCredentials(ctx context.Context, deviceType, connection string) ([]iotclient.ArduinoCredentialsv1, error) {
 	ctx, err := ctxWithToken(ctx, cl.token)
@@ -213,24 +246,60 @@ func (cl *Client) DeviceNetworkCredentials(ctx context.Context, deviceType, conn
 }
 
 // DeviceOTA performs an OTA upload request to Arduino IoT Cloud, passing
-// the ID of the device to be updated and the actual file containing the OTA firmware.
-func (cl *Client) DeviceOTA(ctx context.Context, id string, file *os.File, expireMins int) error {
-	ctx, err := ctxWithToken(ctx, cl.token)
+// the ID of the device to be updated and the OTA firmware.
+// The request body is built in memory, so that the upload
+// can be retried when it is rejected with 429 Too Many Requests.
+func (cl *Client) DeviceOTA(ctx context.Context, id string, firmware io.Reader, expireMins int) error {
+	token, err := GetToken(cl.token)
 	if err != nil {
 		return err
 	}
 
-	req := cl.api.DevicesV2OtaAPI.DevicesV2OtaUpload(ctx, id)
-	req = req.ExpireInMins(int32(expireMins))
-	req = req.Async(true)
-	req = req.OtaFile(file)
-	_, resp, err := cl.api.DevicesV2OtaAPI.DevicesV2OtaUploadExecute(req)
+	// iot-client-go only accepts firmwares stored in files,
+	// so the multipart request is built here
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	if err := form.WriteField("async", "true"); err != nil {
+		return err
+	}
+	if err := form.WriteField("expire_in_mins", strconv.Itoa(expireMins)); err != nil {
+		return err
+	}
+	fw, err := form.CreateFormFile("ota_file", "firmware.ota")
 	if err != nil {
-		// 409 (Conflict) is the status code for an already existing OTA in progress for the same device. Handling it in a different way.
-		if resp != nil && resp.StatusCode == 409 {
-			return ErrOtaAlreadyInProgress
-		}
-		return fmt.Errorf("uploading device ota: %w", errorDetail(err))
+		return err
+	}
+	if _, err := io.Copy(fw, firmware); err != nil {
+		return fmt.Errorf("reading ota firmware: %w", err)
+	}
+	if err := form.Close(); err != nil {
+		return err
+	}
+
+	// The upload of large firmwares on slow links can exceed the global timeout
+	ctx = httpclient.WithAttemptTimeout(ctx, 0)
+	endpoint := cl.host + "/iot/v2/devices/" + url.PathEscape(id) + "/ota"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body.Bytes()))
+	if err != nil {
+		return fmt.Errorf("uploading device ota: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	if cl.organization != "" {
+		req.Header.Set("X-Organization", cl.organization)
+	}
+	resp, err := cl.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("uploading device ota: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// 409 (Conflict) is the status code for an already existing OTA in progress for the same device. Handling it in a different way.
+	if resp.StatusCode == http.StatusConflict {
+		return ErrOtaAlreadyInProgress
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("uploading device ota: %s%s", resp.Status, responseDetail(resp.Body))
 	}
 	return nil
 }
@@ -547,6 +616,23 @@ func (cl *Client) DashboardDelete(ctx context.Context, id string) error {
 	return nil
 }
 
+// DashboardUpdate updates a dashboard on Arduino IoT Cloud,
+// replacing its widgets with the passed ones.
+func (cl *Client) DashboardUpdate(ctx context.Context, id string, dashboard *iotclient.Dashboardv3) (*iotclient.ArduinoDashboardv3, error) {
+	ctx, err := ctxWithToken(ctx, cl.token)
+	if err != nil {
+		return nil, err
+	}
+
+	req := cl.api.DashboardsV3API.DashboardsV3Update(ctx, id)
+	req = req.Dashboardv3(*dashboard)
+	updated, _, err := cl.api.DashboardsV3API.DashboardsV3UpdateExecute(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", "updating dashboard", errorDetail(err))
+	}
+	return updated, nil
+}
+
 func (cl *Client) PropertyShow(ctx context.Context, thingId, variableId string) (*iotclient.ArduinoProperty, error) {
 	ctx, err := ctxWithToken(ctx, cl.token)
 	if err != nil {
@@ -562,6 +648,110 @@ func (cl *Client) PropertyShow(ctx context.Context, thingId, variableId string)
 	return property, nil
 }
 
+// PropertyList returns the properties of a thing.
+func (cl *Client) PropertyList(ctx context.Context, thingId string) ([]iotclient.ArduinoProperty, error) {
+	ctx, err := ctxWithToken(ctx, cl.token)
+	if err != nil {
+		return nil, err
+	}
+
+	req := cl.api.PropertiesV2API.PropertiesV2List(ctx, thingId)
+	properties, _, err := cl.api.PropertiesV2API.PropertiesV2ListExecute(req)
+	if err != nil {
+		err = fmt.Errorf("listing properties: %w", errorDetail(err))
+		return nil, err
+	}
+	return properties, nil
+}
+
+// PropertyCreate adds a new property to a thing.
+func (cl *Client) PropertyCreate(ctx context.Context, thingId string, property *iotclient.Property) (*iotclient.ArduinoProperty, error) {
+	ctx, err := ctxWithToken(ctx, cl.token)
+	if err != nil {
+		return nil, err
+	}
+
+	req := cl.api.PropertiesV2API.PropertiesV2Create(ctx, thingId)
+	req = req.Property(*property)
+	newProperty, _, err := cl.api.PropertiesV2API.PropertiesV2CreateExecute(req)
+	if err != nil {
+		err = fmt.Errorf("creating property: %w", errorDetail(err))
+		return nil, err
+	}
+	return newProperty, nil
+}
+
+// PropertyUpdate updates a property of a thing.
+func (cl *Client) PropertyUpdate(ctx context.Context, thingId, propertyId string, property *iotclient.Property) (*iotclient.ArduinoProperty, error) {
+	ctx, err := ctxWithToken(ctx, cl.token)
+	if err != nil {
+		return nil, err
+	}
+
+	req := cl.api.PropertiesV2API.PropertiesV2Update(ctx, thingId, propertyId)
+	req = req.Property(*property)
+	updated, _, err := cl.api.PropertiesV2API.PropertiesV2UpdateExecute(req)
+	if err != nil {
+		err = fmt.Errorf("updating property: %w", errorDetail(err))
+		return nil, err
+	}
+	return updated, nil
+}
+
+// PropertyDelete deletes a property of a thing.
+func (cl *Client) PropertyDelete(ctx context.Context, thingId, propertyId string) error {
+	ctx, err := ctxWithToken(ctx, cl.token)
+	if err != nil {
+		return err
+	}
+
+	req := cl.api.PropertiesV2API.PropertiesV2Delete(ctx, thingId, propertyId)
+	_, err = cl.api.PropertiesV2API.PropertiesV2DeleteExecute(req)
+	if err != nil {
+		err = fmt.Errorf("deleting property: %w", errorDetail(err))
+		return err
+	}
+	return nil
+}
+
+// PropertyPublish publishes a new value of a property of a thing.
+func (cl *Client) PropertyPublish(ctx context.Context, thingId, propertyId string, value interface{}) error {
+	ctx, err := ctxWithToken(ctx, cl.token)
+	if err != nil {
+		return err
+	}
+
+	req := cl.api.PropertiesV2API.PropertiesV2Publish(ctx, thingId, propertyId)
+	req = req.PropertyValue(iotclient.PropertyValue{Value: value})
+	_, err = cl.api.PropertiesV2API.PropertiesV2PublishExecute(req)
+	if err != nil {
+		err = fmt.Errorf("publishing property value: %w", errorDetail(err))
+		return err
+	}
+	return nil
+}
+
+// PropertyTimeseries returns the values of a property in the [from, to) time range.
+// If interval is greater than zero, values are aggregated over intervals of the passed seconds.
+func (cl *Client) PropertyTimeseries(ctx context.Context, thingId, propertyId string, from, to time.Time, interval int32) (*iotclient.ArduinoTimeseriesmedia, error) {
+	ctx, err := ctxWithToken(ctx, cl.token)
+	if err != nil {
+		return nil, err
+	}
+
+	req := cl.api.PropertiesV2API.PropertiesV2Timeseries(ctx, thingId, propertyId)
+	req = req.From(from.UTC().Format(time.RFC3339)).To(to.UTC().Format(time.RFC3339))
+	if interval > 0 {
+		req = req.Interval(interval)
+	}
+	series, _, err := cl.api.PropertiesV2API.PropertiesV2TimeseriesExecute(req)
+	if err != nil {
+		err = fmt.Errorf("retrieving property timeseries: %w", errorDetail(err))
+		return nil, err
+	}
+	return series, nil
+}
+
 // TemplateApply apply a given template, creating associated resources like things and dashboards.
 func (cl *Client) TemplateApply(ctx context.Context, id, thingId, prefix, deviceId string, credentials map[string]string) (*iotclient.ArduinoTemplate, error) {
 	ctx, err := ctxWithToken(ctx, cl.token)
@@ -589,13 +779,15 @@ func (cl *Client) TemplateApply(ctx context.Context, id, thingId, prefix, device
 	return dev, nil
 }
 
-func (cl *Client) setup(client, secret, organizationId string) error {
-	baseURL := GetArduinoAPIBaseURL()
-
+func (cl *Client) setup(client, secret, organizationId, baseURL string) error {
 	// Configure a token source given the user's credentials.
 	cl.token = NewUserTokenSource(client, secret, baseURL, organizationId)
 
 	config := iotclient.NewConfiguration()
+	config.HTTPClient = httpclient.New()
+	cl.http = config.HTTPClient
+	cl.host = baseURL
+	cl.organization = organizationId
 	if organizationId != "" {
 		config.AddDefaultHeader("X-Organization", organizationId)
 	}
@@ -18,10 +18,18 @@
 package iot
 
 import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/arduino/arduino-cloud-cli/internal/httpclient"
 	iotclient "github.com/arduino/iot-client-go/v3"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
 )
 
 func TestJSON_UnknownFields_areAccepted(t *testing.T) {
@@ -56,3 +64,66 @@ func TestJSON_UnknownFields_areAccepted(t *testing.T) {
 	}
 	assert.Equal(t, 3, len(cert.AdditionalProperties))
 }
+
+func TestDeviceOTA(t *testing.T) {
+	var uploaded []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/iot/v2/devices/dev-1/ota", r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		assert.Equal(t, "org", r.Header.Get("X-Organization"))
+		assert.Equal(t, "true", r.FormValue("async"))
+		assert.Equal(t, "10", r.FormValue("expire_in_mins"))
+		file, _, err := r.FormFile("ota_file")
+		if !assert.NoError(t, err) {
+			return
+		}
+		content, _ := io.ReadAll(file)
+		uploaded = append(uploaded, string(content))
+		if len(uploaded) > 1 {
+			w.WriteHeader(http.StatusConflict)
+		}
+	}))
+	defer srv.Close()
+
+	cl := &Client{
+		token:        oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "token"}),
+		http:         srv.Client(),
+		host:         srv.URL,
+		organization: "org",
+	}
+	err := cl.DeviceOTA(context.Background(), "dev-1", strings.NewReader("firmware"), 10)
+	assert.NoError(t, err)
+	err = cl.DeviceOTA(context.Background(), "dev-1", strings.NewReader("firmware"), 10)
+	assert.ErrorIs(t, err, ErrOtaAlreadyInProgress)
+	assert.Equal(t, []string{"firmware", "firmware"}, uploaded)
+}
+
+func TestDeviceOTARetried(t *testing.T) {
+	var uploaded []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("ota_file")
+		if !assert.NoError(t, err) {
+			return
+		}
+		content, _ := io.ReadAll(file)
+		uploaded = append(uploaded, string(content))
+		if len(uploaded) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer srv.Close()
+
+	cl := &Client{
+		token: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "token"}),
+		http: &http.Client{Transport: &httpclient.Transport{
+			Base:       srv.Client().Transport,
+			MaxRetries: 1,
+			BaseDelay:  time.Millisecond,
+			MaxDelay:   time.Millisecond,
+		}},
+		host: srv.URL,
+	}
+	err := cl.DeviceOTA(context.Background(), "dev-1", strings.NewReader("firmware"), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"firmware", "firmware"}, uploaded)
+}
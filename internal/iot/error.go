@@ -20,6 +20,7 @@ package iot
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 
 	iotclient "github.com/arduino/iot-client-go/v3"
 )
@@ -47,3 +48,17 @@ func errorDetail(err error) error {
 	}
 	return fmt.Errorf("%w: %v", err, detail)
 }
+
+// responseDetail returns the detail contained in the
+// body of an error response, formatted to be appended
+// to an error message. Returns an empty string if
+// the body contains no detail.
+func responseDetail(body io.Reader) string {
+	var e struct {
+		Detail string `json:"detail"`
+	}
+	if err := json.NewDecoder(body).Decode(&e); err != nil || e.Detail == "" {
+		return ""
+	}
+	return ": " + e.Detail
+}
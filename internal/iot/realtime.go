@@ -0,0 +1,122 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package iot
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/arduino/arduino-cloud-cli/internal/realtime"
+)
+
+// RealtimeURLEnv is the environment variable that can be used
+// to override the url of the realtime broker.
+const RealtimeURLEnv = "IOT_REALTIME_URL"
+
+// realtimeHosts maps the hosts of Arduino Cloud API to the hosts
+// of the corresponding realtime brokers and web apps.
+// The brokers only accept the websocket connections from the web apps.
+var realtimeHosts = map[string]struct{ broker, origin string }{
+	"api2.arduino.cc":    {"wss.iot.arduino.cc:8443", "https://cloud.arduino.cc"},
+	"api-dev.arduino.cc": {"wss.iot.oniudra.cc:8443", "https://cloud.oniudra.cc"},
+	"api2.oniudra.cc":    {"wss.iot.oniudra.cc:8443", "https://cloud.oniudra.cc"},
+}
+
+// realtimeURL returns the websocket url of the realtime broker
+// of the Arduino Cloud API at the passed base url.
+// Unknown hosts, like local mock servers, are expected to serve
+// the broker on the '/mqtt' path of the API host.
+func realtimeURL(baseURL string) string {
+	if u := os.Getenv(RealtimeURLEnv); u != "" {
+		return u
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return baseURL
+	}
+	if h, ok := realtimeHosts[u.Host]; ok {
+		return "wss://" + h.broker + "/mqtt"
+	}
+	scheme := "wss"
+	if u.Scheme == "http" {
+		scheme = "ws"
+	}
+	return scheme + "://" + u.Host + "/mqtt"
+}
+
+// realtimeOrigin returns the origin of the websocket connections to the
+// realtime broker of the Arduino Cloud API at the passed base url.
+// Unknown hosts get the origin of the API itself.
+func realtimeOrigin(baseURL string) string {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return ""
+	}
+	if h, ok := realtimeHosts[u.Host]; ok {
+		return h.origin
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// RealtimeConnect connects to the realtime broker of Arduino Cloud,
+// authenticating with the token of the client.
+func (cl *Client) RealtimeConnect(ctx context.Context) (*realtime.Conn, error) {
+	token, err := GetToken(cl.token)
+	if err != nil {
+		return nil, err
+	}
+	userID, err := tokenUserID(token.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	return realtime.Dial(ctx, realtimeURL(cl.host), realtime.Options{
+		ClientID: fmt.Sprintf("%s:%d", userID, time.Now().UnixNano()),
+		Username: userID,
+		Password: token.AccessToken,
+		Origin:   realtimeOrigin(cl.host),
+	})
+}
+
+// tokenUserID extracts the ID of the user from the claims of an access token.
+func tokenUserID(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", errors.New("access token is not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("decoding access token: %w", err)
+	}
+	var claims map[string]interface{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", fmt.Errorf("decoding access token: %w", err)
+	}
+	for _, claim := range []string{"http://arduino.cc/id", "sub"} {
+		if id, ok := claims[claim].(string); ok && id != "" {
+			return id, nil
+		}
+	}
+	return "", errors.New("access token doesn't contain the user ID")
+}
@@ -0,0 +1,60 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package iot
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRealtimeURL(t *testing.T) {
+	t.Setenv(RealtimeURLEnv, "")
+	assert.Equal(t, "wss://wss.iot.arduino.cc:8443/mqtt", realtimeURL("https://api2.arduino.cc"))
+	assert.Equal(t, "wss://wss.iot.oniudra.cc:8443/mqtt", realtimeURL("https://api-dev.arduino.cc"))
+	assert.Equal(t, "ws://127.0.0.1:8080/mqtt", realtimeURL("http://127.0.0.1:8080"))
+
+	t.Setenv(RealtimeURLEnv, "wss://broker.example.com/mqtt")
+	assert.Equal(t, "wss://broker.example.com/mqtt", realtimeURL("https://api2.arduino.cc"))
+}
+
+func TestRealtimeOrigin(t *testing.T) {
+	assert.Equal(t, "https://cloud.arduino.cc", realtimeOrigin("https://api2.arduino.cc"))
+	assert.Equal(t, "https://cloud.oniudra.cc", realtimeOrigin("https://api-dev.arduino.cc/"))
+	assert.Equal(t, "http://127.0.0.1:8080", realtimeOrigin("http://127.0.0.1:8080"))
+}
+
+func TestTokenUserID(t *testing.T) {
+	jwt := func(claims string) string {
+		return "header." + base64.RawURLEncoding.EncodeToString([]byte(claims)) + ".signature"
+	}
+
+	id, err := tokenUserID(jwt(`{"sub": "client", "http://arduino.cc/id": "user-id"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "user-id", id)
+
+	id, err = tokenUserID(jwt(`{"sub": "client"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "client", id)
+
+	_, err = tokenUserID(jwt(`{}`))
+	assert.Error(t, err)
+	_, err = tokenUserID("opaque-token")
+	assert.Error(t, err)
+}
@@ -25,7 +25,10 @@ import (
 	"os"
 	"strings"
 
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/arduino/arduino-cloud-cli/internal/httpclient"
 	iotclient "github.com/arduino/iot-client-go/v3"
+	"github.com/sirupsen/logrus"
 	"golang.org/x/oauth2"
 	cc "golang.org/x/oauth2/clientcredentials"
 )
@@ -38,7 +41,19 @@ func GetArduinoAPIBaseURL() string {
 	return baseURL
 }
 
-// Build a new token source to forge api JWT tokens based on provided credentials
+// GetArduinoAPIBaseURLFor returns the base URL of Arduino Cloud API
+// to be used with the passed credentials. The IOT_API_URL environment
+// variable has the highest priority, followed by the base URL of the credentials.
+func GetArduinoAPIBaseURLFor(cred *config.Credentials) string {
+	if os.Getenv("IOT_API_URL") == "" && cred.BaseURL != "" {
+		return strings.TrimRight(cred.BaseURL, "/")
+	}
+	return GetArduinoAPIBaseURL()
+}
+
+// Build a new token source to forge api JWT tokens based on provided credentials.
+// Tokens are cached on disk and shared between CLI invocations,
+// unless the NoTokenCacheEnv environment variable is set.
 func NewUserTokenSource(client, secret, baseURL, organizationId string) oauth2.TokenSource {
 	// We need to pass the additional "audience" var to request an access token.
 	additionalValues := url.Values{}
@@ -56,7 +71,19 @@ func NewUserTokenSource(client, secret, baseURL, organizationId string) oauth2.T
 
 	// Retrieve a token source that allows to retrieve tokens
 	// with an automatic refresh mechanism.
-	return config.TokenSource(context.Background())
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, httpclient.New())
+	src := config.TokenSource(ctx)
+	// Tokens are not cached while using cassettes, so that
+	// token requests are always recorded and replayed
+	if os.Getenv(NoTokenCacheEnv) != "" || httpclient.CassetteMode() != "" {
+		return src
+	}
+	path, err := tokenCachePath()
+	if err != nil {
+		logrus.Warnf("Token cache disabled: %v", err)
+		return src
+	}
+	return oauth2.ReuseTokenSource(nil, newCachedTokenSource(path, client, secret, baseURL, organizationId, src))
 }
 
 func ctxWithToken(ctx context.Context, src oauth2.TokenSource) (context.Context, error) {
@@ -0,0 +1,232 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package iot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// NoTokenCacheEnv is the environment variable that,
+	// if set, disables the persistent token cache.
+	NoTokenCacheEnv = config.EnvPrefix + "_NO_TOKEN_CACHE"
+
+	tokenCacheDir  = "arduino-cloud-cli"
+	tokenCacheFile = "tokens.json"
+	// expiryMargin is subtracted from the token expiration
+	// to avoid using tokens that are about to expire.
+	expiryMargin = time.Minute
+)
+
+// tokenCacheMutex serializes the access to the cache file
+// by the token sources of the same process.
+var tokenCacheMutex sync.Mutex
+
+// cachedToken is a token saved in the cache file.
+type cachedToken struct {
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type"`
+	Expiry      time.Time `json:"expiry"`
+	// SecretHash allows to invalidate the token when the secret changes.
+	SecretHash string `json:"secret_hash"`
+}
+
+// cachedTokenSource is a token source that shares its tokens
+// between CLI invocations, saving them in a cache file.
+type cachedTokenSource struct {
+	path       string
+	key        string
+	secretHash string
+	src        oauth2.TokenSource
+}
+
+// newCachedTokenSource wraps the passed token source with the cache file at path.
+func newCachedTokenSource(path, client, secret, baseURL, organizationId string, src oauth2.TokenSource) oauth2.TokenSource {
+	return &cachedTokenSource{
+		path:       path,
+		key:        tokenCacheKey(client, baseURL, organizationId),
+		secretHash: hash(secret),
+		src:        src,
+	}
+}
+
+// Token returns the cached token if it's still valid,
+// otherwise it retrieves a new token and saves it in the cache.
+func (s *cachedTokenSource) Token() (*oauth2.Token, error) {
+	tokenCacheMutex.Lock()
+	defer tokenCacheMutex.Unlock()
+
+	cache, err := readTokenCache(s.path)
+	if err != nil {
+		logrus.Warnf("Ignoring token cache: %v", err)
+		cache = make(map[string]cachedToken)
+	}
+	if t, ok := cache[s.key]; ok && t.SecretHash == s.secretHash && time.Now().Add(expiryMargin).Before(t.Expiry) {
+		logrus.Info("Using cached token")
+		return &oauth2.Token{AccessToken: t.AccessToken, TokenType: t.TokenType, Expiry: t.Expiry}, nil
+	}
+
+	token, err := s.src.Token()
+	if err != nil {
+		return nil, err
+	}
+	if token.Expiry.IsZero() {
+		return token, nil
+	}
+
+	// Drop expired tokens while saving the new one
+	for k, t := range cache {
+		if time.Now().After(t.Expiry) {
+			delete(cache, k)
+		}
+	}
+	cache[s.key] = cachedToken{
+		AccessToken: token.AccessToken,
+		TokenType:   token.TokenType,
+		Expiry:      token.Expiry,
+		SecretHash:  s.secretHash,
+	}
+	if err := writeTokenCache(s.path, cache); err != nil {
+		logrus.Warnf("Cannot save token in cache: %v", err)
+	}
+	return token, nil
+}
+
+// PurgeTokenCache deletes the cached token of the passed credentials.
+func PurgeTokenCache(cred *config.Credentials) error {
+	path, err := tokenCachePath()
+	if err != nil {
+		return err
+	}
+	tokenCacheMutex.Lock()
+	defer tokenCacheMutex.Unlock()
+
+	cache, err := readTokenCache(path)
+	if err != nil {
+		return err
+	}
+	key := tokenCacheKey(cred.Client, GetArduinoAPIBaseURLFor(cred), cred.Organization)
+	if _, ok := cache[key]; !ok {
+		return nil
+	}
+	delete(cache, key)
+	return writeTokenCache(path, cache)
+}
+
+// PurgeAllTokenCache deletes the token cache file,
+// dropping the tokens of all the credentials.
+func PurgeAllTokenCache() error {
+	path, err := tokenCachePath()
+	if err != nil {
+		return err
+	}
+	tokenCacheMutex.Lock()
+	defer tokenCacheMutex.Unlock()
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("deleting token cache: %w", err)
+	}
+	return nil
+}
+
+// tokenCachePath returns the path of the token cache file,
+// placed in the user cache directory.
+func tokenCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("retrieving user cache directory: %w", err)
+	}
+	return filepath.Join(dir, tokenCacheDir, tokenCacheFile), nil
+}
+
+// tokenCacheKey identifies the tokens of a client,
+// in a given organization and for a given API endpoint.
+func tokenCacheKey(client, baseURL, organizationId string) string {
+	return hash(client + "\x00" + organizationId + "\x00" + baseURL)
+}
+
+func hash(s string) string {
+	h := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(h[:])
+}
+
+// readTokenCache reads the cache file. A missing file is an empty cache.
+// Files readable by other users are refused, since they could have been tampered.
+func readTokenCache(path string) (map[string]cachedToken, error) {
+	cache := make(map[string]cachedToken)
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading token cache: %w", err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		return nil, fmt.Errorf("token cache %s is accessible by other users, permissions should be 0600", path)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading token cache: %w", err)
+	}
+	if err := json.Unmarshal(content, &cache); err != nil {
+		return nil, fmt.Errorf("parsing token cache %s: %w", path, err)
+	}
+	return cache, nil
+}
+
+// writeTokenCache atomically replaces the cache file, so that
+// concurrent CLI invocations never read a partially written file.
+func writeTokenCache(path string, cache map[string]cachedToken) error {
+	content, err := json.Marshal(cache)
+	if err != nil {
+		return fmt.Errorf("encoding token cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.FileMode(0700)); err != nil {
+		return fmt.Errorf("creating token cache directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), tokenCacheFile+".*")
+	if err != nil {
+		return fmt.Errorf("creating token cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing token cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing token cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("writing token cache: %w", err)
+	}
+	return nil
+}
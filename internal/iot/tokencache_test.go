@@ -0,0 +1,131 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package iot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+type countingTokenSource struct {
+	calls  int
+	expiry time.Duration
+}
+
+func (s *countingTokenSource) Token() (*oauth2.Token, error) {
+	s.calls++
+	return &oauth2.Token{
+		AccessToken: "token",
+		TokenType:   "Bearer",
+		Expiry:      time.Now().Add(s.expiry),
+	}, nil
+}
+
+func TestCachedTokenSource(t *testing.T) {
+	tests := []struct {
+		name      string
+		expiry    time.Duration
+		client    string
+		secret    string
+		wantCalls int
+	}{
+		{
+			name:      "valid-token-reused",
+			expiry:    time.Hour,
+			client:    "client",
+			secret:    "secret",
+			wantCalls: 1,
+		},
+		{
+			name:      "expiring-token-refreshed",
+			expiry:    30 * time.Second,
+			client:    "client",
+			secret:    "secret",
+			wantCalls: 2,
+		},
+		{
+			name:      "other-client-not-reused",
+			expiry:    time.Hour,
+			client:    "other-client",
+			secret:    "secret",
+			wantCalls: 2,
+		},
+		{
+			name:      "changed-secret-not-reused",
+			expiry:    time.Hour,
+			client:    "client",
+			secret:    "new-secret",
+			wantCalls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tokenCacheDir, tokenCacheFile)
+			src := &countingTokenSource{expiry: tt.expiry}
+
+			first := newCachedTokenSource(path, "client", "secret", "https://api2.arduino.cc", "org", src)
+			if _, err := first.Token(); err != nil {
+				t.Fatal(err)
+			}
+			// A new token source simulates a new CLI invocation
+			second := newCachedTokenSource(path, tt.client, tt.secret, "https://api2.arduino.cc", "org", src)
+			if _, err := second.Token(); err != nil {
+				t.Fatal(err)
+			}
+
+			if src.calls != tt.wantCalls {
+				t.Errorf("Expected %d token requests, but got %d", tt.wantCalls, src.calls)
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if perm := info.Mode().Perm(); perm != 0600 {
+				t.Errorf("Expected cache file permissions 0600, but got %o", perm)
+			}
+		})
+	}
+}
+
+func TestCachedTokenSourceRefusesOpenPermissions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), tokenCacheFile)
+	src := &countingTokenSource{expiry: time.Hour}
+	if _, err := newCachedTokenSource(path, "client", "secret", "", "", src).Token(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := readTokenCache(path); err == nil {
+		t.Error("Expected an error reading a cache file accessible by other users")
+	}
+	// The token source should ignore the cache and request a new token
+	if _, err := newCachedTokenSource(path, "client", "secret", "", "", src).Token(); err != nil {
+		t.Fatal(err)
+	}
+	if src.calls != 2 {
+		t.Errorf("Expected 2 token requests, but got %d", src.calls)
+	}
+}
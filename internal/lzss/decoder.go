@@ -21,58 +21,110 @@ package lzss
 
 import (
 	"bytes"
+	"errors"
+	"io"
 
 	"github.com/icza/bitio"
 )
 
+// Decompress takes a slice of bytes compressed with the lzss compression algorithm
+// and returns the decompressed data. Trailing bits not forming a whole
+// char or token, such as the padding of the last byte, are ignored.
 func Decompress(data []byte) []byte {
-	input := bitio.NewReader(bytes.NewBuffer(data))
-	output := make([]byte, 0)
+	// Reads from a bytes.Reader never fail
+	output, _ := io.ReadAll(NewReader(bytes.NewReader(data)))
+	return output
+}
+
+// Reader decompresses the data read from an underlying
+// reader, compressed with the lzss compression algorithm.
+type Reader struct {
+	input  *bitio.Reader
+	buffer [bufsz]byte
+	r      int    // Position of the next decompressed byte in buffer.
+	out    []byte // Decompressed bytes not read yet, backed by scratch.
+	err    error
+
+	scratch [looksz]byte
+}
 
-	buffer := make([]byte, bufsz*2)
+// NewReader returns a new Reader decompressing data read from r.
+func NewReader(r io.Reader) *Reader {
+	z := &Reader{
+		input: bitio.NewReader(r),
+		r:     bufsz - looksz,
+	}
 	for i := 0; i < bufsz-looksz; i++ {
-		buffer[i] = ' '
+		z.buffer[i] = ' '
 	}
+	return z
+}
 
-	r := bufsz - looksz
-	var char byte
-	var isChar bool
-	var err error
-	for {
-		isChar, err = input.ReadBool()
-		if err != nil {
-			break
-		}
-
-		if isChar {
-			char, err = input.ReadByte()
-			if err != nil {
-				break
-			}
-			output = append(output, char)
-			buffer[r] = char
-			r++
-			r &= bufsz - 1
-		} else {
-			var i, j uint64
-			i, err = input.ReadBits(idxsz)
-			if err != nil {
-				break
-			}
-			j, err = input.ReadBits(lensz)
-			if err != nil {
+// Read decompresses data into p.
+func (z *Reader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(z.out) == 0 {
+			if z.err != nil {
 				break
 			}
+			z.err = z.decodeNext()
+			continue
+		}
+		c := copy(p[n:], z.out)
+		z.out = z.out[c:]
+		n += c
+	}
+	if n > 0 {
+		return n, nil
+	}
+	return 0, z.err
+}
 
-			for k := 0; k <= int(j)+1; k++ {
-				char = buffer[(int(i)+k)&(bufsz-1)]
-				output = append(output, char)
-				buffer[r] = char
-				r++
-				r &= bufsz - 1
-			}
+// decodeNext decodes the next char or token into out.
+// Running out of input while decoding ends the data.
+func (z *Reader) decodeNext() error {
+	isChar, err := z.input.ReadBool()
+	if err != nil {
+		return endOfInput(err)
+	}
+
+	if isChar {
+		char, err := z.input.ReadByte()
+		if err != nil {
+			return endOfInput(err)
 		}
+		z.out = append(z.scratch[:0], z.put(char))
+		return nil
 	}
 
-	return output
+	i, err := z.input.ReadBits(idxsz)
+	if err != nil {
+		return endOfInput(err)
+	}
+	j, err := z.input.ReadBits(lensz)
+	if err != nil {
+		return endOfInput(err)
+	}
+	out := z.scratch[:0]
+	for k := 0; k <= int(j)+1; k++ {
+		out = append(out, z.put(z.buffer[(int(i)+k)&(bufsz-1)]))
+	}
+	z.out = out
+	return nil
+}
+
+// put adds a decompressed byte to the buffer.
+func (z *Reader) put(char byte) byte {
+	z.buffer[z.r] = char
+	z.r++
+	z.r &= bufsz - 1
+	return char
+}
+
+func endOfInput(err error) error {
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return io.EOF
+	}
+	return err
 }
@@ -21,6 +21,9 @@ package lzss
 
 import (
 	"bytes"
+	"errors"
+	"io"
+	"math"
 )
 
 const (
@@ -38,103 +41,189 @@ const (
 
 	charStartBit  = true  // Indicates next bits encode a char.
 	tokenStartBit = false // Indicates next bits encode a token.
+
+	// The window is kept in a ring buffer large enough to contain
+	// the history, the lookahead and a chunk of pending input.
+	ringsz   = bufsz * 2
+	ringmask = ringsz - 1
+)
+
+var (
+	errClosed   = errors.New("lzss: write to closed writer")
+	errTooLarge = errors.New("lzss: input too large")
 )
 
+// maxInput is the maximum size of the input of a Writer,
+// whose positions are stored as int32 in the hash chains.
+const maxInput = math.MaxInt32 - historysz - ringsz
+
 // Encode takes a slice of bytes, compresses it using the lzss compression algorithm
 // and returns the result in a new bytes buffer.
 func Encode(data []byte) []byte {
-	// buffer is made up of two parts: the first is for already processed data (history); the second is for new data
-	buffer := make([]byte, bufsz*2)
-	// Initialize the old-data part (history) of the buffer
+	var out bytes.Buffer
+	w := NewWriter(&out)
+	// Writes to a bytes.Buffer never fail
+	w.Write(data)
+	w.Close()
+	return out.Bytes()
+}
+
+// Writer compresses the data written to it using the lzss compression
+// algorithm, writing the result to an underlying writer.
+// The output is the same of Encode: it can be decompressed
+// by Decompress and by the boards.
+// Close must be called to write the last bits of the output.
+type Writer struct {
+	w   io.Writer
+	out *result
+	err error
+
+	// ring contains the bytes from position cur-historysz to end.
+	// Positions are absolute: the history initially contains historysz spaces,
+	// so the first input byte is at position historysz.
+	ring [ringsz]byte
+	cur  int // Position of the next byte to be encoded.
+	end  int // Position of the next byte to be written.
+
+	// Hash chains used to find matches: head contains the last position
+	// starting with each couple of bytes, and prev links each position
+	// to the previous one starting with the same couple of bytes.
+	head     [1 << 16]int32
+	prev     [ringsz]int32
+	inserted int // Position of the next byte to be added to the hash chains.
+}
+
+// NewWriter returns a new Writer compressing data to w.
+func NewWriter(w io.Writer) *Writer {
+	z := &Writer{
+		w:   w,
+		out: newResult(),
+		cur: historysz,
+		end: historysz,
+	}
 	for i := 0; i < historysz; i++ {
-		buffer[i] = ' '
-	}
-	out := newResult()
-	in := newFiller(data)
-
-	// Fill the new-data part of the buffer
-	n := in.fill(buffer[historysz:])
-	bufferend := historysz + n
-	for current := historysz; current < bufferend; {
-		idx, len := findLargestMatch(buffer, current, bufferend)
-		if len <= threshold {
-			out.addChar(buffer[current])
-			len = 1
-		} else {
-			out.addToken(idx, len)
+		z.ring[i] = ' '
+	}
+	for i := range z.head {
+		z.head[i] = -1
+	}
+	return z
+}
+
+// Write compresses p. Bytes are encoded as soon as
+// a whole lookahead is available after them.
+func (z *Writer) Write(p []byte) (int, error) {
+	if z.err != nil {
+		return 0, z.err
+	}
+	written := 0
+	for len(p) > 0 {
+		// Don't overwrite the history still needed
+		free := ringsz - (z.end - (z.cur - historysz))
+		n := min(free, len(p))
+		if z.end-historysz+n > maxInput {
+			z.err = errTooLarge
+			return written, z.err
+		}
+		for _, c := range p[:n] {
+			z.ring[z.end&ringmask] = c
+			z.end++
 		}
+		p = p[n:]
+		written += n
 
-		current += len
-		if current >= bufsz*2-looksz {
-			// Shift processed bytes to the old-data portion of the buffer
-			copy(buffer[:bufsz], buffer[bufsz:])
-			current -= bufsz
-			// Refill the new-data portion of the buffer
-			bufferend -= bufsz
-			bufferend += in.fill(buffer[bufferend:])
+		for z.end-z.cur >= looksz {
+			z.encodeNext()
+		}
+		if err := z.flushOutput(); err != nil {
+			return written, err
 		}
 	}
+	return written, nil
+}
 
-	out.flush()
-	return out.bytes()
+// Close encodes the remaining bytes and writes the last bits of the output.
+// It does not close the underlying writer.
+func (z *Writer) Close() error {
+	if z.err != nil {
+		if z.err == errClosed {
+			return nil
+		}
+		return z.err
+	}
+	for z.cur < z.end {
+		z.encodeNext()
+	}
+	z.out.flush()
+	if err := z.flushOutput(); err != nil {
+		return err
+	}
+	z.err = errClosed
+	return nil
 }
 
-func min(x, y int) int {
-	if x < y {
-		return x
+func (z *Writer) flushOutput() error {
+	if _, err := z.out.out.WriteTo(z.w); err != nil {
+		z.err = err
+		return err
 	}
-	return y
+	return nil
 }
 
-// findLargestMatch looks for the largest sequence of characters (from current to current+ahead)
-// contained in the history of the buffer.
-// It returns the index of the found match, if any, and its length.
-// The index is relative to the current position. If idx 0 is returned than no match has been found.
-func findLargestMatch(buf []byte, current, size int) (idx, len int) {
-	idx = 0
-	len = 1
-	ahead := min(looksz, size-current)
-	history := current - historysz
-	c := buf[current]
-	for i := current - 1; i >= history; i-- {
-		if buf[i] == c {
-			var j int
-			for j = 1; j < ahead; j++ {
-				if buf[i+j] != buf[current+j] {
-					break
-				}
-			}
-			if j > len {
-				idx = i
-				len = j
-			}
-		}
+// encodeNext encodes the byte at cur, or the longest match starting at cur.
+func (z *Writer) encodeNext() {
+	idx, len := z.findLargestMatch()
+	if len <= threshold {
+		z.out.addChar(z.ring[z.cur&ringmask])
+		len = 1
+	} else {
+		z.out.addToken(idx, len)
 	}
-	return
+	z.cur += len
 }
 
-// filler abstracts the process of consuming an input buffer
-// using its bytes to fill another buffer.
-// It's been used to facilitate the handling of the input buffer in the Encode function.
-type filler struct {
-	src []byte
-	idx int
+// key returns the hash chain key of the position, made by its first two bytes.
+func (z *Writer) key(pos int) int {
+	return int(z.ring[pos&ringmask])<<8 | int(z.ring[(pos+1)&ringmask])
 }
 
-func newFiller(src []byte) *filler {
-	return &filler{
-		src: src,
+// findLargestMatch looks for the largest sequence of characters (from cur to cur+ahead)
+// contained in the history.
+// It returns the position of the found match, if any, and its length.
+// Among the matches with the same length the closest one is chosen, so that
+// the output doesn't depend on the match finder.
+func (z *Writer) findLargestMatch() (idx, len int) {
+	idx = 0
+	len = 1
+	ahead := min(looksz, z.end-z.cur)
+	if ahead < 2 {
+		return
 	}
-}
 
-// fill tries to fill all the dst buffer with bytes read from src.
-// It returns the number of bytes moved from src to dst.
-// The src buffer offset is then incremented so that all the content of src
-// can be consumed in small chunks.
-func (f *filler) fill(dst []byte) int {
-	n := copy(dst, f.src[f.idx:])
-	f.idx += n
-	return n
+	// Add the history to the hash chains. The key of each
+	// position includes the following byte, available up to cur.
+	for ; z.inserted < z.cur; z.inserted++ {
+		k := z.key(z.inserted)
+		z.prev[z.inserted&ringmask] = z.head[k]
+		z.head[k] = int32(z.inserted)
+	}
+
+	history := z.cur - historysz
+	for i := int(z.head[z.key(z.cur)]); i >= history; i = int(z.prev[i&ringmask]) {
+		// The first two bytes match by construction
+		j := 2
+		for j < ahead && z.ring[(i+j)&ringmask] == z.ring[(z.cur+j)&ringmask] {
+			j++
+		}
+		if j > len {
+			idx = i
+			len = j
+			if len == ahead {
+				break
+			}
+		}
+	}
+	return
 }
 
 // result is responsible for storing the actual result of the encoding.
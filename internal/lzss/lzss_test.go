@@ -19,8 +19,11 @@ package lzss
 
 import (
 	"bytes"
+	"io"
 	"io/ioutil"
+	"math/rand"
 	"testing"
+	"testing/iotest"
 )
 
 func TestEncode(t *testing.T) {
@@ -60,3 +63,186 @@ func TestEncode(t *testing.T) {
 		})
 	}
 }
+
+// testInputs returns inputs exercising the match finder: random,
+// repetitive and mixed data, longer than the window.
+func testInputs(t testing.TB) map[string][]byte {
+	rnd := rand.New(rand.NewSource(1))
+	random := make([]byte, 10000)
+	rnd.Read(random)
+	lowEntropy := make([]byte, 10000)
+	for i := range lowEntropy {
+		lowEntropy[i] = byte(rnd.Intn(3))
+	}
+	cloud, err := ioutil.ReadFile("testdata/cloud.bin")
+	if err != nil {
+		t.Fatal("couldn't open test file")
+	}
+	return map[string][]byte{
+		"empty":       nil,
+		"one byte":    {'a'},
+		"spaces":      bytes.Repeat([]byte(" "), 5000),
+		"zeros":       make([]byte, 5000),
+		"random":      random,
+		"low entropy": lowEntropy,
+		"pattern":     bytes.Repeat([]byte("abcabdabcabe"), 1000),
+		"cloud":       cloud,
+	}
+}
+
+func TestWriter(t *testing.T) {
+	for name, input := range testInputs(t) {
+		t.Run(name, func(t *testing.T) {
+			want := linearEncode(input)
+			if got := Encode(input); !bytes.Equal(want, got) {
+				t.Fatal("output differs from the linear encoder")
+			}
+
+			// Output must not depend on how data is written
+			var out bytes.Buffer
+			w := NewWriter(&out)
+			rnd := rand.New(rand.NewSource(2))
+			for data := input; len(data) > 0; {
+				n := min(len(data), rnd.Intn(5000))
+				if _, err := w.Write(data[:n]); err != nil {
+					t.Fatal(err)
+				}
+				data = data[n:]
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(want, out.Bytes()) {
+				t.Fatal("chunked output differs from the linear encoder")
+			}
+			if _, err := w.Write([]byte("a")); err == nil {
+				t.Error("expected error writing to closed writer")
+			}
+		})
+	}
+}
+
+func TestReader(t *testing.T) {
+	for name, input := range testInputs(t) {
+		t.Run(name, func(t *testing.T) {
+			r := NewReader(iotest.OneByteReader(bytes.NewReader(Encode(input))))
+			got, err := io.ReadAll(iotest.OneByteReader(r))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(input, got) {
+				t.Fatal("decompressed data differs from the input")
+			}
+		})
+	}
+}
+
+func benchmarkInput(b *testing.B) []byte {
+	input, err := ioutil.ReadFile("testdata/cloud.bin")
+	if err != nil {
+		b.Fatal("couldn't open test file")
+	}
+	return input
+}
+
+func BenchmarkEncode(b *testing.B) {
+	input := benchmarkInput(b)
+	b.SetBytes(int64(len(input)))
+	for i := 0; i < b.N; i++ {
+		Encode(input)
+	}
+}
+
+func BenchmarkEncodeLinear(b *testing.B) {
+	input := benchmarkInput(b)
+	b.SetBytes(int64(len(input)))
+	for i := 0; i < b.N; i++ {
+		linearEncode(input)
+	}
+}
+
+func BenchmarkDecompress(b *testing.B) {
+	input := benchmarkInput(b)
+	compressed := Encode(input)
+	b.SetBytes(int64(len(input)))
+	for i := 0; i < b.N; i++ {
+		Decompress(compressed)
+	}
+}
+
+// linearEncode is the original encoder, scanning the whole history
+// to find matches. It's the reference for the output of Writer.
+func linearEncode(data []byte) []byte {
+	// buffer is made up of two parts: the first is for already processed data (history); the second is for new data
+	buffer := make([]byte, bufsz*2)
+	// Initialize the old-data part (history) of the buffer
+	for i := 0; i < historysz; i++ {
+		buffer[i] = ' '
+	}
+	out := newResult()
+	in := newFiller(data)
+
+	// Fill the new-data part of the buffer
+	n := in.fill(buffer[historysz:])
+	bufferend := historysz + n
+	for current := historysz; current < bufferend; {
+		idx, len := linearFindLargestMatch(buffer, current, bufferend)
+		if len <= threshold {
+			out.addChar(buffer[current])
+			len = 1
+		} else {
+			out.addToken(idx, len)
+		}
+
+		current += len
+		if current >= bufsz*2-looksz {
+			// Shift processed bytes to the old-data portion of the buffer
+			copy(buffer[:bufsz], buffer[bufsz:])
+			current -= bufsz
+			// Refill the new-data portion of the buffer
+			bufferend -= bufsz
+			bufferend += in.fill(buffer[bufferend:])
+		}
+	}
+
+	out.flush()
+	return out.bytes()
+}
+func linearFindLargestMatch(buf []byte, current, size int) (idx, len int) {
+	idx = 0
+	len = 1
+	ahead := min(looksz, size-current)
+	history := current - historysz
+	c := buf[current]
+	for i := current - 1; i >= history; i-- {
+		if buf[i] == c {
+			var j int
+			for j = 1; j < ahead; j++ {
+				if buf[i+j] != buf[current+j] {
+					break
+				}
+			}
+			if j > len {
+				idx = i
+				len = j
+			}
+		}
+	}
+	return
+}
+
+type filler struct {
+	src []byte
+	idx int
+}
+
+func newFiller(src []byte) *filler {
+	return &filler{
+		src: src,
+	}
+}
+func (f *filler) fill(dst []byte) int {
+	n := copy(dst, f.src[f.idx:])
+	f.idx += n
+	return n
+}
@@ -0,0 +1,172 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package mockserver
+
+import (
+	"net/http"
+	"sort"
+	"time"
+)
+
+type dashboard struct {
+	ID        string                   `json:"id"`
+	Name      string                   `json:"name"`
+	Widgets   []map[string]interface{} `json:"widgets"`
+	Pages     []map[string]interface{} `json:"pages,omitempty"`
+	CreatedBy map[string]string        `json:"created_by"`
+	UpdatedAt time.Time                `json:"updated_at"`
+}
+
+// dashboardFields are the writable fields of a dashboard.
+type dashboardFields struct {
+	Name    *string                  `json:"name"`
+	Widgets []map[string]interface{} `json:"widgets"`
+	Pages   []map[string]interface{} `json:"pages"`
+}
+
+func (s *Server) routeDashboards() {
+	s.handle("PUT /iot/v3/dashboards", s.dashboardCreate)
+	s.handle("GET /iot/v3/dashboards", s.dashboardList)
+	s.handle("GET /iot/v3/dashboards/{id}", s.dashboardShow)
+	s.handle("POST /iot/v3/dashboards/{id}", s.dashboardUpdate)
+	s.handle("DELETE /iot/v3/dashboards/{id}", s.dashboardDelete)
+	s.handle("DELETE /iot/v2/dashboards/{id}", s.dashboardDelete)
+	s.handle("GET /iot/v3/dashboards/{id}/template", s.dashboardTemplate)
+}
+
+func (s *Server) dashboardCreate(w http.ResponseWriter, r *http.Request) {
+	var body dashboardFields
+	if !readJSON(w, r, &body) {
+		return
+	}
+	if body.Name == nil || *body.Name == "" {
+		writeError(w, http.StatusBadRequest, "dashboard name is required")
+		return
+	}
+	d := &dashboard{
+		ID:        newID(),
+		Name:      *body.Name,
+		Widgets:   body.Widgets,
+		Pages:     body.Pages,
+		CreatedBy: map[string]string{"user_id": s.UserID, "username": "mock-user"},
+		UpdatedAt: now(),
+	}
+	if d.Widgets == nil {
+		d.Widgets = []map[string]interface{}{}
+	}
+	s.dashboards[d.ID] = d
+	writeJSON(w, http.StatusCreated, d)
+}
+
+func (s *Server) dashboardList(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	list := []*dashboard{}
+	for _, d := range s.dashboards {
+		if name == "" || d.Name == name {
+			list = append(list, d)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) dashboard(w http.ResponseWriter, r *http.Request) (*dashboard, bool) {
+	id := r.PathValue("id")
+	d, ok := s.dashboards[id]
+	if !ok {
+		notFound(w, "dashboard", id)
+	}
+	return d, ok
+}
+
+func (s *Server) dashboardShow(w http.ResponseWriter, r *http.Request) {
+	if d, ok := s.dashboard(w, r); ok {
+		writeJSON(w, http.StatusOK, d)
+	}
+}
+
+func (s *Server) dashboardUpdate(w http.ResponseWriter, r *http.Request) {
+	d, ok := s.dashboard(w, r)
+	if !ok {
+		return
+	}
+	var body dashboardFields
+	if !readJSON(w, r, &body) {
+		return
+	}
+	if body.Name != nil {
+		d.Name = *body.Name
+	}
+	if body.Widgets != nil {
+		d.Widgets = body.Widgets
+	}
+	if body.Pages != nil {
+		d.Pages = body.Pages
+	}
+	d.UpdatedAt = now()
+	writeJSON(w, http.StatusOK, d)
+}
+
+func (s *Server) dashboardDelete(w http.ResponseWriter, r *http.Request) {
+	if d, ok := s.dashboard(w, r); ok {
+		delete(s.dashboards, d.ID)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// dashboardTemplate returns the dashboard with the ids of the
+// variables replaced by the names of things and variables.
+func (s *Server) dashboardTemplate(w http.ResponseWriter, r *http.Request) {
+	d, ok := s.dashboard(w, r)
+	if !ok {
+		return
+	}
+	widgets := make([]map[string]interface{}, 0, len(d.Widgets))
+	for _, widget := range d.Widgets {
+		tw := make(map[string]interface{}, len(widget))
+		for k, v := range widget {
+			tw[k] = v
+		}
+		var vars []map[string]string
+		if ids, ok := widget["variables"].([]interface{}); ok {
+			for _, id := range ids {
+				if t, p := s.findProperty(id); p != nil {
+					vars = append(vars, map[string]string{"thing_id": t.Name, "variable_id": p.VariableName})
+				}
+			}
+		}
+		tw["variables"] = vars
+		widgets = append(widgets, tw)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":      d.ID,
+		"name":    d.Name,
+		"widgets": widgets,
+	})
+}
+
+func (s *Server) findProperty(id interface{}) (*thing, *property) {
+	for _, t := range s.things {
+		for _, p := range t.Properties {
+			if p.ID == id {
+				return t, p
+			}
+		}
+	}
+	return nil, nil
+}
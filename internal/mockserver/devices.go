@@ -0,0 +1,446 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package mockserver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+type device struct {
+	ID             string                 `json:"id"`
+	Href           string                 `json:"href"`
+	Name           string                 `json:"name"`
+	Label          string                 `json:"label"`
+	Fqbn           string                 `json:"fqbn,omitempty"`
+	Serial         string                 `json:"serial"`
+	Type           string                 `json:"type"`
+	ConnectionType string                 `json:"connection_type,omitempty"`
+	DeviceStatus   string                 `json:"device_status"`
+	OtaCompatible  bool                   `json:"ota_compatible"`
+	OtaAvailable   bool                   `json:"ota_available"`
+	Tags           map[string]interface{} `json:"tags"`
+	Thing          *thingSummary          `json:"thing,omitempty"`
+	UserID         string                 `json:"user_id"`
+	CreatedAt      time.Time              `json:"created_at"`
+	UpdatedAt      time.Time              `json:"updated_at"`
+	LastActivityAt *time.Time             `json:"last_activity_at,omitempty"`
+
+	password string
+}
+
+// thingSummary is the thing embedded in the devices bound to it.
+type thingSummary struct {
+	ID     string `json:"id"`
+	Href   string `json:"href"`
+	Name   string `json:"name"`
+	UserID string `json:"user_id"`
+}
+
+func (s *Server) routeDevices() {
+	s.handle("PUT /iot/v2/devices", s.deviceCreate)
+	s.handle("GET /iot/v2/devices", s.deviceList)
+	s.handle("GET /iot/v2/devices/{id}", s.deviceShow)
+	s.handle("POST /iot/v2/devices/{id}", s.deviceUpdate)
+	s.handle("DELETE /iot/v2/devices/{id}", s.deviceDelete)
+	s.handle("GET /iot/v2/devices/{id}/pass", s.devicePassGet)
+	s.handle("PUT /iot/v2/devices/{id}/pass", s.devicePassSet)
+	s.handle("PUT /iot/v2/devices/{id}/certs", s.deviceCertCreate)
+	s.handle("GET /iot/v2/devices/{id}/tags", s.deviceTagsList)
+	s.handle("PUT /iot/v2/devices/{id}/tags", s.deviceTagsUpsert)
+	s.handle("DELETE /iot/v2/devices/{id}/tags/{key}", s.deviceTagsDelete)
+	s.handle("POST /iot/v2/devices/{id}/ota", s.deviceOtaUpload)
+	s.handle("PUT /iot/v1/lora-devices/", s.loraDeviceCreate)
+	s.handle("GET /iot/v1/lora-freq-plans", s.loraFreqPlanList)
+	s.handle("GET /iot/v1/network_credentials/{type}", s.networkCredentials)
+	s.handle("GET /iot/v1/supported/devices", s.supportedDevices)
+	s.handle("GET /iot/v2/binaries/provisioningv2", s.provisioningSketch)
+}
+
+// AddDevice adds a device to the server state and returns its id.
+func (s *Server) AddDevice(name, fqbn, deviceType string, tags map[string]string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.newDevice(name, fqbn, newSecret(), deviceType, "wifi")
+	for k, v := range tags {
+		d.Tags[k] = v
+	}
+	return d.ID
+}
+
+func (s *Server) newDevice(name, fqbn, serial, deviceType, connection string) *device {
+	id := newID()
+	d := &device{
+		ID:             id,
+		Href:           "/iot/v2/devices/" + id,
+		Name:           name,
+		Label:          name,
+		Fqbn:           fqbn,
+		Serial:         serial,
+		Type:           deviceType,
+		ConnectionType: connection,
+		DeviceStatus:   "OFFLINE",
+		OtaCompatible:  true,
+		OtaAvailable:   true,
+		Tags:           make(map[string]interface{}),
+		UserID:         s.UserID,
+		CreatedAt:      now(),
+		UpdatedAt:      now(),
+	}
+	s.devices[id] = d
+	return d
+}
+
+func (s *Server) deviceCreate(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Name           string `json:"name"`
+		Fqbn           string `json:"fqbn"`
+		Serial         string `json:"serial"`
+		Type           string `json:"type"`
+		ConnectionType string `json:"connection_type"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	if body.Type == "" {
+		writeError(w, http.StatusBadRequest, "device type is required")
+		return
+	}
+	d := s.newDevice(body.Name, body.Fqbn, body.Serial, body.Type, body.ConnectionType)
+	writeJSON(w, http.StatusCreated, d)
+}
+
+func (s *Server) deviceList(w http.ResponseWriter, r *http.Request) {
+	tags := queryList(r, "tags")
+	serial := r.URL.Query().Get("serial")
+	list := []*device{}
+	for _, d := range s.devices {
+		if serial != "" && d.Serial != serial {
+			continue
+		}
+		if matchTags(d.Tags, tags) {
+			list = append(list, d)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) device(w http.ResponseWriter, r *http.Request) (*device, bool) {
+	id := r.PathValue("id")
+	d, ok := s.devices[id]
+	if !ok {
+		notFound(w, "device", id)
+	}
+	return d, ok
+}
+
+func (s *Server) deviceShow(w http.ResponseWriter, r *http.Request) {
+	if d, ok := s.device(w, r); ok {
+		writeJSON(w, http.StatusOK, d)
+	}
+}
+
+func (s *Server) deviceUpdate(w http.ResponseWriter, r *http.Request) {
+	d, ok := s.device(w, r)
+	if !ok {
+		return
+	}
+	var body struct {
+		Name           *string `json:"name"`
+		Fqbn           *string `json:"fqbn"`
+		Serial         *string `json:"serial"`
+		ConnectionType *string `json:"connection_type"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	if body.Name != nil {
+		d.Name, d.Label = *body.Name, *body.Name
+	}
+	if body.Fqbn != nil {
+		d.Fqbn = *body.Fqbn
+	}
+	if body.Serial != nil {
+		d.Serial = *body.Serial
+	}
+	if body.ConnectionType != nil {
+		d.ConnectionType = *body.ConnectionType
+	}
+	d.UpdatedAt = now()
+	writeJSON(w, http.StatusOK, d)
+}
+
+func (s *Server) deviceDelete(w http.ResponseWriter, r *http.Request) {
+	d, ok := s.device(w, r)
+	if !ok {
+		return
+	}
+	if d.Thing != nil {
+		if t, ok := s.things[d.Thing.ID]; ok {
+			t.unbind()
+		}
+	}
+	delete(s.devices, d.ID)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) devicePassGet(w http.ResponseWriter, r *http.Request) {
+	d, ok := s.device(w, r)
+	if !ok {
+		return
+	}
+	res := map[string]interface{}{"set": d.password != ""}
+	if r.URL.Query().Get("suggested_password") == "true" {
+		res["suggested_password"] = newSecret()
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) devicePassSet(w http.ResponseWriter, r *http.Request) {
+	d, ok := s.device(w, r)
+	if !ok {
+		return
+	}
+	var body struct {
+		Password string `json:"password"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	d.password = body.Password
+	writeJSON(w, http.StatusOK, map[string]interface{}{"set": d.password != ""})
+}
+
+func (s *Server) deviceCertCreate(w http.ResponseWriter, r *http.Request) {
+	d, ok := s.device(w, r)
+	if !ok {
+		return
+	}
+	var body struct {
+		Ca      string `json:"ca"`
+		Csr     string `json:"csr"`
+		Enabled bool   `json:"enabled"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	id := newID()
+	sum := sha256.Sum256([]byte(body.Csr))
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"id":        id,
+		"href":      "/iot/v2/devices/" + d.ID + "/certs/" + id,
+		"ca":        body.Ca,
+		"device_id": d.ID,
+		"enabled":   body.Enabled,
+		"pem":       "-----BEGIN CERTIFICATE-----\nMOCK\n-----END CERTIFICATE-----\n",
+		"der":       "MOCK",
+		"compressed": map[string]interface{}{
+			"authority_key_identifier": hex.EncodeToString(sum[:20]),
+			"not_after":                now().AddDate(30, 0, 0),
+			"not_before":               now(),
+			"serial":                   hex.EncodeToString(sum[:16]),
+			"signature":                hex.EncodeToString(sum[:]) + hex.EncodeToString(sum[:]),
+			"signature_asn1_x":         hex.EncodeToString(sum[:]),
+			"signature_asn1_y":         hex.EncodeToString(sum[:]),
+		},
+	})
+}
+
+func (s *Server) deviceTagsList(w http.ResponseWriter, r *http.Request) {
+	if d, ok := s.device(w, r); ok {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"tags": tagList(d.Tags)})
+	}
+}
+
+func (s *Server) deviceTagsUpsert(w http.ResponseWriter, r *http.Request) {
+	d, ok := s.device(w, r)
+	if !ok {
+		return
+	}
+	var tag struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	}
+	if !readJSON(w, r, &tag) {
+		return
+	}
+	d.Tags[tag.Key] = tag.Value
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) deviceTagsDelete(w http.ResponseWriter, r *http.Request) {
+	if d, ok := s.device(w, r); ok {
+		delete(d.Tags, r.PathValue("key"))
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func (s *Server) deviceOtaUpload(w http.ResponseWriter, r *http.Request) {
+	d, ok := s.device(w, r)
+	if !ok {
+		return
+	}
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid multipart form: %v", err))
+		return
+	}
+	file, _, err := r.FormFile("ota_file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "ota_file is required")
+		return
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	for _, o := range s.otas {
+		if o.DeviceID == d.ID && !o.finished() {
+			writeError(w, http.StatusConflict, "ota already in progress")
+			return
+		}
+	}
+	sum := sha256.Sum256(content)
+	o := s.newOta(d.ID, content)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"ota_id":   o.ID,
+		"file_sha": hex.EncodeToString(sum[:]),
+	})
+}
+
+func (s *Server) loraDeviceCreate(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Name          string `json:"name"`
+		Serial        string `json:"serial"`
+		Type          string `json:"type"`
+		Eui           string `json:"eui"`
+		FrequencyPlan string `json:"frequency_plan"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	d := s.newDevice(body.Name, "", body.Serial, body.Type, "lora")
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"device_id":      d.ID,
+		"eui":            body.Eui,
+		"app_eui":        strings.ToUpper(newSecret()[:16]),
+		"app_key":        strings.ToUpper(newSecret()),
+		"frequency_plan": body.FrequencyPlan,
+		"name":           body.Name,
+		"serial":         body.Serial,
+		"type":           body.Type,
+		"user_id":        s.UserID,
+	})
+}
+
+func (s *Server) loraFreqPlanList(w http.ResponseWriter, r *http.Request) {
+	plans := []map[string]interface{}{}
+	for _, p := range []struct{ id, name string }{
+		{"EU_863_870_TTN", "Europe 863-870 MHz"},
+		{"US_902_928_FSB_2", "United States 902-928 MHz, FSB 2"},
+		{"AU_915_928_FSB_2", "Australia 915-928 MHz, FSB 2"},
+	} {
+		plans = append(plans, map[string]interface{}{
+			"id": p.id, "name": p.name, "advanced": false, "supported": true,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"frequency_plans": plans})
+}
+
+func (s *Server) networkCredentials(w http.ResponseWriter, r *http.Request) {
+	credentials := []map[string]interface{}{
+		{"friendly_name": "Wi-Fi Name", "required": true, "secret_name": "wifi_ssid", "sensitive": false},
+		{"friendly_name": "Password", "required": true, "secret_name": "wifi_pass", "sensitive": true},
+	}
+	switch r.URL.Query().Get("connection") {
+	case "eth":
+		credentials = []map[string]interface{}{
+			{"friendly_name": "IP", "required": false, "secret_name": "ip", "sensitive": false},
+		}
+	case "lora":
+		credentials = []map[string]interface{}{
+			{"friendly_name": "App EUI", "required": true, "secret_name": "app_eui", "sensitive": false},
+			{"friendly_name": "App Key", "required": true, "secret_name": "app_key", "sensitive": true},
+		}
+	}
+	writeJSON(w, http.StatusOK, credentials)
+}
+
+func (s *Server) supportedDevices(w http.ResponseWriter, r *http.Request) {
+	boards := []map[string]interface{}{}
+	for _, b := range []struct{ fqbn, label, typ, provisioning string }{
+		{"arduino:samd:nano_33_iot", "Arduino Nano 33 IoT", "nano_33_iot", "v1"},
+		{"arduino:samd:mkrwifi1010", "Arduino MKR WiFi 1010", "mkrwifi1010", "v1"},
+		{"arduino:mbed_portenta:envie_m7", "Arduino Portenta H7", "envie_m7", "v1"},
+		{"arduino:renesas_uno:unor4wifi", "Arduino UNO R4 WiFi", "unor4wifi", "v2"},
+		{"arduino:esp32:nano_nora", "Arduino Nano ESP32", "nano_nora", "v2"},
+	} {
+		boards = append(boards, map[string]interface{}{
+			"fqbn":                            b.fqbn,
+			"label":                           b.label,
+			"type":                            b.typ,
+			"vendor":                          "Arduino",
+			"tags":                            []string{},
+			"provisioning":                    b.provisioning,
+			"ota_available":                   true,
+			"min_provisioning_sketch_version": "1.0.0",
+			"min_provisioning_wifi_version":   "1.0.0",
+		})
+	}
+	writeJSON(w, http.StatusOK, boards)
+}
+
+func (s *Server) provisioningSketch(w http.ResponseWriter, r *http.Request) {
+	fqbn := r.URL.Query().Get("fqbn")
+	if fqbn == "" {
+		writeError(w, http.StatusBadRequest, "fqbn is required")
+		return
+	}
+	bin := []byte("mock provisioning sketch for " + fqbn)
+	sum := sha256.Sum256(bin)
+	name := strings.ReplaceAll(fqbn, ":", ".")
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"bin":      bin,
+		"filename": name + ".bin",
+		"fqbn":     fqbn,
+		"name":     name,
+		"sha256":   hex.EncodeToString(sum[:]),
+	})
+}
+
+func tagList(tags map[string]interface{}) []map[string]interface{} {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	list := make([]map[string]interface{}, 0, len(keys))
+	for _, k := range keys {
+		list = append(list, map[string]interface{}{"key": k, "value": tags[k]})
+	}
+	return list
+}
@@ -0,0 +1,193 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package mockserver
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// OTA statuses, in the order followed by a successful update.
+const (
+	otaPending    = "pending"
+	otaInProgress = "in_progress"
+	otaSucceeded  = "succeeded"
+	otaFailed     = "failed"
+	otaCancelled  = "cancelled"
+)
+
+// otaJob is an OTA update of a device. Since no device is connected,
+// jobs advance by one status each time they are read.
+type otaJob struct {
+	ID           string     `json:"id"`
+	DeviceID     string     `json:"device_id"`
+	Status       string     `json:"status"`
+	StartedAt    time.Time  `json:"started_at"`
+	EndedAt      *time.Time `json:"ended_at,omitempty"`
+	ErrorReason  string     `json:"error_reason,omitempty"`
+	FirmwareSize int64      `json:"firmware_size"`
+
+	firmware []byte
+	states   []otaState
+	// failure, if set, makes the job fail with this reason.
+	failure string
+}
+
+type otaState struct {
+	OtaID     string    `json:"ota_id"`
+	State     string    `json:"state"`
+	StateData string    `json:"state_data,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func (s *Server) routeOta() {
+	s.handle("GET /ota/v1/ota", s.otaList)
+	s.handle("GET /ota/v1/ota/{id}", s.otaShow)
+	s.handle("PUT /ota/v1/ota/{id}/cancel", s.otaCancel)
+}
+
+func (s *Server) newOta(deviceID string, firmware []byte) *otaJob {
+	o := &otaJob{
+		ID:           newID(),
+		DeviceID:     deviceID,
+		Status:       otaPending,
+		StartedAt:    now(),
+		FirmwareSize: int64(len(firmware)),
+		firmware:     firmware,
+		failure:      s.otaFailures[deviceID],
+	}
+	o.addState("pending", "")
+	s.otas[o.ID] = o
+	return o
+}
+
+// FailOta makes the next OTA updates of the device fail with the passed reason.
+// An empty reason restores successful updates.
+func (s *Server) FailOta(deviceID, reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if reason == "" {
+		delete(s.otaFailures, deviceID)
+		return
+	}
+	s.otaFailures[deviceID] = reason
+}
+
+// Firmware returns the firmware uploaded with the last OTA update of the device.
+func (s *Server) Firmware(deviceID string) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var last *otaJob
+	for _, o := range s.otas {
+		if o.DeviceID == deviceID && (last == nil || o.StartedAt.After(last.StartedAt)) {
+			last = o
+		}
+	}
+	if last == nil {
+		return nil
+	}
+	return last.firmware
+}
+
+func (o *otaJob) finished() bool {
+	return o.Status == otaSucceeded || o.Status == otaFailed || o.Status == otaCancelled
+}
+
+func (o *otaJob) addState(state, data string) {
+	o.states = append(o.states, otaState{OtaID: o.ID, State: state, StateData: data, Timestamp: now()})
+}
+
+// advance moves the job to its next status.
+func (o *otaJob) advance() {
+	switch o.Status {
+	case otaPending:
+		o.Status = otaInProgress
+		o.addState("start", "")
+		o.addState("fetch", strconv.FormatInt(o.FirmwareSize, 10))
+	case otaInProgress:
+		ended := now()
+		o.EndedAt = &ended
+		if o.failure != "" {
+			o.Status, o.ErrorReason = otaFailed, o.failure
+			o.addState("fail", o.failure)
+			return
+		}
+		o.Status = otaSucceeded
+		o.addState("flash", "")
+		o.addState("reboot", "")
+	}
+}
+
+func (s *Server) otaList(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	deviceID := q.Get("device_id")
+	list := []*otaJob{}
+	for _, o := range s.otas {
+		if deviceID == "" || o.DeviceID == deviceID {
+			list = append(list, o)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if q.Get("order") == "asc" {
+			return list[i].StartedAt.Before(list[j].StartedAt)
+		}
+		return list[i].StartedAt.After(list[j].StartedAt)
+	})
+	if limit, err := strconv.Atoi(q.Get("limit")); err == nil && limit > 0 && len(list) > limit {
+		list = list[:limit]
+	}
+	for _, o := range list {
+		o.advance()
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"ota": list})
+}
+
+func (s *Server) ota(w http.ResponseWriter, r *http.Request) (*otaJob, bool) {
+	id := r.PathValue("id")
+	o, ok := s.otas[id]
+	if !ok {
+		notFound(w, "ota", id)
+	}
+	return o, ok
+}
+
+func (s *Server) otaShow(w http.ResponseWriter, r *http.Request) {
+	o, ok := s.ota(w, r)
+	if !ok {
+		return
+	}
+	o.advance()
+	writeJSON(w, http.StatusOK, map[string]interface{}{"ota": o, "states": o.states})
+}
+
+func (s *Server) otaCancel(w http.ResponseWriter, r *http.Request) {
+	o, ok := s.ota(w, r)
+	if !ok {
+		return
+	}
+	if o.finished() {
+		writeError(w, http.StatusConflict, "ota already "+o.Status)
+		return
+	}
+	ended := now()
+	o.Status, o.EndedAt = otaCancelled, &ended
+	o.addState("cancel", "")
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,127 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package mockserver
+
+import (
+	"net/http"
+	"sort"
+	"time"
+)
+
+type onboarding struct {
+	ID               string     `json:"id"`
+	UniqueHardwareID string     `json:"unique_hardware_id"`
+	DeviceName       string     `json:"device_name"`
+	ConnectionType   string     `json:"connection_type"`
+	DeviceID         *string    `json:"device_id"`
+	UserID           string     `json:"user_id"`
+	BLEMac           string     `json:"ble_mac"`
+	CreatedAt        time.Time  `json:"created_at"`
+	ClaimedAt        time.Time  `json:"claimed_at"`
+	ProvisionedAt    *time.Time `json:"provisioned_at"`
+	EndedAt          *time.Time `json:"ended_at"`
+	FQBN             string     `json:"fqbn"`
+}
+
+func (s *Server) routeProvisioning() {
+	s.handle("POST /provisioning/v1/boards/register", s.boardRegister)
+	s.handle("POST /provisioning/v1/onboarding/claim", s.onboardingClaim)
+	s.handle("GET /provisioning/v1/onboarding", s.onboardingList)
+	s.handle("GET /provisioning/v1/onboarding/{id}", s.onboardingShow)
+	s.handle("DELETE /provisioning/v1/onboarding/{id}", s.onboardingDelete)
+}
+
+func (s *Server) boardRegister(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		UniqueHardwareID string `json:"unique_hardware_id"`
+		PublicKey        string `json:"public_key"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	if body.UniqueHardwareID == "" || body.PublicKey == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"err": "unique_hardware_id and public_key are required", "err_code": 1})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{})
+}
+
+func (s *Server) onboardingClaim(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		BLEMac         string `json:"ble_mac"`
+		BoardToken     string `json:"board_token"`
+		ConnectionType string `json:"connection_type"`
+		DeviceName     string `json:"device_name"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	if body.BoardToken == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"err": "board_token is required", "err_code": 1})
+		return
+	}
+	o := &onboarding{
+		ID:               newID(),
+		UniqueHardwareID: newSecret(),
+		DeviceName:       body.DeviceName,
+		ConnectionType:   body.ConnectionType,
+		UserID:           s.UserID,
+		BLEMac:           body.BLEMac,
+		CreatedAt:        now(),
+		ClaimedAt:        now(),
+		FQBN:             "arduino:renesas_uno:unor4wifi",
+	}
+	s.onboardings[o.ID] = o
+	writeJSON(w, http.StatusOK, map[string]string{"id": o.ID})
+}
+
+func (s *Server) onboardingList(w http.ResponseWriter, r *http.Request) {
+	list := []*onboarding{}
+	for _, o := range s.onboardings {
+		list = append(list, o)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	writeJSON(w, http.StatusOK, map[string]interface{}{"onboardings": list})
+}
+
+// onboardingShow completes the provisioning of the device,
+// as if it had connected to the cloud, the first time it's read.
+func (s *Server) onboardingShow(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	o, ok := s.onboardings[id]
+	if !ok {
+		notFound(w, "onboarding", id)
+		return
+	}
+	if o.DeviceID == nil {
+		d := s.newDevice(o.DeviceName, o.FQBN, o.UniqueHardwareID, "unor4wifi", o.ConnectionType)
+		ended := now()
+		o.DeviceID, o.ProvisionedAt, o.EndedAt = &d.ID, &ended, &ended
+	}
+	writeJSON(w, http.StatusOK, o)
+}
+
+func (s *Server) onboardingDelete(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, ok := s.onboardings[id]; !ok {
+		notFound(w, "onboarding", id)
+		return
+	}
+	delete(s.onboardings, id)
+	writeJSON(w, http.StatusOK, map[string]interface{}{})
+}
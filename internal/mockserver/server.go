@@ -0,0 +1,224 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package mockserver implements an in-memory fake of the subset of
+// Arduino Cloud APIs used by the CLI, to run end-to-end flows
+// without network access.
+package mockserver
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// tokenExpiration is the validity of the tokens issued by the server.
+const tokenExpiration = 300
+
+// Server is a fake Arduino Cloud server keeping its state in memory.
+// It implements http.Handler.
+type Server struct {
+	// Client and Secret, if set, are the only credentials
+	// accepted by the token endpoint.
+	Client string
+	Secret string
+	// UserID is the id of the user owning the resources.
+	UserID string
+
+	mu          sync.Mutex
+	mux         *http.ServeMux
+	tokens      map[string]bool
+	devices     map[string]*device
+	things      map[string]*thing
+	dashboards  map[string]*dashboard
+	otas        map[string]*otaJob
+	otaFailures map[string]string
+	templates   map[string]*storedTemplate
+	onboardings map[string]*onboarding
+}
+
+// New returns a server without any resource.
+func New() *Server {
+	s := &Server{
+		UserID:      newID(),
+		mux:         http.NewServeMux(),
+		tokens:      make(map[string]bool),
+		devices:     make(map[string]*device),
+		things:      make(map[string]*thing),
+		dashboards:  make(map[string]*dashboard),
+		otas:        make(map[string]*otaJob),
+		otaFailures: make(map[string]string),
+		templates:   make(map[string]*storedTemplate),
+		onboardings: make(map[string]*onboarding),
+	}
+	s.mux.HandleFunc("POST /iot/v1/clients/token", s.token)
+	s.routeDevices()
+	s.routeThings()
+	s.routeDashboards()
+	s.routeOta()
+	s.routeStorage()
+	s.routeProvisioning()
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logrus.Infof("Mock server: %s %s", r.Method, r.URL.String())
+	s.mux.ServeHTTP(w, r)
+}
+
+// handle registers an authenticated handler, executed with the state locked.
+func (s *Server) handle(pattern string, handler http.HandlerFunc) {
+	s.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !s.tokens[token] {
+			writeError(w, http.StatusUnauthorized, "invalid or missing token")
+			return
+		}
+		handler(w, r)
+	})
+}
+
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// Client credentials can be passed in the body or with basic auth
+	client, secret, ok := r.BasicAuth()
+	if !ok {
+		client, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if client == "" || (s.Client != "" && (client != s.Client || secret != s.Secret)) {
+		writeError(w, http.StatusUnauthorized, "invalid client credentials")
+		return
+	}
+
+	s.mu.Lock()
+	token := newSecret()
+	s.tokens[token] = true
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_in":   tokenExpiration,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logrus.Errorf("Mock server: encoding response: %v", err)
+	}
+}
+
+// writeError writes an error in the format used by Arduino Cloud.
+func writeError(w http.ResponseWriter, status int, detail string) {
+	writeJSON(w, status, map[string]interface{}{
+		"id":     newID(),
+		"code":   strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_"),
+		"status": status,
+		"detail": detail,
+	})
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return false
+	}
+	return true
+}
+
+func notFound(w http.ResponseWriter, kind, id string) {
+	writeError(w, http.StatusNotFound, fmt.Sprintf("%s %s not found", kind, id))
+}
+
+// queryList returns the values of a list query parameter, accepting
+// both repeated parameters and comma separated values.
+func queryList(r *http.Request, key string) []string {
+	var list []string
+	for _, v := range r.URL.Query()[key] {
+		for _, item := range strings.Split(v, ",") {
+			if item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
+
+// matchTags checks that tags contain all the passed filters,
+// expressed in the 'key:value' format.
+func matchTags(tags map[string]interface{}, filters []string) bool {
+	for _, f := range filters {
+		key, value, _ := strings.Cut(f, ":")
+		if v, ok := tags[key]; !ok || fmt.Sprint(v) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// decodeTags accepts tags both as a map and as a list of key-value pairs.
+func decodeTags(raw json.RawMessage) map[string]interface{} {
+	tags := make(map[string]interface{})
+	if len(raw) == 0 {
+		return tags
+	}
+	if err := json.Unmarshal(raw, &tags); err == nil {
+		return tags
+	}
+	var list []struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	}
+	if err := json.Unmarshal(raw, &list); err == nil {
+		for _, t := range list {
+			tags[t.Key] = t.Value
+		}
+	}
+	return tags
+}
+
+func newID() string {
+	return uuid.Must(uuid.NewV4()).String()
+}
+
+func newSecret() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
+}
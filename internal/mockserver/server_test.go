@@ -0,0 +1,159 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package mockserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/arduino/arduino-cloud-cli/internal/iot"
+	otaapi "github.com/arduino/arduino-cloud-cli/internal/ota-api"
+)
+
+type testClient struct {
+	t     *testing.T
+	url   string
+	token string
+}
+
+func newTestClient(t *testing.T, srv *httptest.Server) *testClient {
+	res, err := http.PostForm(srv.URL+"/iot/v1/clients/token", url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {"client"},
+		"client_secret": {"secret"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&token); err != nil {
+		t.Fatal(err)
+	}
+	return &testClient{t: t, url: srv.URL, token: token.AccessToken}
+}
+
+// do performs the request, checks its status and decodes the response in out.
+func (c *testClient) do(method, path string, body io.Reader, contentType string, status int, out interface{}) {
+	c.t.Helper()
+	req, err := http.NewRequest(method, c.url+path, body)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Content-Type", contentType)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	defer res.Body.Close()
+	b, _ := io.ReadAll(res.Body)
+	if res.StatusCode != status {
+		c.t.Fatalf("%s %s: expected status %d, but got %d: %s", method, path, status, res.StatusCode, b)
+	}
+	if out != nil {
+		if err := json.Unmarshal(b, out); err != nil {
+			c.t.Fatalf("%s %s: decoding response: %v", method, path, err)
+		}
+	}
+}
+
+func (c *testClient) json(method, path string, in interface{}, status int, out interface{}) {
+	c.t.Helper()
+	b, _ := json.Marshal(in)
+	c.do(method, path, bytes.NewReader(b), "application/json", status, out)
+}
+
+func TestUnauthorized(t *testing.T) {
+	srv := httptest.NewServer(New())
+	defer srv.Close()
+
+	c := &testClient{t: t, url: srv.URL, token: "wrong"}
+	c.do(http.MethodGet, "/iot/v2/devices", nil, "", http.StatusUnauthorized, nil)
+}
+
+func TestCreateBindOtaDeleteFlow(t *testing.T) {
+	srv := httptest.NewServer(New())
+	defer srv.Close()
+	c := newTestClient(t, srv)
+
+	var dev device
+	c.json(http.MethodPut, "/iot/v2/devices", map[string]string{
+		"name": "sensor", "fqbn": "arduino:samd:nano_33_iot", "type": "nano_33_iot", "serial": "ABC",
+	}, http.StatusCreated, &dev)
+	c.json(http.MethodPut, "/iot/v2/devices/"+dev.ID+"/tags", map[string]string{"key": "env", "value": "prod"}, http.StatusOK, nil)
+
+	var devices []device
+	c.do(http.MethodGet, "/iot/v2/devices?tags=env:prod", nil, "", http.StatusOK, &devices)
+	if len(devices) != 1 || devices[0].ID != dev.ID {
+		t.Fatalf("Expected device %s to be found by tags, but got %v", dev.ID, devices)
+	}
+
+	var th thing
+	c.json(http.MethodPut, "/iot/v2/things", map[string]interface{}{
+		"name": "greenhouse",
+		"properties": []map[string]string{
+			{"name": "temperature", "type": "TEMPERATURE_C", "permission": "READ_ONLY", "update_strategy": "ON_CHANGE"},
+		},
+	}, http.StatusCreated, &th)
+	c.json(http.MethodPost, "/iot/v2/things/"+th.ID, map[string]string{"device_id": dev.ID}, http.StatusOK, &th)
+	if th.DeviceID == nil || *th.DeviceID != dev.ID {
+		t.Fatalf("Expected thing to be bound to device %s", dev.ID)
+	}
+	c.do(http.MethodGet, "/iot/v2/devices/"+dev.ID, nil, "", http.StatusOK, &dev)
+	if dev.Thing == nil || dev.Thing.ID != th.ID {
+		t.Fatalf("Expected device to be bound to thing %s", th.ID)
+	}
+
+	// Upload an OTA and follow it with the OTA API client
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, _ := mw.CreateFormFile("ota_file", "firmware.ota")
+	fw.Write([]byte("firmware"))
+	mw.WriteField("async", "true")
+	mw.Close()
+	c.do(http.MethodPost, "/iot/v2/devices/"+dev.ID+"/ota", bytes.NewReader(body.Bytes()), mw.FormDataContentType(), http.StatusOK, nil)
+	c.do(http.MethodPost, "/iot/v2/devices/"+dev.ID+"/ota", bytes.NewReader(body.Bytes()), mw.FormDataContentType(), http.StatusConflict, nil)
+
+	t.Setenv("IOT_API_URL", srv.URL)
+	t.Setenv(iot.NoTokenCacheEnv, "1")
+	ota := otaapi.NewClient(&config.Credentials{Client: "client", Secret: "secret"})
+	wantStatuses := []string{otaInProgress, otaSucceeded, otaSucceeded}
+	for _, want := range wantStatuses {
+		list, err := ota.GetOtaLastStatusByDeviceID(dev.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list.Ota) != 1 || list.Ota[0].Status != want {
+			t.Fatalf("Expected ota status %s, but got %+v", want, list.Ota)
+		}
+	}
+
+	c.do(http.MethodDelete, "/iot/v2/things/"+th.ID, nil, "", http.StatusOK, nil)
+	c.do(http.MethodDelete, "/iot/v2/devices/"+dev.ID, nil, "", http.StatusOK, nil)
+	c.do(http.MethodGet, "/iot/v2/devices/"+dev.ID, nil, "", http.StatusNotFound, nil)
+}
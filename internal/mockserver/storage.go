@@ -0,0 +1,119 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package mockserver
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+type storedTemplate struct {
+	TemplateID string    `json:"template_id"`
+	Name       string    `json:"name"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+
+	archive []byte
+}
+
+func (s *Server) routeStorage() {
+	s.handle("POST /storage/template/archive/v1/", s.templateImport)
+	s.handle("GET /storage/template/archive/v1/{id}", s.templateExport)
+	s.handle("POST /storage/template/v1/list", s.templateList)
+	s.handle("GET /storage/template/v1/{id}", s.templateShow)
+}
+
+func (s *Server) templateImport(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid multipart form: %v", err))
+		return
+	}
+	file, header, err := r.FormFile("template")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "template file is required")
+		return
+	}
+	defer file.Close()
+	archive, err := io.ReadAll(file)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	name := strings.TrimSuffix(filepath.Base(header.Filename), filepath.Ext(header.Filename))
+	for _, t := range s.templates {
+		if t.Name == name {
+			writeError(w, http.StatusConflict, fmt.Sprintf("template %s already exists", name))
+			return
+		}
+	}
+	t := &storedTemplate{TemplateID: newID(), Name: name, CreatedAt: now(), UpdatedAt: now(), archive: archive}
+	s.templates[t.TemplateID] = t
+	writeJSON(w, http.StatusOK, map[string]string{
+		"message":     "template imported",
+		"name":        t.Name,
+		"template_id": t.TemplateID,
+	})
+}
+
+func (s *Server) template(w http.ResponseWriter, r *http.Request) (*storedTemplate, bool) {
+	id := r.PathValue("id")
+	t, ok := s.templates[id]
+	if !ok {
+		notFound(w, "template", id)
+	}
+	return t, ok
+}
+
+func (s *Server) templateExport(w http.ResponseWriter, r *http.Request) {
+	t, ok := s.template(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.tino", t.Name))
+	w.WriteHeader(http.StatusOK)
+	w.Write(t.archive)
+}
+
+func (s *Server) templateList(w http.ResponseWriter, r *http.Request) {
+	list := []*storedTemplate{}
+	for _, t := range s.templates {
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	writeJSON(w, http.StatusOK, map[string]interface{}{"templates": list})
+}
+
+func (s *Server) templateShow(w http.ResponseWriter, r *http.Request) {
+	if t, ok := s.template(w, r); ok {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"template_id": t.TemplateID,
+			"name":        t.Name,
+			"description": "",
+			"created_at":  t.CreatedAt,
+			"updated_at":  t.UpdatedAt,
+			"user_id":     s.UserID,
+		})
+	}
+}
@@ -0,0 +1,564 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package mockserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type thing struct {
+	ID              string                 `json:"id"`
+	Href            string                 `json:"href"`
+	Name            string                 `json:"name"`
+	DeviceID        *string                `json:"device_id,omitempty"`
+	DeviceName      *string                `json:"device_name,omitempty"`
+	DeviceFqbn      *string                `json:"device_fqbn,omitempty"`
+	DeviceType      *string                `json:"device_type,omitempty"`
+	Properties      []*property            `json:"properties,omitempty"`
+	PropertiesCount int                    `json:"properties_count"`
+	Tags            map[string]interface{} `json:"tags"`
+	Timezone        string                 `json:"timezone"`
+	WebhookURI      *string                `json:"webhook_uri,omitempty"`
+	UserID          string                 `json:"user_id"`
+	CreatedAt       time.Time              `json:"created_at"`
+	UpdatedAt       time.Time              `json:"updated_at"`
+
+	device *device
+}
+
+type property struct {
+	ID              string      `json:"id"`
+	Href            string      `json:"href"`
+	Name            string      `json:"name"`
+	VariableName    string      `json:"variable_name"`
+	Type            string      `json:"type"`
+	Permission      string      `json:"permission"`
+	UpdateStrategy  string      `json:"update_strategy"`
+	UpdateParameter *float64    `json:"update_parameter,omitempty"`
+	Persist         *bool       `json:"persist,omitempty"`
+	MinValue        *float64    `json:"min_value,omitempty"`
+	MaxValue        *float64    `json:"max_value,omitempty"`
+	Tag             *int64      `json:"tag,omitempty"`
+	ThingID         string      `json:"thing_id"`
+	ThingName       string      `json:"thing_name"`
+	LastValue       interface{} `json:"last_value"`
+	ValueUpdatedAt  *time.Time  `json:"value_updated_at,omitempty"`
+	CreatedAt       time.Time   `json:"created_at"`
+	UpdatedAt       time.Time   `json:"updated_at"`
+
+	history []sample
+}
+
+// sample is a value published on a property.
+type sample struct {
+	Time  time.Time   `json:"time"`
+	Value interface{} `json:"value"`
+}
+
+// propertyFields are the writable fields of a property.
+type propertyFields struct {
+	Name            *string  `json:"name"`
+	VariableName    *string  `json:"variable_name"`
+	Type            *string  `json:"type"`
+	Permission      *string  `json:"permission"`
+	UpdateStrategy  *string  `json:"update_strategy"`
+	UpdateParameter *float64 `json:"update_parameter"`
+	Persist         *bool    `json:"persist"`
+	MinValue        *float64 `json:"min_value"`
+	MaxValue        *float64 `json:"max_value"`
+	Tag             *int64   `json:"tag"`
+}
+
+// thingFields are the writable fields of a thing.
+type thingFields struct {
+	Name       *string          `json:"name"`
+	DeviceID   *string          `json:"device_id"`
+	Properties []propertyFields `json:"properties"`
+	Tags       json.RawMessage  `json:"tags"`
+	Timezone   *string          `json:"timezone"`
+	WebhookURI *string          `json:"webhook_uri"`
+}
+
+func (s *Server) routeThings() {
+	s.handle("PUT /iot/v2/things", s.thingCreate)
+	s.handle("GET /iot/v2/things", s.thingList)
+	s.handle("GET /iot/v2/things/{id}", s.thingShow)
+	s.handle("POST /iot/v2/things/{id}", s.thingUpdate)
+	s.handle("DELETE /iot/v2/things/{id}", s.thingDelete)
+	s.handle("PUT /iot/v2/things/{id}/clone", s.thingClone)
+	s.handle("GET /iot/v2/things/{id}/tags", s.thingTagsList)
+	s.handle("PUT /iot/v2/things/{id}/tags", s.thingTagsUpsert)
+	s.handle("DELETE /iot/v2/things/{id}/tags/{key}", s.thingTagsDelete)
+	s.handle("GET /iot/v2/things/{id}/properties", s.propertyList)
+	s.handle("PUT /iot/v2/things/{id}/properties", s.propertyCreate)
+	s.handle("GET /iot/v2/things/{id}/properties/{pid}", s.propertyShow)
+	s.handle("POST /iot/v2/things/{id}/properties/{pid}", s.propertyUpdate)
+	s.handle("DELETE /iot/v2/things/{id}/properties/{pid}", s.propertyDelete)
+	s.handle("PUT /iot/v2/things/{id}/properties/{pid}/publish", s.propertyPublish)
+	s.handle("GET /iot/v2/things/{id}/properties/{pid}/timeseries", s.propertyTimeseries)
+}
+
+func (s *Server) thingCreate(w http.ResponseWriter, r *http.Request) {
+	var body thingFields
+	if !readJSON(w, r, &body) {
+		return
+	}
+	if body.Name == nil || *body.Name == "" {
+		writeError(w, http.StatusBadRequest, "thing name is required")
+		return
+	}
+	id := newID()
+	t := &thing{
+		ID:        id,
+		Href:      "/iot/v1/things/" + id,
+		Name:      *body.Name,
+		Tags:      decodeTags(body.Tags),
+		Timezone:  "America/New_York",
+		UserID:    s.UserID,
+		CreatedAt: now(),
+		UpdatedAt: now(),
+	}
+	if !s.applyThingFields(w, r, t, &body) {
+		return
+	}
+	for i := range body.Properties {
+		p, ok := t.newProperty(w, &body.Properties[i])
+		if !ok {
+			return
+		}
+		t.Properties = append(t.Properties, p)
+	}
+	t.PropertiesCount = len(t.Properties)
+	s.things[id] = t
+	writeJSON(w, http.StatusCreated, t)
+}
+
+// applyThingFields updates the thing with the passed fields, binding
+// its device if requested. A device already bound to another thing
+// can only be bound when the 'force' query parameter is set.
+func (s *Server) applyThingFields(w http.ResponseWriter, r *http.Request, t *thing, body *thingFields) bool {
+	if body.DeviceID != nil && *body.DeviceID != "" {
+		d, ok := s.devices[*body.DeviceID]
+		if !ok {
+			notFound(w, "device", *body.DeviceID)
+			return false
+		}
+		if d.Thing != nil && d.Thing.ID != t.ID {
+			if force, _ := strconv.ParseBool(r.URL.Query().Get("force")); !force {
+				writeError(w, http.StatusPreconditionFailed, fmt.Sprintf("device %s already bound to thing %s", d.ID, d.Thing.ID))
+				return false
+			}
+			if other, ok := s.things[d.Thing.ID]; ok {
+				other.unbind()
+			}
+		}
+		t.unbind()
+		t.bind(d)
+	} else if body.DeviceID != nil {
+		t.unbind()
+	}
+	if body.Name != nil {
+		t.Name = *body.Name
+		if t.device != nil {
+			t.device.Thing.Name = t.Name
+		}
+	}
+	if body.Timezone != nil {
+		t.Timezone = *body.Timezone
+	}
+	if body.WebhookURI != nil {
+		t.WebhookURI = body.WebhookURI
+	}
+	return true
+}
+
+func (t *thing) bind(d *device) {
+	t.device = d
+	t.DeviceID, t.DeviceName, t.DeviceType = &d.ID, &d.Name, &d.Type
+	if d.Fqbn != "" {
+		t.DeviceFqbn = &d.Fqbn
+	}
+	d.Thing = &thingSummary{ID: t.ID, Href: t.Href, Name: t.Name, UserID: t.UserID}
+}
+
+func (t *thing) unbind() {
+	if t.device != nil {
+		t.device.Thing = nil
+	}
+	t.device = nil
+	t.DeviceID, t.DeviceName, t.DeviceFqbn, t.DeviceType = nil, nil, nil, nil
+}
+
+func (s *Server) thingList(w http.ResponseWriter, r *http.Request) {
+	ids := queryList(r, "ids")
+	tags := queryList(r, "tags")
+	deviceID := r.URL.Query().Get("device_id")
+	showProperties, _ := strconv.ParseBool(r.URL.Query().Get("show_properties"))
+
+	idSet := make(map[string]bool)
+	for _, id := range ids {
+		idSet[id] = true
+	}
+	list := []thing{}
+	for _, t := range s.things {
+		if len(ids) > 0 && !idSet[t.ID] {
+			continue
+		}
+		if deviceID != "" && (t.DeviceID == nil || *t.DeviceID != deviceID) {
+			continue
+		}
+		if !matchTags(t.Tags, tags) {
+			continue
+		}
+		item := *t
+		if !showProperties {
+			item.Properties = nil
+		}
+		list = append(list, item)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) thing(w http.ResponseWriter, r *http.Request) (*thing, bool) {
+	id := r.PathValue("id")
+	t, ok := s.things[id]
+	if !ok {
+		notFound(w, "thing", id)
+	}
+	return t, ok
+}
+
+func (s *Server) thingShow(w http.ResponseWriter, r *http.Request) {
+	if t, ok := s.thing(w, r); ok {
+		writeJSON(w, http.StatusOK, t)
+	}
+}
+
+func (s *Server) thingUpdate(w http.ResponseWriter, r *http.Request) {
+	t, ok := s.thing(w, r)
+	if !ok {
+		return
+	}
+	var body thingFields
+	if !readJSON(w, r, &body) {
+		return
+	}
+	if !s.applyThingFields(w, r, t, &body) {
+		return
+	}
+	if len(body.Tags) > 0 {
+		t.Tags = decodeTags(body.Tags)
+	}
+	t.UpdatedAt = now()
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (s *Server) thingDelete(w http.ResponseWriter, r *http.Request) {
+	if t, ok := s.thing(w, r); ok {
+		t.unbind()
+		delete(s.things, t.ID)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func (s *Server) thingClone(w http.ResponseWriter, r *http.Request) {
+	t, ok := s.thing(w, r)
+	if !ok {
+		return
+	}
+	var body struct {
+		Name        string `json:"name"`
+		IncludeTags *bool  `json:"include_tags"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	id := newID()
+	clone := &thing{
+		ID:        id,
+		Href:      "/iot/v1/things/" + id,
+		Name:      body.Name,
+		Tags:      make(map[string]interface{}),
+		Timezone:  t.Timezone,
+		UserID:    s.UserID,
+		CreatedAt: now(),
+		UpdatedAt: now(),
+	}
+	if body.IncludeTags != nil && *body.IncludeTags {
+		for k, v := range t.Tags {
+			clone.Tags[k] = v
+		}
+	}
+	for _, p := range t.Properties {
+		c := *p
+		c.ID = newID()
+		c.Href = "/iot/v1/things/" + id + "/properties/" + c.ID
+		c.ThingID, c.ThingName = id, clone.Name
+		c.LastValue, c.ValueUpdatedAt, c.history = nil, nil, nil
+		clone.Properties = append(clone.Properties, &c)
+	}
+	clone.PropertiesCount = len(clone.Properties)
+	s.things[id] = clone
+	writeJSON(w, http.StatusOK, clone)
+}
+
+func (s *Server) thingTagsList(w http.ResponseWriter, r *http.Request) {
+	if t, ok := s.thing(w, r); ok {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"tags": tagList(t.Tags)})
+	}
+}
+
+func (s *Server) thingTagsUpsert(w http.ResponseWriter, r *http.Request) {
+	t, ok := s.thing(w, r)
+	if !ok {
+		return
+	}
+	var tag struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	}
+	if !readJSON(w, r, &tag) {
+		return
+	}
+	t.Tags[tag.Key] = tag.Value
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) thingTagsDelete(w http.ResponseWriter, r *http.Request) {
+	if t, ok := s.thing(w, r); ok {
+		delete(t.Tags, r.PathValue("key"))
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func (t *thing) newProperty(w http.ResponseWriter, f *propertyFields) (*property, bool) {
+	if f.Name == nil || f.Type == nil || f.Permission == nil || f.UpdateStrategy == nil {
+		writeError(w, http.StatusBadRequest, "property name, type, permission and update_strategy are required")
+		return nil, false
+	}
+	id := newID()
+	p := &property{
+		ID:        id,
+		Href:      "/iot/v1/things/" + t.ID + "/properties/" + id,
+		ThingID:   t.ID,
+		ThingName: t.Name,
+		CreatedAt: now(),
+	}
+	p.apply(f)
+	if p.VariableName == "" {
+		p.VariableName = p.Name
+	}
+	return p, true
+}
+
+func (p *property) apply(f *propertyFields) {
+	if f.Name != nil {
+		p.Name = *f.Name
+	}
+	if f.VariableName != nil {
+		p.VariableName = *f.VariableName
+	}
+	if f.Type != nil {
+		p.Type = *f.Type
+	}
+	if f.Permission != nil {
+		p.Permission = *f.Permission
+	}
+	if f.UpdateStrategy != nil {
+		p.UpdateStrategy = *f.UpdateStrategy
+	}
+	if f.UpdateParameter != nil {
+		p.UpdateParameter = f.UpdateParameter
+	}
+	if f.Persist != nil {
+		p.Persist = f.Persist
+	}
+	if f.MinValue != nil {
+		p.MinValue = f.MinValue
+	}
+	if f.MaxValue != nil {
+		p.MaxValue = f.MaxValue
+	}
+	if f.Tag != nil {
+		p.Tag = f.Tag
+	}
+	p.UpdatedAt = now()
+}
+
+func (s *Server) property(w http.ResponseWriter, r *http.Request) (*thing, int, bool) {
+	t, ok := s.thing(w, r)
+	if !ok {
+		return nil, 0, false
+	}
+	pid := r.PathValue("pid")
+	for i, p := range t.Properties {
+		if p.ID == pid {
+			return t, i, true
+		}
+	}
+	notFound(w, "property", pid)
+	return nil, 0, false
+}
+
+func (s *Server) propertyList(w http.ResponseWriter, r *http.Request) {
+	if t, ok := s.thing(w, r); ok {
+		props := t.Properties
+		if props == nil {
+			props = []*property{}
+		}
+		writeJSON(w, http.StatusOK, props)
+	}
+}
+
+func (s *Server) propertyCreate(w http.ResponseWriter, r *http.Request) {
+	t, ok := s.thing(w, r)
+	if !ok {
+		return
+	}
+	var body propertyFields
+	if !readJSON(w, r, &body) {
+		return
+	}
+	p, ok := t.newProperty(w, &body)
+	if !ok {
+		return
+	}
+	for _, other := range t.Properties {
+		if other.VariableName == p.VariableName {
+			writeError(w, http.StatusConflict, fmt.Sprintf("property %s already exists", p.VariableName))
+			return
+		}
+	}
+	t.Properties = append(t.Properties, p)
+	t.PropertiesCount = len(t.Properties)
+	writeJSON(w, http.StatusCreated, p)
+}
+
+func (s *Server) propertyShow(w http.ResponseWriter, r *http.Request) {
+	if t, i, ok := s.property(w, r); ok {
+		writeJSON(w, http.StatusOK, t.Properties[i])
+	}
+}
+
+func (s *Server) propertyUpdate(w http.ResponseWriter, r *http.Request) {
+	t, i, ok := s.property(w, r)
+	if !ok {
+		return
+	}
+	var body propertyFields
+	if !readJSON(w, r, &body) {
+		return
+	}
+	t.Properties[i].apply(&body)
+	writeJSON(w, http.StatusOK, t.Properties[i])
+}
+
+func (s *Server) propertyDelete(w http.ResponseWriter, r *http.Request) {
+	if t, i, ok := s.property(w, r); ok {
+		t.Properties = append(t.Properties[:i], t.Properties[i+1:]...)
+		t.PropertiesCount = len(t.Properties)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func (s *Server) propertyPublish(w http.ResponseWriter, r *http.Request) {
+	t, i, ok := s.property(w, r)
+	if !ok {
+		return
+	}
+	var body struct {
+		Value interface{} `json:"value"`
+	}
+	if !readJSON(w, r, &body) {
+		return
+	}
+	p := t.Properties[i]
+	ts := now()
+	p.LastValue, p.ValueUpdatedAt = body.Value, &ts
+	p.history = append(p.history, sample{Time: ts, Value: body.Value})
+	w.WriteHeader(http.StatusOK)
+}
+
+// PublishValue records a value of a property at the passed time,
+// as if it had been sent by the device.
+func (s *Server) PublishValue(thingID, propertyID string, value interface{}, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.things[thingID]
+	if !ok {
+		return fmt.Errorf("thing %s not found", thingID)
+	}
+	for _, p := range t.Properties {
+		if p.ID == propertyID {
+			at = at.UTC()
+			p.history = append(p.history, sample{Time: at, Value: value})
+			sort.Slice(p.history, func(i, j int) bool { return p.history[i].Time.Before(p.history[j].Time) })
+			last := p.history[len(p.history)-1]
+			p.LastValue, p.ValueUpdatedAt = last.Value, &last.Time
+			return nil
+		}
+	}
+	return fmt.Errorf("property %s not found", propertyID)
+}
+
+func (s *Server) propertyTimeseries(w http.ResponseWriter, r *http.Request) {
+	t, i, ok := s.property(w, r)
+	if !ok {
+		return
+	}
+	p := t.Properties[i]
+	q := r.URL.Query()
+	from, to := time.Time{}, now().Add(time.Second)
+	var err error
+	if v := q.Get("from"); v != "" {
+		if from, err = time.Parse(time.RFC3339, v); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid from: "+err.Error())
+			return
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if to, err = time.Parse(time.RFC3339, v); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid to: "+err.Error())
+			return
+		}
+	}
+
+	data := []sample{}
+	for _, smp := range p.history {
+		if !smp.Time.Before(from) && smp.Time.Before(to) {
+			data = append(data, smp)
+		}
+	}
+	if desc, _ := strconv.ParseBool(q.Get("desc")); desc {
+		sort.Slice(data, func(a, b int) bool { return data[a].Time.After(data[b].Time) })
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":           p.ID,
+		"count_values": len(data),
+		"from_date":    from,
+		"to_date":      to,
+		"interval":     1,
+		"data":         data,
+	})
+}
@@ -28,6 +28,7 @@ import (
 	"time"
 
 	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/arduino/arduino-cloud-cli/internal/httpclient"
 	"github.com/arduino/arduino-cloud-cli/internal/iot"
 	"golang.org/x/oauth2"
 )
@@ -39,6 +40,7 @@ const (
 
 var ErrAlreadyInProgress = fmt.Errorf("already in progress")
 var ErrAlreadyCancelled = fmt.Errorf("already cancelled")
+var ErrNotFound = fmt.Errorf("not found")
 
 type OtaApiClient struct {
 	client       *http.Client
@@ -48,10 +50,10 @@ type OtaApiClient struct {
 }
 
 func NewClient(credentials *config.Credentials) *OtaApiClient {
-	host := iot.GetArduinoAPIBaseURL()
+	host := iot.GetArduinoAPIBaseURLFor(credentials)
 	tokenSource := iot.NewUserTokenSource(credentials.Client, credentials.Secret, host, credentials.Organization)
 	return &OtaApiClient{
-		client:       &http.Client{},
+		client:       httpclient.New(),
 		src:          tokenSource,
 		host:         host,
 		organization: credentials.Organization,
@@ -203,12 +205,15 @@ func (c *OtaApiClient) GetOtaStatusByDeviceID(deviceID string, limit int, order
 		}
 		return &otaResponse, nil
 	} else if res.StatusCode == 404 || res.StatusCode == 400 {
-		return nil, fmt.Errorf("device-id %s not found", deviceID)
+		// Devices that never received an OTA are not known by the OTA service
+		return nil, fmt.Errorf("device-id %s %w", deviceID, ErrNotFound)
 	} else if res.StatusCode == 409 {
 		return nil, ErrAlreadyInProgress
 	}
-
-	return nil, err
+	if err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("retrieving ota status of device-id %s: unexpected status %s", deviceID, res.Status)
 }
 
 func (c *OtaApiClient) CancelOta(otaid string) (bool, error) {
@@ -29,6 +29,15 @@ import (
 
 const progressBarMultiplier = 2
 
+// OTA statuses.
+const (
+	StatusPending    = "pending"
+	StatusInProgress = "in_progress"
+	StatusSucceeded  = "succeeded"
+	StatusFailed     = "failed"
+	StatusCancelled  = "cancelled"
+)
+
 type (
 	OtaStatusResponse struct {
 		Ota    Ota     `json:"ota"`
@@ -103,6 +112,15 @@ func (r OtaStatusList) String() string {
 	return t.Render()
 }
 
+// IsTerminal reports whether the OTA has ended, either successfully or not.
+func (o Ota) IsTerminal() bool {
+	switch strings.ToLower(o.Status) {
+	case StatusSucceeded, StatusFailed, StatusCancelled:
+		return true
+	}
+	return false
+}
+
 func (o Ota) MapStatus() string {
 	return upperCaseFirst(o.Status)
 }
@@ -190,6 +208,16 @@ func (r OtaStatusDetail) String() string {
 	return output
 }
 
+// Describe returns a human readable description of the state,
+// including the download progress for the fetch state.
+func (s State) Describe(firmwareSize int64) string {
+	desc := upperCaseFirst(s.State)
+	if data := formatStateData(s.State, s.StateData, firmwareSize, false); data != "" {
+		desc += " " + data
+	}
+	return desc
+}
+
 func hasReachedFlashState(states []State, succeeded bool) bool {
 	if succeeded {
 		return true
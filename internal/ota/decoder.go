@@ -22,12 +22,14 @@ import (
 	"crypto/sha256"
 	"encoding/binary"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"hash/crc32"
 	"io"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/arduino/arduino-cli/table"
 	"github.com/arduino/arduino-cloud-cli/internal/lzss"
@@ -36,6 +38,7 @@ import (
 var (
 	ErrCRC32Mismatch  = fmt.Errorf("CRC32 mismatch")
 	ErrLengthMismatch = fmt.Errorf("file length mismatch")
+	ErrDeltaUnpack    = fmt.Errorf("delta OTA contains a patch: the firmware can only be obtained applying it to the base firmware")
 
 	boardTypes = map[uint32]string{
 		0x45535033: "ESP32",
@@ -90,8 +93,10 @@ type OtaMetadata struct {
 	PID            string
 	IsArduinoBoard bool
 	Compressed     bool
-	PayloadSHA256  string // SHA256 of the payload (decompressed if compressed). This is the SHA256 as seen ny the board.
-	OtaSHA256      string // SHA256 of the whole file (header + payload).
+	PayloadSHA256  string     // SHA256 of the payload (decompressed if compressed). This is the SHA256 as seen ny the board.
+	OtaSHA256      string     // SHA256 of the whole file (header + payload).
+	Delta          *DeltaInfo `json:",omitempty"` // Set for delta OTAs, whose payload is a patch to be applied to the firmware running on the board.
+	Manifest       *Manifest  `json:",omitempty"` // Manifest of the firmware, if any. Not part of the header, see FindManifest.
 }
 
 func (r OtaMetadata) Data() interface{} {
@@ -116,6 +121,27 @@ func (r OtaMetadata) String() string {
 	t.AddRow([]interface{}{"Compressed", strconv.FormatBool(r.Compressed)}...)
 	t.AddRow([]interface{}{"Payload SHA256", r.PayloadSHA256}...)
 	t.AddRow([]interface{}{"OTA SHA256", r.OtaSHA256}...)
+	if d := r.Delta; d != nil {
+		t.AddRow([]interface{}{"Delta From SHA256", d.OldSHA256}...)
+		t.AddRow([]interface{}{"Delta From Length", fmt.Sprintf("%d bytes", d.OldSize)}...)
+		t.AddRow([]interface{}{"Delta To SHA256", d.NewSHA256}...)
+		t.AddRow([]interface{}{"Delta To Length", fmt.Sprintf("%d bytes", d.NewSize)}...)
+	}
+	if m := r.Manifest; m != nil {
+		t.AddRow([]interface{}{"Version", m.Version}...)
+		if m.Commit != "" {
+			t.AddRow([]interface{}{"Commit", m.Commit}...)
+		}
+		if m.BuildDate != nil {
+			t.AddRow([]interface{}{"Build Date", m.BuildDate.Format(time.RFC3339)}...)
+		}
+		if len(m.FQBNs) > 0 {
+			t.AddRow([]interface{}{"Target FQBNs", strings.Join(m.FQBNs, ", ")}...)
+		}
+		if m.MinFromVersion != "" {
+			t.AddRow([]interface{}{"Min From Version", m.MinFromVersion}...)
+		}
+	}
 
 	return t.Render()
 }
@@ -123,15 +149,25 @@ func (r OtaMetadata) String() string {
 // Read header starting from the first byte of the file
 func readHeader(file OtaFileReader) ([]byte, error) {
 	bytes := make([]byte, HeaderSize)
-	_, err := file.Read(bytes)
-	if err != nil {
+	if _, err := io.ReadFull(file, bytes); errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("file too short to contain an OTA header: %w", err)
+	} else if err != nil {
 		return nil, err
 	}
 	return bytes, nil
 }
 
+// fileHashes contains the values computed reading an OTA file.
+type fileHashes struct {
+	crc32         uint32
+	payloadSHA256 string
+	otaSHA256     string
+	length        uint32
+	delta         *DeltaInfo // Nil if the file is not a delta OTA
+}
+
 // Function will compute OTA CRC32 and file SHA256 hash, starting from a reader that has already extracted the header (so pointing to payload).
-func computeFileHashes(file OtaFileReader, compressed bool, otaHeader []byte) (uint32, string, string, uint32, error) {
+func computeFileHashes(file OtaFileReader, version Version, otaHeader []byte) (*fileHashes, error) {
 	crcSum := crc32.NewIEEE()
 	payload := bytes.Buffer{}
 	// Length of remaining header + payload excluding the fields LENGTH and CRC32.
@@ -145,7 +181,7 @@ func computeFileHashes(file OtaFileReader, compressed bool, otaHeader []byte) (u
 	for {
 		n, err := file.Read(buf)
 		if err != nil && err != io.EOF {
-			return 0, "", "", 0, err
+			return nil, err
 		}
 		if n == 0 {
 			break
@@ -155,18 +191,33 @@ func computeFileHashes(file OtaFileReader, compressed bool, otaHeader []byte) (u
 		payload.Write(buf[:n])
 	}
 
-	payloadSHA, otaSHA := computeBinarySha256(compressed, payload.Bytes(), otaHeader)
+	payloadSHA, otaSHA, delta := computeBinarySha256(version, payload.Bytes(), otaHeader)
 
-	return crcSum.Sum32(), payloadSHA, otaSHA, uint32(computedLength), nil
+	return &fileHashes{
+		crc32:         crcSum.Sum32(),
+		payloadSHA256: payloadSHA,
+		otaSHA256:     otaSHA,
+		length:        uint32(computedLength),
+		delta:         delta,
+	}, nil
 }
 
-func computeBinarySha256(compressed bool, payload []byte, otaHeader []byte) (string, string) {
-	var computedShaBytes [32]byte
-	if compressed {
-		decompressed := lzss.Decompress(payload)
-		computedShaBytes = sha256.Sum256(decompressed)
-	} else {
-		computedShaBytes = sha256.Sum256(payload)
+// computeBinarySha256 returns the SHA256 of the payload as seen by the board, and the SHA256 of
+// the whole file. For delta OTAs, the payload SHA256 is the one of the patch: the SHA256 of the
+// firmware produced by the patch is only declared by the patch itself, see DeltaInfo.
+func computeBinarySha256(version Version, payload []byte, otaHeader []byte) (string, string, *DeltaInfo) {
+	decompressed := payload
+	if version.Compression {
+		decompressed = lzss.Decompress(payload)
+	}
+	computedShaBytes := sha256.Sum256(decompressed)
+	payloadSHA := hex.EncodeToString(computedShaBytes[:])
+
+	var delta *DeltaInfo
+	if version.Delta {
+		if info, err := ReadDeltaInfo(decompressed); err == nil {
+			delta = info
+		}
 	}
 
 	// Whole file SHA256 (header + payload)
@@ -174,7 +225,7 @@ func computeBinarySha256(compressed bool, payload []byte, otaHeader []byte) (str
 	otaSHA.Write(otaHeader)
 	otaSHA.Write(payload)
 
-	return hex.EncodeToString(computedShaBytes[:]), hex.EncodeToString(otaSHA.Sum(nil))
+	return payloadSHA, hex.EncodeToString(otaSHA.Sum(nil)), delta
 }
 
 func extractXID(buff []byte) string {
@@ -230,22 +281,22 @@ func DecodeOtaFirmwareHeader(otafileptr OtaFileReader) (*OtaMetadata, error) {
 	version := decodeVersion(header[OffsetVersion:OffsetPayload])
 
 	// Read full binary file (buffered), starting from 8th byte (magic number)
-	computedsum, fileSha, otaSha, computedLength, err := computeFileHashes(otafileptr, version.Compression, header)
+	hashes, err := computeFileHashes(otafileptr, version, header)
 	if err != nil {
 		return nil, err
 	}
 
 	// File sanity check. Validate CRC32 and length declared in header with computed values.
-	if computedsum != readsum {
+	if hashes.crc32 != readsum {
 		return nil, ErrCRC32Mismatch
 	}
-	if computedLength != lengthInt {
+	if hashes.length != lengthInt {
 		return nil, ErrLengthMismatch
 	}
 
 	return &OtaMetadata{
 		Length:         lengthInt,
-		CRC32:          computedsum,
+		CRC32:          hashes.crc32,
 		BoardType:      boardType,
 		MagicNumber:    completeMagicNumber,
 		IsArduinoBoard: isArduino,
@@ -253,11 +304,50 @@ func DecodeOtaFirmwareHeader(otafileptr OtaFileReader) (*OtaMetadata, error) {
 		VID:            vid,
 		FQBN:           fqbn,
 		Compressed:     version.Compression,
-		PayloadSHA256:  fileSha,
-		OtaSHA256:      otaSha,
+		PayloadSHA256:  hashes.payloadSHA256,
+		OtaSHA256:      hashes.otaSHA256,
+		Delta:          hashes.delta,
 	}, nil
 }
 
+// Unpack verifies the passed ota file and returns the binary it contains,
+// decompressed if needed, along with the decoded header.
+// Delta OTAs can't be unpacked, see ApplyDelta.
+func Unpack(otaFile io.Reader) ([]byte, *OtaMetadata, error) {
+	payload, header, err := readPayload(otaFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	if header.Delta != nil {
+		return nil, header, ErrDeltaUnpack
+	}
+	return payload, header, nil
+}
+
+// readPayload decodes the header of the passed ota file, checking
+// its CRC32 and length, and returns its decompressed payload.
+func readPayload(otaFile io.Reader) ([]byte, *OtaMetadata, error) {
+	data, err := io.ReadAll(otaFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	header, err := DecodeOtaFirmwareHeader(io.NopCloser(bytes.NewReader(data)))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(data) < HeaderSize {
+		return nil, nil, errors.New("file too short to contain an OTA header")
+	}
+	payload := data[HeaderSize:]
+	if header.Compressed {
+		payload, err = io.ReadAll(lzss.NewReader(bytes.NewReader(payload)))
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot decompress payload: %w", err)
+		}
+	}
+	return payload, header, nil
+}
+
 func getBoardType(magicNumber uint32, pid string) (string, *string, bool) {
 	baordType := "UNKNOWN"
 	if t, ok := boardTypes[magicNumber]; ok {
@@ -18,10 +18,15 @@
 package ota
 
 import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"os"
 	"testing"
 
 	"github.com/arduino/arduino-cloud-cli/internal/boardpids"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDecodeHeader(t *testing.T) {
@@ -64,3 +69,42 @@ func TestDecodeEsp32Header(t *testing.T) {
 	assert.Equal(t, "ESP32", header.BoardType)
 
 }
+
+func TestUnpack(t *testing.T) {
+	for _, name := range []string{"cloud", "blink"} {
+		bin, err := os.ReadFile("testdata/" + name + ".bin")
+		require.NoError(t, err)
+		otaFile, err := os.ReadFile("testdata/" + name + ".ota")
+		require.NoError(t, err)
+
+		out, header, err := Unpack(bytes.NewReader(otaFile))
+		require.NoError(t, err, name)
+		assert.True(t, header.Compressed)
+		assert.True(t, bytes.Equal(bin, out), name)
+	}
+
+	// Corrupted files are refused
+	otaFile, err := os.ReadFile("testdata/cloud.ota")
+	require.NoError(t, err)
+	otaFile[len(otaFile)/2] ^= 0xFF
+	_, _, err = Unpack(bytes.NewReader(otaFile))
+	assert.ErrorIs(t, err, ErrCRC32Mismatch)
+	_, _, err = Unpack(bytes.NewReader(otaFile[:len(otaFile)-1]))
+	assert.Error(t, err)
+
+	// Files shorter than the header are refused, even if their length and CRC32 match
+	short := make([]byte, 8)
+	binary.LittleEndian.PutUint32(short[0:4], HeaderSize-8)
+	binary.LittleEndian.PutUint32(short[4:8], crc32.ChecksumIEEE(make([]byte, HeaderSize-8)))
+	_, _, err = Unpack(bytes.NewReader(short))
+	assert.Error(t, err)
+
+	// Delta files can't be unpacked
+	old, err := os.ReadFile("testdata/cloud.bin")
+	require.NoError(t, err)
+	var delta bytes.Buffer
+	require.NoError(t, NewEncoder(&delta, "2341", "8057").EncodeDelta(old, old))
+	_, header, err := Unpack(&delta)
+	assert.ErrorIs(t, err, ErrDeltaUnpack)
+	assert.NotNil(t, header.Delta)
+}
@@ -0,0 +1,223 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ota
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/arduino/arduino-cloud-cli/internal/lzss"
+)
+
+// A delta OTA contains the patch transforming the firmware running
+// on the board into the new firmware, instead of the whole new firmware.
+// It is marked by the Delta flag of the header Version, and its
+// payload is the LZSS compressed patch, whose layout is:
+//
+//	MAGIC "ADLT" (4 B) | OLD SIZE (4 B) | NEW SIZE (4 B) | OLD SHA256 (32 B) | NEW SHA256 (32 B) | ENTRIES
+//
+// Each entry is:
+//
+//	COPY LEN (4 B) | ADD LEN (4 B) | EXTRA LEN (4 B) | SEEK (4 B, signed) | ADD (ADD LEN B) | EXTRA (EXTRA LEN B)
+//
+// Starting from the beginning of the old firmware, COPY LEN bytes of the old firmware
+// are copied, ADD bytes are added byte-wise to the following old firmware bytes,
+// EXTRA bytes are copied as they are, then the position in the old firmware
+// is moved by SEEK bytes. All integers are little endian.
+const deltaMagic = "ADLT"
+
+const (
+	deltaHeaderSize = 4 + 4 + 4 + sha256.Size + sha256.Size
+	deltaEntrySize  = 4 * 4
+
+	// deltaMinCopy is the shortest run of unchanged bytes stored
+	// as a copy instead of as zero add bytes. Shorter runs
+	// cost less than the header of a new entry.
+	deltaMinCopy = 2 * deltaEntrySize
+)
+
+var ErrDeltaBaseMismatch = errors.New("delta patch does not apply to this firmware")
+
+// DeltaInfo describes a delta patch.
+type DeltaInfo struct {
+	OldSize   uint32
+	NewSize   uint32
+	OldSHA256 string // SHA256 of the firmware the patch applies to
+	NewSHA256 string // SHA256 of the firmware produced by the patch
+}
+
+// Diff returns the patch transforming old into new.
+func Diff(old, new []byte) []byte {
+	var patch bytes.Buffer
+	oldSum, newSum := sha256.Sum256(old), sha256.Sum256(new)
+	patch.WriteString(deltaMagic)
+	binary.Write(&patch, binary.LittleEndian, uint32(len(old)))
+	binary.Write(&patch, binary.LittleEndian, uint32(len(new)))
+	patch.Write(oldSum[:])
+	patch.Write(newSum[:])
+
+	for _, e := range bsdiff(old, new) {
+		writeDeltaEntry(&patch, e)
+	}
+	return patch.Bytes()
+}
+
+// writeDeltaEntry writes a bsdiff entry, turning the long runs of zeros of
+// its add bytes, which are unchanged bytes of the old firmware, into copies:
+// bsdiff relies on bzip2 to shrink them, while lzss compresses them poorly.
+func writeDeltaEntry(w io.Writer, e deltaEntry) {
+	add := e.Add
+	for {
+		copyLen := 0
+		for copyLen < len(add) && add[copyLen] == 0 {
+			copyLen++
+		}
+		add = add[copyLen:]
+		addLen := zeroRun(add, deltaMinCopy)
+
+		ctrl := [4]int32{int32(copyLen), int32(addLen), 0, 0}
+		last := addLen == len(add)
+		if last {
+			ctrl[2], ctrl[3] = int32(len(e.Extra)), int32(e.Seek)
+		}
+		binary.Write(w, binary.LittleEndian, ctrl)
+		w.Write(add[:addLen])
+		if last {
+			w.Write(e.Extra)
+			return
+		}
+		add = add[addLen:]
+	}
+}
+
+// zeroRun returns the index of the first run of at least n zeros of buf,
+// or len(buf) if there is none.
+func zeroRun(buf []byte, n int) int {
+	run := 0
+	for i, b := range buf {
+		if b != 0 {
+			run = 0
+			continue
+		}
+		if run++; run == n {
+			return i + 1 - n
+		}
+	}
+	return len(buf)
+}
+
+// ReadDeltaInfo decodes the header of a patch.
+func ReadDeltaInfo(patch []byte) (*DeltaInfo, error) {
+	if len(patch) < deltaHeaderSize || string(patch[:4]) != deltaMagic {
+		return nil, errors.New("not a delta patch")
+	}
+	return &DeltaInfo{
+		OldSize:   binary.LittleEndian.Uint32(patch[4:8]),
+		NewSize:   binary.LittleEndian.Uint32(patch[8:12]),
+		OldSHA256: hex.EncodeToString(patch[12 : 12+sha256.Size]),
+		NewSHA256: hex.EncodeToString(patch[12+sha256.Size : deltaHeaderSize]),
+	}, nil
+}
+
+// Patch applies the patch to old, returning the new firmware.
+// Both the old and the resulting firmware are checked against
+// the hashes contained in the patch.
+func Patch(old, patch []byte) ([]byte, error) {
+	info, err := ReadDeltaInfo(patch)
+	if err != nil {
+		return nil, err
+	}
+	oldSum := sha256.Sum256(old)
+	if int(info.OldSize) != len(old) || hex.EncodeToString(oldSum[:]) != info.OldSHA256 {
+		return nil, ErrDeltaBaseMismatch
+	}
+
+	corrupted := errors.New("delta patch corrupted")
+	new := make([]byte, 0, info.NewSize)
+	r := bytes.NewReader(patch[deltaHeaderSize:])
+	oldPos := 0
+	for r.Len() > 0 {
+		var ctrl struct {
+			Copy, Add, Extra uint32
+			Seek             int32
+		}
+		if err := binary.Read(r, binary.LittleEndian, &ctrl); err != nil {
+			return nil, corrupted
+		}
+		if oldPos < 0 || oldPos+int(ctrl.Copy)+int(ctrl.Add) > len(old) ||
+			len(new)+int(ctrl.Copy)+int(ctrl.Add)+int(ctrl.Extra) > int(info.NewSize) {
+			return nil, corrupted
+		}
+		new = append(new, old[oldPos:oldPos+int(ctrl.Copy)]...)
+		oldPos += int(ctrl.Copy)
+		add := make([]byte, ctrl.Add)
+		if _, err := io.ReadFull(r, add); err != nil {
+			return nil, corrupted
+		}
+		for i, b := range add {
+			new = append(new, old[oldPos+i]+b)
+		}
+		extra := make([]byte, ctrl.Extra)
+		if _, err := io.ReadFull(r, extra); err != nil {
+			return nil, corrupted
+		}
+		new = append(new, extra...)
+		oldPos += int(ctrl.Add) + int(ctrl.Seek)
+	}
+
+	newSum := sha256.Sum256(new)
+	if hex.EncodeToString(newSum[:]) != info.NewSHA256 {
+		return nil, corrupted
+	}
+	return new, nil
+}
+
+// EncodeDelta computes the patch transforming old into new, compresses it
+// using a lzss algorithm, encodes the result in ota format, marked as
+// delta, and writes it to e's underlying writer.
+func (e *Encoder) EncodeDelta(old, new []byte) error {
+	return e.encode(Version{Compression: true, Delta: true}, func(w io.Writer) error {
+		lw := lzss.NewWriter(w)
+		if _, err := lw.Write(Diff(old, new)); err != nil {
+			return err
+		}
+		return lw.Close()
+	})
+}
+
+// ApplyDelta verifies the passed delta ota file and applies it to old,
+// returning the new firmware, as a board would do.
+func ApplyDelta(otaFile io.Reader, old []byte) ([]byte, error) {
+	patch, header, err := readPayload(otaFile)
+	if err != nil {
+		return nil, err
+	}
+	if header.Delta == nil {
+		return nil, errors.New("not a delta OTA")
+	}
+	new, err := Patch(old, patch)
+	if err != nil {
+		return nil, fmt.Errorf("cannot apply delta OTA: %w", err)
+	}
+	return new, nil
+}
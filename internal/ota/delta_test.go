@@ -0,0 +1,167 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ota
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/rand"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/arduino/arduino-cloud-cli/internal/lzss"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQsufsort(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	inputs := [][]byte{nil, []byte("a"), []byte("banana"), bytes.Repeat([]byte("ab"), 100)}
+	for i := 0; i < 10; i++ {
+		buf := make([]byte, rnd.Intn(500))
+		for j := range buf {
+			buf[j] = byte(rnd.Intn(4))
+		}
+		inputs = append(inputs, buf)
+	}
+
+	for _, in := range inputs {
+		want := make([]int, len(in)+1)
+		for i := range want {
+			want[i] = i
+		}
+		sort.Slice(want, func(i, j int) bool { return bytes.Compare(in[want[i]:], in[want[j]:]) < 0 })
+		assert.Equal(t, want, qsufsort(in), "suffix array of %q", in)
+	}
+}
+
+// mutate returns a copy of buf with some bytes changed,
+// inserted and removed, as happens between firmware builds.
+func mutate(rnd *rand.Rand, buf []byte) []byte {
+	out := append([]byte{}, buf...)
+	for i := 0; i < 20; i++ {
+		pos := rnd.Intn(len(out))
+		switch rnd.Intn(3) {
+		case 0:
+			out[pos] ^= byte(rnd.Intn(255) + 1)
+		case 1:
+			ins := make([]byte, rnd.Intn(64))
+			rnd.Read(ins)
+			out = append(out[:pos], append(ins, out[pos:]...)...)
+		case 2:
+			end := min(len(out), pos+rnd.Intn(64))
+			out = append(out[:pos], out[end:]...)
+		}
+	}
+	return out
+}
+
+func TestDiffPatch(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	blink, err := os.ReadFile("testdata/blink.bin")
+	require.NoError(t, err)
+	cloud, err := os.ReadFile("testdata/cloud.bin")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		old, new []byte
+	}{
+		{"empty", nil, nil},
+		{"from empty", nil, blink},
+		{"to empty", blink, nil},
+		{"same", blink, blink},
+		{"mutated", blink, mutate(rnd, blink)},
+		{"mutated big", cloud, mutate(rnd, cloud)},
+		{"unrelated", blink, cloud},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch := Diff(tt.old, tt.new)
+			out, err := Patch(tt.old, patch)
+			require.NoError(t, err)
+			assert.Equal(t, len(tt.new), len(out))
+			assert.True(t, bytes.Equal(tt.new, out))
+		})
+	}
+}
+
+func TestPatchErrors(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	blink, err := os.ReadFile("testdata/blink.bin")
+	require.NoError(t, err)
+	patch := Diff(blink, mutate(rnd, blink))
+
+	// Patch applied to another firmware
+	_, err = Patch(mutate(rnd, blink), patch)
+	assert.ErrorIs(t, err, ErrDeltaBaseMismatch)
+
+	// Truncated patch
+	_, err = Patch(blink, patch[:len(patch)-1])
+	assert.Error(t, err)
+
+	// Corrupted entries
+	corrupted := append([]byte{}, patch...)
+	corrupted[len(corrupted)/2] ^= 0xff
+	_, err = Patch(blink, corrupted)
+	assert.Error(t, err)
+
+	_, err = Patch(blink, []byte("not a patch"))
+	assert.Error(t, err)
+}
+
+func TestEncodeDelta(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	old, err := os.ReadFile("testdata/cloud.bin")
+	require.NoError(t, err)
+	new := mutate(rnd, old)
+
+	var full, delta bytes.Buffer
+	require.NoError(t, NewEncoder(&full, "2341", "8057").Encode(new))
+	require.NoError(t, NewEncoder(&delta, "2341", "8057").EncodeDelta(old, new))
+	assert.Less(t, delta.Len(), full.Len()/10)
+
+	header, err := DecodeOtaFirmwareHeader(nopCloser{bytes.NewReader(delta.Bytes())})
+	require.NoError(t, err)
+	require.NotNil(t, header.Delta)
+	assert.True(t, header.Compressed)
+	assert.Equal(t, uint32(len(old)), header.Delta.OldSize)
+	fullHeader, err := DecodeOtaFirmwareHeader(nopCloser{bytes.NewReader(full.Bytes())})
+	require.NoError(t, err)
+	assert.Nil(t, fullHeader.Delta)
+	// Both files produce the same firmware on the board, but the delta
+	// payload hash covers the patch actually shipped
+	assert.Equal(t, fullHeader.PayloadSHA256, header.Delta.NewSHA256)
+	patchSum := sha256.Sum256(lzss.Decompress(delta.Bytes()[HeaderSize:]))
+	assert.Equal(t, hex.EncodeToString(patchSum[:]), header.PayloadSHA256)
+
+	out, err := ApplyDelta(bytes.NewReader(delta.Bytes()), old)
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(new, out))
+
+	_, err = ApplyDelta(bytes.NewReader(full.Bytes()), old)
+	assert.Error(t, err)
+}
+
+type nopCloser struct {
+	*bytes.Reader
+}
+
+func (nopCloser) Close() error { return nil }
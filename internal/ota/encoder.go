@@ -18,6 +18,7 @@
 package ota
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
 	"hash/crc32"
@@ -51,6 +52,30 @@ func NewEncoder(w io.Writer, magicNumberPart1, magicNumberPart2 string) *Encoder
 // Encode compresses data using a lzss algorithm, encodes the result
 // in ota format and writes it to e's underlying writer.
 func (e *Encoder) Encode(data []byte) error {
+	return e.EncodeFrom(bytes.NewReader(data))
+}
+
+// EncodeFrom compresses the data read from r using a lzss algorithm, encodes
+// the result in ota format and writes it to e's underlying writer.
+// Data is compressed while it's read, so that only the compressed data is kept
+// in memory: it can't be streamed since the header contains its length and CRC.
+func (e *Encoder) EncodeFrom(r io.Reader) error {
+	// Version field (byte array of size 8)
+	version := Version{
+		Compression: true,
+	}
+	return e.encode(version, func(w io.Writer) error {
+		lw := lzss.NewWriter(w)
+		if _, err := io.Copy(lw, r); err != nil {
+			return err
+		}
+		return lw.Close()
+	})
+}
+
+// encode writes the payload produced by writePayload
+// to e's underlying writer, in ota format.
+func (e *Encoder) encode(version Version, writePayload func(w io.Writer) error) error {
 	// Compute the magic number (VID/PID)
 	magicNumber := make([]byte, 4)
 	magicNumberPart1, err := strconv.ParseUint(e.magicNumberPart1, 16, 16)
@@ -65,24 +90,20 @@ func (e *Encoder) Encode(data []byte) error {
 	binary.LittleEndian.PutUint16(magicNumber[0:2], uint16(magicNumberPart2))
 	binary.LittleEndian.PutUint16(magicNumber[2:4], uint16(magicNumberPart1))
 
-	// Version field (byte array of size 8)
-	version := Version{
-		Compression: true,
-	}
-
-	compressed := lzss.Encode(data)
 	// Prepend magic number and version field to payload
-	var outData []byte
-	outData = append(outData, magicNumber...)
-	outData = append(outData, version.Bytes()...)
-	outData = append(outData, compressed...)
+	var outData bytes.Buffer
+	outData.Write(magicNumber)
+	outData.Write(version.Bytes())
+	if err := writePayload(&outData); err != nil {
+		return fmt.Errorf("cannot encode payload: %w", err)
+	}
 
-	err = e.writeHeader(outData)
+	err = e.writeHeader(outData.Bytes())
 	if err != nil {
 		return fmt.Errorf("cannot write data header to output stream: %w", err)
 	}
 
-	_, err = e.w.Write(outData)
+	_, err = outData.WriteTo(e.w)
 	if err != nil {
 		return fmt.Errorf("cannot write encoded data to output stream: %w", err)
 	}
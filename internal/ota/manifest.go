@@ -0,0 +1,235 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ota
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Extensions of the sidecar files containing the manifest of an OTA firmware,
+// appended to the firmware file name.
+var manifestExts = []string{".manifest.json", ".manifest.yaml", ".manifest.yml"}
+
+// Manifest describes the release an OTA firmware belongs to.
+// It is kept in a sidecar file next to the firmware, see FindManifest,
+// since the OTA header has no room for it.
+type Manifest struct {
+	Version        string     `json:"version" yaml:"version"`                                       // Semantic version of the firmware
+	Commit         string     `json:"commit,omitempty" yaml:"commit,omitempty"`                     // Commit the firmware has been built from
+	BuildDate      *time.Time `json:"build_date,omitempty" yaml:"build_date,omitempty"`             // Date of the build
+	FQBNs          []string   `json:"fqbns,omitempty" yaml:"fqbns,omitempty"`                       // Boards the firmware can be installed on; any board if empty
+	MinFromVersion string     `json:"min_from_version,omitempty" yaml:"min_from_version,omitempty"` // Oldest version that can be updated to this firmware; optional
+}
+
+// FindManifest returns the path of the manifest of the passed firmware file,
+// named as the firmware file followed by '.manifest.json' or '.manifest.yaml'.
+// Returns an empty string if the firmware has no manifest.
+func FindManifest(firmwareFile string) string {
+	for _, ext := range manifestExts {
+		if _, err := os.Stat(firmwareFile + ext); err == nil {
+			return firmwareFile + ext
+		}
+	}
+	return ""
+}
+
+// LoadManifest reads and validates the manifest contained in the passed file.
+// The format of the file (json or yaml) is inferred from its extension.
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read manifest file: %w", err)
+	}
+	m := &Manifest{}
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, m)
+	default:
+		err = json.Unmarshal(data, m)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse manifest file %s: %w", path, err)
+	}
+	if err := m.validate(); err != nil {
+		return nil, fmt.Errorf("manifest file %s not valid: %w", path, err)
+	}
+	return m, nil
+}
+
+func (m *Manifest) validate() error {
+	if m.Version == "" {
+		return errors.New("version is required")
+	}
+	if _, err := ParseSemver(m.Version); err != nil {
+		return err
+	}
+	if m.MinFromVersion != "" {
+		if _, err := ParseSemver(m.MinFromVersion); err != nil {
+			return fmt.Errorf("min_from_version: %w", err)
+		}
+	}
+	return nil
+}
+
+// SupportsFQBN tells whether the firmware can be installed on boards with the passed FQBN.
+func (m *Manifest) SupportsFQBN(fqbn string) bool {
+	if len(m.FQBNs) == 0 {
+		return true
+	}
+	for _, f := range m.FQBNs {
+		if f == fqbn {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckUpdateFrom returns an error if boards running
+// the passed version cannot be updated to this firmware.
+func (m *Manifest) CheckUpdateFrom(version string) error {
+	if m.MinFromVersion == "" {
+		return nil
+	}
+	v, err := ParseSemver(version)
+	if err != nil {
+		return fmt.Errorf("current version: %w", err)
+	}
+	min, _ := ParseSemver(m.MinFromVersion)
+	if v.Compare(min) < 0 {
+		return fmt.Errorf("version %s is older than %s, the minimum version that can be updated to %s", version, m.MinFromVersion, m.Version)
+	}
+	return nil
+}
+
+// IsVersion tells whether version is the same version of the firmware.
+// Build metadata is ignored, as semantic versioning requires.
+func (m *Manifest) IsVersion(version string) bool {
+	v, err := ParseSemver(version)
+	if err != nil {
+		return false
+	}
+	own, _ := ParseSemver(m.Version)
+	return v.Compare(own) == 0
+}
+
+// Semver is a semantic version, see https://semver.org.
+type Semver struct {
+	Major, Minor, Patch uint64
+	PreRelease          []string
+	Build               string
+}
+
+// ParseSemver parses a semantic version. A leading 'v' is accepted.
+func ParseSemver(s string) (Semver, error) {
+	var v Semver
+	rest := strings.TrimPrefix(s, "v")
+	if i := strings.Index(rest, "+"); i >= 0 {
+		rest, v.Build = rest[:i], rest[i+1:]
+		if v.Build == "" {
+			return Semver{}, fmt.Errorf("invalid semantic version %q: empty build metadata", s)
+		}
+	}
+	if i := strings.Index(rest, "-"); i >= 0 {
+		rest, v.PreRelease = rest[:i], strings.Split(rest[i+1:], ".")
+		for _, id := range v.PreRelease {
+			if id == "" {
+				return Semver{}, fmt.Errorf("invalid semantic version %q: empty pre-release identifier", s)
+			}
+		}
+	}
+	core := strings.Split(rest, ".")
+	if len(core) != 3 {
+		return Semver{}, fmt.Errorf("invalid semantic version %q: expected MAJOR.MINOR.PATCH", s)
+	}
+	nums := make([]uint64, 3)
+	for i, c := range core {
+		n, err := strconv.ParseUint(c, 10, 64)
+		if err != nil || (len(c) > 1 && c[0] == '0') {
+			return Semver{}, fmt.Errorf("invalid semantic version %q: %q is not a valid number", s, c)
+		}
+		nums[i] = n
+	}
+	v.Major, v.Minor, v.Patch = nums[0], nums[1], nums[2]
+	return v, nil
+}
+
+// Compare returns -1, 0 or 1 if v is lower, equal or greater than o.
+// Precedence follows semantic versioning: build metadata is ignored
+// and pre-releases precede the related release.
+func (v Semver) Compare(o Semver) int {
+	for _, c := range [][2]uint64{{v.Major, o.Major}, {v.Minor, o.Minor}, {v.Patch, o.Patch}} {
+		if c[0] != c[1] {
+			if c[0] < c[1] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	switch {
+	case len(v.PreRelease) == 0 && len(o.PreRelease) == 0:
+		return 0
+	case len(v.PreRelease) == 0:
+		return 1
+	case len(o.PreRelease) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.PreRelease) && i < len(o.PreRelease); i++ {
+		if c := comparePreRelease(v.PreRelease[i], o.PreRelease[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(v.PreRelease) < len(o.PreRelease):
+		return -1
+	case len(v.PreRelease) > len(o.PreRelease):
+		return 1
+	}
+	return 0
+}
+
+// comparePreRelease compares two pre-release identifiers: numeric identifiers
+// are compared numerically and have lower precedence than alphanumeric ones.
+func comparePreRelease(a, b string) int {
+	na, errA := strconv.ParseUint(a, 10, 64)
+	nb, errB := strconv.ParseUint(b, 10, 64)
+	switch {
+	case errA == nil && errB == nil:
+		if na == nb {
+			return 0
+		}
+		if na < nb {
+			return -1
+		}
+		return 1
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
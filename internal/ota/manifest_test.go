@@ -0,0 +1,102 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ota
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSemverCompare(t *testing.T) {
+	// Sorted by precedence, see https://semver.org/#spec-item-11
+	sorted := []string{
+		"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta",
+		"1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.2.0", "v2.0.0",
+	}
+	for i := range sorted {
+		for j := range sorted {
+			a, err := ParseSemver(sorted[i])
+			require.NoError(t, err)
+			b, err := ParseSemver(sorted[j])
+			require.NoError(t, err)
+
+			want := 0
+			if i < j {
+				want = -1
+			} else if i > j {
+				want = 1
+			}
+			assert.Equal(t, want, a.Compare(b), "%s vs %s", sorted[i], sorted[j])
+		}
+	}
+
+	a, _ := ParseSemver("1.0.0+build.1")
+	b, _ := ParseSemver("1.0.0+build.2")
+	assert.Equal(t, 0, a.Compare(b))
+
+	for _, invalid := range []string{"", "1", "1.0", "1.0.0.0", "01.0.0", "1.0.a", "1.0.0-", "1.0.0+", "1.0.0-a..b"} {
+		_, err := ParseSemver(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestLoadManifest(t *testing.T) {
+	dir := t.TempDir()
+	firmware := filepath.Join(dir, "fw.ota")
+	assert.Empty(t, FindManifest(firmware))
+
+	yamlManifest := "version: 1.2.0\ncommit: abc123\nbuild_date: 2024-05-01T10:00:00Z\nfqbns: [arduino:samd:nano_33_iot]\nmin_from_version: 1.0.0\n"
+	require.NoError(t, os.WriteFile(firmware+".manifest.yaml", []byte(yamlManifest), 0644))
+	path := FindManifest(firmware)
+	require.Equal(t, firmware+".manifest.yaml", path)
+	m, err := LoadManifest(path)
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.0", m.Version)
+	assert.Equal(t, "abc123", m.Commit)
+	require.NotNil(t, m.BuildDate)
+	assert.Equal(t, 2024, m.BuildDate.Year())
+
+	assert.True(t, m.SupportsFQBN("arduino:samd:nano_33_iot"))
+	assert.False(t, m.SupportsFQBN("arduino:samd:mkrwifi1010"))
+	assert.NoError(t, m.CheckUpdateFrom("1.0.0"))
+	assert.NoError(t, m.CheckUpdateFrom("1.1.5"))
+	assert.Error(t, m.CheckUpdateFrom("0.9.0"))
+	assert.Error(t, m.CheckUpdateFrom("1.0.0-rc.1"))
+	assert.Error(t, m.CheckUpdateFrom("not a version"))
+	assert.True(t, m.IsVersion("v1.2.0+build.7"))
+	assert.False(t, m.IsVersion("1.2.1"))
+
+	// Json manifests take precedence
+	require.NoError(t, os.WriteFile(firmware+".manifest.json", []byte(`{"version": "2.0.0"}`), 0644))
+	m, err = LoadManifest(FindManifest(firmware))
+	require.NoError(t, err)
+	assert.Equal(t, "2.0.0", m.Version)
+	assert.True(t, m.SupportsFQBN("arduino:samd:mkrwifi1010"))
+	assert.NoError(t, m.CheckUpdateFrom("0.0.1"))
+
+	require.NoError(t, os.WriteFile(firmware+".manifest.json", []byte(`{"version": "2.0"}`), 0644))
+	_, err = LoadManifest(FindManifest(firmware))
+	assert.Error(t, err)
+	require.NoError(t, os.WriteFile(firmware+".manifest.json", []byte(`{"commit": "abc123"}`), 0644))
+	_, err = LoadManifest(FindManifest(firmware))
+	assert.Error(t, err)
+}
@@ -0,0 +1,203 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ota
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Algorithms used to sign OTA firmwares.
+const (
+	SignatureECDSA   = "ecdsa-sha256"
+	SignatureEd25519 = "ed25519"
+)
+
+// SignatureExt is the extension of the sidecar file containing
+// the signature of an OTA firmware, appended to the firmware file name.
+// The signature is kept out of the firmware, so that
+// the firmware received by the devices is unchanged.
+const SignatureExt = ".sig"
+
+var ErrSignatureMismatch = errors.New("signature verification failed")
+
+// Signature is the signature of an OTA firmware.
+// It signs the SHA256 of the firmware payload as seen by the board
+// (see OtaMetadata.PayloadSHA256), so the same signature is valid
+// for a .bin file and for the .ota files generated from it.
+type Signature struct {
+	Algorithm     string `json:"algorithm"`
+	PayloadSHA256 string `json:"payload_sha256"`
+	Value         []byte `json:"signature"`
+}
+
+// SignatureFile returns the path of the signature sidecar of the passed firmware file.
+func SignatureFile(firmwareFile string) string {
+	return firmwareFile + SignatureExt
+}
+
+// Sign signs the passed payload SHA256, in hex format, with the passed key.
+// ECDSA and Ed25519 keys are supported.
+func Sign(payloadSHA256 string, key crypto.Signer) (*Signature, error) {
+	digest, err := decodeDigest(payloadSHA256)
+	if err != nil {
+		return nil, err
+	}
+
+	sig := &Signature{PayloadSHA256: payloadSHA256}
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		sig.Algorithm = SignatureECDSA
+		sig.Value, err = ecdsa.SignASN1(rand.Reader, k, digest)
+	case ed25519.PrivateKey:
+		sig.Algorithm = SignatureEd25519
+		sig.Value = ed25519.Sign(k, digest)
+	default:
+		return nil, fmt.Errorf("unsupported key type %T: only ECDSA and Ed25519 keys are supported", key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("signing payload: %w", err)
+	}
+	return sig, nil
+}
+
+// Verify checks that the signature is valid for the passed payload SHA256,
+// in hex format, and public key.
+func (s *Signature) Verify(payloadSHA256 string, key crypto.PublicKey) error {
+	if s.PayloadSHA256 != payloadSHA256 {
+		return fmt.Errorf("%w: signature is for payload %s, not %s", ErrSignatureMismatch, s.PayloadSHA256, payloadSHA256)
+	}
+	digest, err := decodeDigest(payloadSHA256)
+	if err != nil {
+		return err
+	}
+
+	var valid bool
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		if s.Algorithm != SignatureECDSA {
+			return fmt.Errorf("%w: signature algorithm %s does not match ECDSA key", ErrSignatureMismatch, s.Algorithm)
+		}
+		valid = ecdsa.VerifyASN1(k, digest, s.Value)
+	case ed25519.PublicKey:
+		if s.Algorithm != SignatureEd25519 {
+			return fmt.Errorf("%w: signature algorithm %s does not match Ed25519 key", ErrSignatureMismatch, s.Algorithm)
+		}
+		valid = ed25519.Verify(k, digest, s.Value)
+	default:
+		return fmt.Errorf("unsupported key type %T: only ECDSA and Ed25519 keys are supported", key)
+	}
+	if !valid {
+		return ErrSignatureMismatch
+	}
+	return nil
+}
+
+func decodeDigest(payloadSHA256 string) ([]byte, error) {
+	digest, err := hex.DecodeString(payloadSHA256)
+	if err != nil || len(digest) != 32 {
+		return nil, fmt.Errorf("invalid payload SHA256: %s", payloadSHA256)
+	}
+	return digest, nil
+}
+
+// WriteSignature writes the signature to the passed file.
+func WriteSignature(path string, sig *Signature) error {
+	data, err := json.MarshalIndent(sig, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("cannot write signature file: %w", err)
+	}
+	return nil
+}
+
+// ReadSignature reads the signature contained in the passed file.
+func ReadSignature(path string) (*Signature, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read signature file: %w", err)
+	}
+	sig := &Signature{}
+	if err := json.Unmarshal(data, sig); err != nil {
+		return nil, fmt.Errorf("cannot parse signature file %s: %w", path, err)
+	}
+	return sig, nil
+}
+
+// LoadPrivateKey reads a PEM encoded ECDSA or Ed25519 private key,
+// in PKCS#8 or SEC 1 ("EC PRIVATE KEY") format.
+func LoadPrivateKey(path string) (crypto.Signer, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var key interface{}
+	if block.Type == "EC PRIVATE KEY" {
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	} else {
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse private key %s: %w", path, err)
+	}
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		return k, nil
+	case ed25519.PrivateKey:
+		return k, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T: only ECDSA and Ed25519 keys are supported", key)
+	}
+}
+
+// LoadPublicKey reads a PEM encoded ECDSA or Ed25519 public key, in PKIX format.
+func LoadPublicKey(path string) (crypto.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse public key %s: %w", path, err)
+	}
+	return key, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read key file: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key file %s is not PEM encoded", path)
+	}
+	return block, nil
+}
@@ -0,0 +1,114 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ota
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeKeys writes the passed private key, and its public key, as PEM files.
+func writeKeys(t *testing.T, key crypto.Signer) (privPath, pubPath string) {
+	dir := t.TempDir()
+	priv, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	pub, err := x509.MarshalPKIXPublicKey(key.Public())
+	require.NoError(t, err)
+
+	privPath, pubPath = filepath.Join(dir, "key.pem"), filepath.Join(dir, "pub.pem")
+	require.NoError(t, os.WriteFile(privPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: priv}), 0600))
+	require.NoError(t, os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub}), 0644))
+	return privPath, pubPath
+}
+
+func TestSignVerify(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	sum := sha256.Sum256([]byte("firmware"))
+	payload := hex.EncodeToString(sum[:])
+	other := sha256.Sum256([]byte("other firmware"))
+
+	tests := []struct {
+		name      string
+		key       crypto.Signer
+		algorithm string
+	}{
+		{name: "ecdsa", key: ecKey, algorithm: SignatureECDSA},
+		{name: "ed25519", key: edKey, algorithm: SignatureEd25519},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			privPath, pubPath := writeKeys(t, tt.key)
+			priv, err := LoadPrivateKey(privPath)
+			require.NoError(t, err)
+			pub, err := LoadPublicKey(pubPath)
+			require.NoError(t, err)
+
+			sig, err := Sign(payload, priv)
+			require.NoError(t, err)
+			assert.Equal(t, tt.algorithm, sig.Algorithm)
+
+			sigPath := SignatureFile(filepath.Join(t.TempDir(), "fw.ota"))
+			require.NoError(t, WriteSignature(sigPath, sig))
+			sig, err = ReadSignature(sigPath)
+			require.NoError(t, err)
+			assert.NoError(t, sig.Verify(payload, pub))
+
+			// Signature of another payload
+			assert.ErrorIs(t, sig.Verify(hex.EncodeToString(other[:]), pub), ErrSignatureMismatch)
+
+			// Tampered signature
+			sig.Value[len(sig.Value)-1] ^= 0xff
+			assert.ErrorIs(t, sig.Verify(payload, pub), ErrSignatureMismatch)
+		})
+	}
+
+	// Signature verified with a key of the wrong type
+	sig, err := Sign(payload, ecKey)
+	require.NoError(t, err)
+	assert.ErrorIs(t, sig.Verify(payload, edKey.Public()), ErrSignatureMismatch)
+}
+
+func TestLoadPrivateKey_sec1(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600))
+
+	loaded, err := LoadPrivateKey(path)
+	require.NoError(t, err)
+	assert.True(t, key.Equal(loaded))
+}
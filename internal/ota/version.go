@@ -17,7 +17,12 @@
 
 package ota
 
-const compressionEnabledMagicNumber = 0x40
+const (
+	compressionEnabledMagicNumber = 0x40
+	// deltaEnabledMagicNumber marks payloads containing a
+	// patch for the firmware running on the board, see delta.go.
+	deltaEnabledMagicNumber = 0x20
+)
 
 // Version contains all the OTA header information
 // Check out https://arduino.atlassian.net/wiki/spaces/RFC/pages/1616871540/OTA+header+structure for more
@@ -26,6 +31,7 @@ type Version struct {
 	HeaderVersion   uint8
 	Compression     bool
 	Signature       bool
+	Delta           bool
 	Spare           uint8
 	PayloadTarget   uint8
 	PayloadMayor    uint8
@@ -40,7 +46,10 @@ func (v *Version) Bytes() []byte {
 
 	// Set compression
 	if v.Compression {
-		version[7] = 0x40
+		version[7] |= compressionEnabledMagicNumber
+	}
+	if v.Delta {
+		version[7] |= deltaEnabledMagicNumber
 	}
 
 	// Other field are currently not implemented ¯\_(ツ)_/¯
@@ -51,11 +60,13 @@ func (v *Version) Bytes() []byte {
 // Bytes builds a 8 byte length representation of the Version Struct for the OTA update.
 func decodeVersion(version []byte) Version {
 
-	compressed := (version[7] == compressionEnabledMagicNumber)
+	compressed := version[7]&compressionEnabledMagicNumber != 0
+	delta := version[7]&deltaEnabledMagicNumber != 0
 
 	// Other field are currently not implemented ¯\_(ツ)_/¯
 
 	return Version{
 		Compression: compressed,
+		Delta:       delta,
 	}
 }
@@ -26,6 +26,7 @@ import (
 	"net/http"
 
 	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/arduino/arduino-cloud-cli/internal/httpclient"
 	"github.com/arduino/arduino-cloud-cli/internal/iot"
 	"golang.org/x/oauth2"
 )
@@ -38,10 +39,10 @@ type ProvisioningApiClient struct {
 }
 
 func NewClient(credentials *config.Credentials) *ProvisioningApiClient {
-	host := iot.GetArduinoAPIBaseURL()
+	host := iot.GetArduinoAPIBaseURLFor(credentials)
 	tokenSource := iot.NewUserTokenSource(credentials.Client, credentials.Secret, host, credentials.Organization)
 	return &ProvisioningApiClient{
-		client:       &http.Client{},
+		client:       httpclient.New(),
 		src:          tokenSource,
 		host:         host,
 		organization: credentials.Organization,
@@ -0,0 +1,188 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package realtime implements a client of the realtime channel of
+// Arduino Cloud: an MQTT broker, reachable through websockets,
+// on which the values of the thing properties are published.
+package realtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// DefaultKeepAlive is the default interval between two pings sent to the broker.
+const DefaultKeepAlive = 30 * time.Second
+
+// messageBuffer is the number of received messages queued
+// while waiting for them to be read.
+const messageBuffer = 64
+
+// errClosed is returned when reading from a closed connection.
+var errClosed = errors.New("realtime connection closed")
+
+// Options contains the parameters of a connection to the broker.
+type Options struct {
+	ClientID  string
+	Username  string
+	Password  string
+	Origin    string        // Origin of the websocket handshake, none if empty
+	KeepAlive time.Duration // Interval between two pings, DefaultKeepAlive if shorter than a second
+}
+
+// Message is a message published on a topic.
+type Message struct {
+	Topic   string
+	Payload []byte
+}
+
+// Conn is a connection to the broker.
+// Messages must be read by a single goroutine.
+type Conn struct {
+	client   mqtt.Client
+	messages chan Message
+
+	lost     chan struct{} // Closed when the connection is lost
+	lostErr  error
+	lostOnce sync.Once
+
+	done      chan struct{} // Closed by Close
+	closeOnce sync.Once
+}
+
+// Dial connects to the broker at the passed websocket url.
+func Dial(ctx context.Context, url string, opts Options) (*Conn, error) {
+	keepAlive := opts.KeepAlive
+	if keepAlive < time.Second {
+		keepAlive = DefaultKeepAlive
+	}
+	c := &Conn{
+		messages: make(chan Message, messageBuffer),
+		lost:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	header := http.Header{}
+	if opts.Origin != "" {
+		header.Set("Origin", opts.Origin)
+	}
+	o := mqtt.NewClientOptions().
+		AddBroker(url).
+		SetClientID(opts.ClientID).
+		SetUsername(opts.Username).
+		SetPassword(opts.Password).
+		SetProtocolVersion(4). // MQTT 3.1.1
+		SetCleanSession(true).
+		SetHTTPHeaders(header).
+		// The broker answers to the pings, so nothing
+		// received within 1.5 keep alive means that it's gone
+		SetKeepAlive(keepAlive).
+		SetPingTimeout(keepAlive / 2).
+		// Reconnections are up to the caller, that must subscribe again
+		SetAutoReconnect(false).
+		SetDefaultPublishHandler(c.receive).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) { c.setLost(err) })
+	if deadline, ok := ctx.Deadline(); ok {
+		o.SetConnectTimeout(time.Until(deadline))
+	}
+	c.client = mqtt.NewClient(o)
+
+	if err := wait(ctx, c.client.Connect()); err != nil {
+		c.client.Disconnect(0)
+		return nil, fmt.Errorf("connecting to realtime broker: %w", err)
+	}
+	return c, nil
+}
+
+// Subscribe subscribes to the passed topics, waiting for the broker acknowledgement.
+func (c *Conn) Subscribe(ctx context.Context, topics ...string) error {
+	filters := make(map[string]byte, len(topics))
+	for _, t := range topics {
+		filters[t] = 0 // QoS 0
+	}
+	token := c.client.SubscribeMultiple(filters, nil)
+	if err := wait(ctx, token); err != nil {
+		return fmt.Errorf("subscribing to %v: %w", topics, err)
+	}
+	for _, t := range topics {
+		if token.(*mqtt.SubscribeToken).Result()[t] == 0x80 {
+			return fmt.Errorf("subscription to %s refused by realtime broker", t)
+		}
+	}
+	return nil
+}
+
+// ReadMessage blocks until a message is received on one of the subscribed topics.
+// An error is returned if the connection is lost or if the broker doesn't
+// answer to pings.
+func (c *Conn) ReadMessage() (Message, error) {
+	// Messages received before the connection was lost are read first
+	select {
+	case msg := <-c.messages:
+		return msg, nil
+	default:
+	}
+	select {
+	case msg := <-c.messages:
+		return msg, nil
+	case <-c.lost:
+		return Message{}, c.lostErr
+	case <-c.done:
+		return Message{}, errClosed
+	}
+}
+
+// Close disconnects from the broker.
+func (c *Conn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.client.Disconnect(250)
+	})
+	return nil
+}
+
+// receive queues a message published by the broker, until it's read
+// or the connection is closed.
+func (c *Conn) receive(_ mqtt.Client, m mqtt.Message) {
+	select {
+	case c.messages <- Message{Topic: m.Topic(), Payload: m.Payload()}:
+	case <-c.done:
+	}
+}
+
+func (c *Conn) setLost(err error) {
+	c.lostOnce.Do(func() {
+		c.lostErr = fmt.Errorf("realtime connection lost: %w", err)
+		close(c.lost)
+	})
+}
+
+// wait waits for the completion of an MQTT operation, or for the context to be done.
+func wait(ctx context.Context, token mqtt.Token) error {
+	select {
+	case <-token.Done():
+		return token.Error()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
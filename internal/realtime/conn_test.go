@@ -0,0 +1,239 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package realtime
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eclipse/paho.mqtt.golang/packets"
+	"github.com/gorilla/websocket"
+)
+
+const testOrigin = "https://cloud.example.com"
+
+// testBroker is a minimal broker accepting a single client,
+// publishing the passed messages once the client subscribes.
+type testBroker struct {
+	t        *testing.T
+	password string
+	messages []Message
+	topics   chan []string
+	pings    chan struct{}
+	conns    chan *websocket.Conn
+}
+
+func (b *testBroker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{
+		Subprotocols: []string{"mqtt"},
+		// The broker only accepts the connections from the web app
+		CheckOrigin: func(r *http.Request) bool { return r.Header.Get("Origin") == testOrigin },
+	}
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		b.t.Error(err)
+		return
+	}
+	defer ws.Close()
+	select {
+	case b.conns <- ws:
+	default:
+	}
+	s := &wsStream{ws: ws}
+
+	p, err := packets.ReadPacket(s)
+	connect, ok := p.(*packets.ConnectPacket)
+	if !ok {
+		b.t.Errorf("expected connect packet, got %v: %v", p, err)
+		return
+	}
+	connack := packets.NewControlPacket(packets.Connack).(*packets.ConnackPacket)
+	if string(connect.Password) != b.password {
+		connack.ReturnCode = packets.ErrRefusedBadUsernameOrPassword
+	}
+	s.send(connack)
+
+	for {
+		p, err := packets.ReadPacket(s)
+		if err != nil {
+			return
+		}
+		switch p := p.(type) {
+		case *packets.SubscribePacket:
+			b.topics <- p.Topics
+			// A message published before the ack must not be lost
+			for _, m := range b.messages[:1] {
+				s.send(publish(m, 0))
+			}
+			suback := packets.NewControlPacket(packets.Suback).(*packets.SubackPacket)
+			suback.MessageID = p.MessageID
+			suback.ReturnCodes = make([]byte, len(p.Topics))
+			s.send(suback)
+			for _, m := range b.messages[1:] {
+				// Published with QoS 1, to be acknowledged
+				s.send(publish(m, 1))
+			}
+		case *packets.PingreqPacket:
+			s.send(packets.NewControlPacket(packets.Pingresp))
+			b.pings <- struct{}{}
+		case *packets.DisconnectPacket:
+			return
+		}
+	}
+}
+
+func publish(m Message, qos byte) *packets.PublishPacket {
+	p := packets.NewControlPacket(packets.Publish).(*packets.PublishPacket)
+	p.TopicName = m.Topic
+	p.Payload = m.Payload
+	p.Qos = qos
+	if qos > 0 {
+		p.MessageID = 7
+	}
+	return p
+}
+
+// wsStream reads the MQTT packets sent in websocket binary messages.
+type wsStream struct {
+	ws *websocket.Conn
+	r  io.Reader
+}
+
+func (s *wsStream) Read(b []byte) (int, error) {
+	for {
+		if s.r == nil {
+			_, r, err := s.ws.NextReader()
+			if err != nil {
+				return 0, err
+			}
+			s.r = r
+		}
+		n, err := s.r.Read(b)
+		if err == io.EOF {
+			s.r = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (s *wsStream) send(p packets.ControlPacket) {
+	var buf bytes.Buffer
+	p.Write(&buf)
+	s.ws.WriteMessage(websocket.BinaryMessage, buf.Bytes())
+}
+
+func newTestBroker(t *testing.T, messages []Message) (*testBroker, string) {
+	b := &testBroker{
+		t:        t,
+		password: "token",
+		messages: messages,
+		topics:   make(chan []string, 1),
+		pings:    make(chan struct{}, 10),
+		conns:    make(chan *websocket.Conn, 1),
+	}
+	srv := httptest.NewServer(b)
+	t.Cleanup(srv.Close)
+	return b, "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func TestConn(t *testing.T) {
+	messages := []Message{
+		{Topic: "/a/t/thing-1/e/o", Payload: []byte("first")},
+		{Topic: "/a/t/thing-2/e/o", Payload: []byte("second")},
+	}
+	broker, url := newTestBroker(t, messages)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	opts := Options{ClientID: "client", Username: "user", Password: "token", Origin: testOrigin, KeepAlive: time.Second}
+	conn, err := Dial(ctx, url, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if err := conn.Subscribe(ctx, "/a/t/thing-1/e/o", "/a/t/thing-2/e/o"); err != nil {
+		t.Fatal(err)
+	}
+	if topics := <-broker.topics; len(topics) != 2 {
+		t.Errorf("unexpected subscribed topics: %v", topics)
+	}
+	for _, want := range messages {
+		msg, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if msg.Topic != want.Topic || string(msg.Payload) != string(want.Payload) {
+			t.Errorf("expected message %v, got %v", want, msg)
+		}
+	}
+
+	// The connection is kept alive with pings while waiting for messages
+	go conn.ReadMessage()
+	select {
+	case <-broker.pings:
+	case <-ctx.Done():
+		t.Fatal("no ping received")
+	}
+	conn.Close()
+	if _, err := conn.ReadMessage(); err == nil {
+		t.Error("expected an error reading from a closed connection")
+	}
+}
+
+func TestConnRefused(t *testing.T) {
+	_, url := newTestBroker(t, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := Dial(ctx, url, Options{ClientID: "client", Username: "user", Password: "wrong", Origin: testOrigin})
+	if err == nil || !strings.Contains(err.Error(), "bad user name or password") {
+		t.Errorf("expected the connection to be refused, got %v", err)
+	}
+}
+
+func TestConnLost(t *testing.T) {
+	broker, url := newTestBroker(t, []Message{{Topic: "topic", Payload: []byte("msg")}})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := Dial(ctx, url, Options{ClientID: "client", Username: "user", Password: "token", Origin: testOrigin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if err := conn.Subscribe(ctx, "topic"); err != nil {
+		t.Fatal(err)
+	}
+	<-broker.topics
+	if _, err := conn.ReadMessage(); err != nil {
+		t.Fatal(err)
+	}
+	(<-broker.conns).Close()
+	if _, err := conn.ReadMessage(); err == nil {
+		t.Error("expected an error reading from a lost connection")
+	}
+}
@@ -0,0 +1,125 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package realtime
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Labels of the SenML fields in CBOR representation, see RFC 8428.
+const (
+	labelBaseName = -2
+	labelBaseTime = -3
+	labelName     = 0
+	labelValue    = 2
+	labelString   = 3
+	labelBool     = 4
+	labelTime     = 6
+	labelData     = 8
+)
+
+// relativeTimeLimit is the SenML time under which
+// times are relative to the current time.
+const relativeTimeLimit = 1 << 28
+
+// Record is a value contained in a SenML message.
+type Record struct {
+	Name  string
+	Time  time.Time   // Time of the value; zero if not provided
+	Value interface{} // float64, string, bool or []byte
+}
+
+// DecodeSenML decodes a SenML message in CBOR representation,
+// used by the things to publish the values of their properties.
+// Records without a value are skipped.
+func DecodeSenML(payload []byte) ([]Record, error) {
+	var pack []map[int]interface{}
+	if err := cbor.Unmarshal(payload, &pack); err != nil {
+		return nil, fmt.Errorf("decoding SenML message: %w", err)
+	}
+
+	var records []Record
+	var baseName string
+	var baseTime float64
+	for _, fields := range pack {
+		if bn, ok := fields[labelBaseName].(string); ok {
+			baseName = bn
+		}
+		if bt, ok := number(fields[labelBaseTime]); ok {
+			baseTime = bt
+		}
+
+		rec := Record{}
+		if n, ok := fields[labelName].(string); ok {
+			rec.Name = baseName + n
+		} else {
+			rec.Name = baseName
+		}
+		if rec.Name == "" {
+			return nil, errors.New("decoding SenML message: record without name")
+		}
+		t, _ := number(fields[labelTime])
+		rec.Time = senmlTime(baseTime + t)
+
+		if v, ok := number(fields[labelValue]); ok {
+			rec.Value = v
+		} else if v, ok := fields[labelString].(string); ok {
+			rec.Value = v
+		} else if v, ok := fields[labelBool].(bool); ok {
+			rec.Value = v
+		} else if v, ok := fields[labelData].([]byte); ok {
+			rec.Value = v
+		} else {
+			continue
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// number converts the numbers decoded from CBOR to float64.
+func number(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// senmlTime converts a SenML time, expressed in seconds, to a time.
+func senmlTime(t float64) time.Time {
+	switch {
+	case t == 0:
+		return time.Time{}
+	case t < relativeTimeLimit:
+		return time.Now().Add(time.Duration(t * float64(time.Second)))
+	}
+	sec, frac := math.Modf(t)
+	return time.Unix(int64(sec), int64(math.Round(frac*1e3))*int64(time.Millisecond)).UTC()
+}
@@ -0,0 +1,81 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package realtime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDecodeSenML(t *testing.T) {
+	payload, err := cbor.Marshal([]map[int]interface{}{
+		{labelBaseTime: 1704067200, labelName: "temperature", labelValue: 21.5},
+		{labelName: "humidity", labelValue: uint64(40), labelTime: 1.25},
+		{labelName: "status", labelString: "ok"},
+		{labelName: "led", labelBool: true},
+		{labelName: "position:lat", labelValue: 45.07},
+		{labelName: "position:lon", labelValue: float32(7.5)},
+		{labelName: "no-value"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := DecodeSenML(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	want := []Record{
+		{Name: "temperature", Time: base, Value: 21.5},
+		{Name: "humidity", Time: base.Add(1250 * time.Millisecond), Value: 40.0},
+		{Name: "status", Time: base, Value: "ok"},
+		{Name: "led", Time: base, Value: true},
+		{Name: "position:lat", Time: base, Value: 45.07},
+		{Name: "position:lon", Time: base, Value: 7.5},
+	}
+	if diff := cmp.Diff(want, records); diff != "" {
+		t.Errorf("unexpected records (-want +got):\n%s", diff)
+	}
+}
+
+func TestDecodeSenMLBaseName(t *testing.T) {
+	payload, err := cbor.Marshal([]map[int]interface{}{
+		{labelBaseName: "urn:dev:", labelName: "a", labelValue: 1},
+		{labelName: "b", labelValue: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := DecodeSenML(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].Name != "urn:dev:a" || records[1].Name != "urn:dev:b" {
+		t.Errorf("unexpected records: %v", records)
+	}
+	if !records[0].Time.IsZero() {
+		t.Errorf("expected zero time for records without time, got %s", records[0].Time)
+	}
+
+	if _, err := DecodeSenML([]byte{0xff}); err == nil {
+		t.Error("expected an error decoding an invalid message")
+	}
+}
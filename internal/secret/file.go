@@ -0,0 +1,186 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// EncryptedFilename specifies the name of the file containing encrypted secrets.
+	EncryptedFilename = "arduino-cloud-secrets.enc"
+
+	encryptedFileVersion = 1
+	saltLen              = 16
+	keyLen               = 32
+	// scrypt parameters recommended for interactive logins.
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// encryptedFile is the on-disk representation of the encrypted file.
+type encryptedFile struct {
+	Version int    `json:"version"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// fileStore saves secrets in a file encrypted with AES-GCM,
+// using a key derived from a passphrase with scrypt.
+type fileStore struct {
+	path       string
+	passphrase func() (string, error)
+}
+
+// NewEncryptedFileStore returns a store backed by the encrypted file at the passed path.
+// The passphrase function is called whenever the file has to be decrypted or encrypted.
+func NewEncryptedFileStore(path string, passphrase func() (string, error)) Store {
+	return &fileStore{path: path, passphrase: passphrase}
+}
+
+// Get retrieves the secret saved for the passed key.
+func (s *fileStore) Get(key string) (string, error) {
+	secrets, _, err := s.load()
+	if err != nil {
+		return "", err
+	}
+	secret, ok := secrets[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return secret, nil
+}
+
+// Set saves the secret for the passed key, overwriting any previous one.
+func (s *fileStore) Set(key, secret string) error {
+	secrets, pass, err := s.load()
+	if err != nil {
+		return err
+	}
+	secrets[key] = secret
+	return s.save(secrets, pass)
+}
+
+// Delete removes the secret saved for the passed key.
+func (s *fileStore) Delete(key string) error {
+	secrets, pass, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[key]; !ok {
+		return ErrNotFound
+	}
+	delete(secrets, key)
+	return s.save(secrets, pass)
+}
+
+// load decrypts the file and returns the secrets it contains, along with the passphrase used.
+// If the file does not exist, an empty set of secrets is returned.
+func (s *fileStore) load() (map[string]string, string, error) {
+	pass, err := s.passphrase()
+	if err != nil {
+		return nil, "", fmt.Errorf("retrieving passphrase: %w", err)
+	}
+	secrets := make(map[string]string)
+
+	content, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return secrets, pass, nil
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("reading encrypted file: %w", err)
+	}
+
+	var file encryptedFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, "", fmt.Errorf("parsing encrypted file %s: %w", s.path, err)
+	}
+	if file.Version != encryptedFileVersion {
+		return nil, "", fmt.Errorf("encrypted file %s has unsupported version %d", s.path, file.Version)
+	}
+	gcm, err := newGCM(pass, file.Salt)
+	if err != nil {
+		return nil, "", err
+	}
+	plain, err := gcm.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot decrypt %s: wrong passphrase or corrupted file", s.path)
+	}
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return nil, "", fmt.Errorf("parsing decrypted secrets: %w", err)
+	}
+	return secrets, pass, nil
+}
+
+// save encrypts the secrets with a fresh salt and nonce and writes them to the file.
+func (s *fileStore) save(secrets map[string]string, pass string) error {
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return fmt.Errorf("encoding secrets: %w", err)
+	}
+
+	file := encryptedFile{Version: encryptedFileVersion, Salt: make([]byte, saltLen)}
+	if _, err := rand.Read(file.Salt); err != nil {
+		return fmt.Errorf("generating salt: %w", err)
+	}
+	gcm, err := newGCM(pass, file.Salt)
+	if err != nil {
+		return err
+	}
+	file.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(file.Nonce); err != nil {
+		return fmt.Errorf("generating nonce: %w", err)
+	}
+	file.Data = gcm.Seal(nil, file.Nonce, plain, nil)
+
+	content, err := json.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("encoding encrypted file: %w", err)
+	}
+	if err := os.WriteFile(s.path, content, os.FileMode(0600)); err != nil {
+		return fmt.Errorf("writing encrypted file: %w", err)
+	}
+	return nil
+}
+
+// newGCM derives the encryption key from the passphrase and
+// returns the AES-GCM cipher to be used with it.
+func newGCM(pass string, salt []byte) (cipher.AEAD, error) {
+	if pass == "" {
+		return nil, errors.New("passphrase cannot be empty")
+	}
+	key, err := scrypt.Key([]byte(pass), salt, scryptN, scryptR, scryptP, keyLen)
+	if err != nil {
+		return nil, fmt.Errorf("deriving key from passphrase: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
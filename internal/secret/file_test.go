@@ -0,0 +1,75 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package secret
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func passphrase(p string) func() (string, error) {
+	return func() (string, error) { return p, nil }
+}
+
+func TestEncryptedFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), EncryptedFilename)
+	store := NewEncryptedFileStore(path, passphrase("correct horse"))
+
+	if _, err := store.Get("client"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound on missing file, got: %v", err)
+	}
+
+	if err := store.Set("client1", "secret1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set("client2", "secret2"); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "secret1") {
+		t.Errorf("Encrypted file contains the secret in clear text")
+	}
+
+	got, err := store.Get("client1")
+	if err != nil || got != "secret1" {
+		t.Errorf("Expected secret1, got %s (err: %v)", got, err)
+	}
+
+	wrong := NewEncryptedFileStore(path, passphrase("wrong"))
+	if _, err := wrong.Get("client1"); err == nil {
+		t.Errorf("Expected an error decrypting with a wrong passphrase")
+	}
+
+	if err := store.Delete("client1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get("client1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got: %v", err)
+	}
+	got, err = store.Get("client2")
+	if err != nil || got != "secret2" {
+		t.Errorf("Expected secret2, got %s (err: %v)", got, err)
+	}
+}
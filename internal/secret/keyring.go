@@ -0,0 +1,137 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package secret
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// keyringStore saves secrets in the keyring of the operating system.
+// It relies on the Secret Service, through 'secret-tool', on Linux
+// and on the login keychain, through 'security', on macOS.
+type keyringStore struct{}
+
+// NewKeyringStore returns a store backed by the keyring of the operating system.
+// Returns an error if the keyring is not supported on the current system.
+func NewKeyringStore() (Store, error) {
+	var tool string
+	switch runtime.GOOS {
+	case "linux":
+		tool = "secret-tool"
+	case "darwin":
+		tool = "security"
+	default:
+		return nil, fmt.Errorf("keyring not supported on %s, use the '%s' secret store instead", runtime.GOOS, EncryptedFile)
+	}
+	if _, err := exec.LookPath(tool); err != nil {
+		return nil, fmt.Errorf("keyring not available, '%s' not found: %w", tool, err)
+	}
+	return &keyringStore{}, nil
+}
+
+// Get retrieves the secret saved for the passed key.
+func (s *keyringStore) Get(key string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		cmd = exec.Command("security", "find-generic-password", "-s", service, "-a", key, "-w")
+	} else {
+		cmd = exec.Command("secret-tool", "lookup", "service", service, "account", key)
+	}
+	out, err := run(cmd, "")
+	if err != nil {
+		return "", fmt.Errorf("reading secret from keyring: %w", err)
+	}
+	secret := strings.TrimRight(out, "\r\n")
+	if secret == "" {
+		return "", ErrNotFound
+	}
+	return secret, nil
+}
+
+// Set saves the secret for the passed key, overwriting any previous one.
+func (s *keyringStore) Set(key, secret string) error {
+	if runtime.GOOS == "darwin" {
+		// The secret is passed on stdin, through the interactive mode of 'security',
+		// so that it can't be read from the arguments of the process.
+		command := fmt.Sprintf("add-generic-password -U -s %q -a %q -X %s\n", service, key, hex.EncodeToString([]byte(secret)))
+		if err := runSecurityInteractive(command); err != nil {
+			return fmt.Errorf("saving secret in keyring: %w", err)
+		}
+		return nil
+	}
+	cmd := exec.Command("secret-tool", "store", "--label", service+" "+key, "service", service, "account", key)
+	if _, err := run(cmd, secret); err != nil {
+		return fmt.Errorf("saving secret in keyring: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the secret saved for the passed key.
+func (s *keyringStore) Delete(key string) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		cmd = exec.Command("security", "delete-generic-password", "-s", service, "-a", key)
+	} else {
+		cmd = exec.Command("secret-tool", "clear", "service", service, "account", key)
+	}
+	if _, err := run(cmd, ""); err != nil {
+		return fmt.Errorf("deleting secret from keyring: %w", err)
+	}
+	return nil
+}
+
+// run executes the command, passing stdin to it, and returns its output.
+// In case of failure, the returned error contains the stderr of the command.
+func run(cmd *exec.Cmd, stdin string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = strings.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && stderr.Len() == 0 && stdout.Len() == 0 {
+			// Lookup tools exit with an error and no output when the secret is missing
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// runSecurityInteractive executes the passed command in the interactive mode of 'security'.
+// The interactive mode exits successfully even if the command fails,
+// so the error is detected from stderr.
+func runSecurityInteractive(command string) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(command)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		return errors.New(msg)
+	}
+	return nil
+}
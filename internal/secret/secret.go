@@ -0,0 +1,61 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package secret
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// Plaintext stores the secret in clear text inside the credentials file.
+	Plaintext = "plaintext"
+	// Keyring stores the secret in the keyring of the operating system.
+	Keyring = "keyring"
+	// EncryptedFile stores the secret in a passphrase-encrypted file.
+	EncryptedFile = "encrypted-file"
+
+	// service is the name under which secrets are saved in the stores.
+	service = "arduino-cloud-cli"
+)
+
+// ErrNotFound is returned when a secret is not present in a store.
+var ErrNotFound = errors.New("secret not found")
+
+// Store can save and retrieve client secrets outside of the credentials file.
+// Secrets are identified by a key, which is the client ID they belong to.
+type Store interface {
+	Get(key string) (string, error)
+	Set(key, secret string) error
+	Delete(key string) error
+}
+
+// Stores returns the names of the supported secret stores.
+func Stores() []string {
+	return []string{Plaintext, Keyring, EncryptedFile}
+}
+
+// ValidateStore checks that the passed name is a supported secret store.
+func ValidateStore(name string) error {
+	for _, s := range Stores() {
+		if name == s {
+			return nil
+		}
+	}
+	return fmt.Errorf("secret store '%s' not valid, can be one of %v", name, Stores())
+}
@@ -19,18 +19,21 @@ package storageapi
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 
 	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/arduino/arduino-cloud-cli/internal/httpclient"
 	"github.com/arduino/arduino-cloud-cli/internal/iot"
 	"github.com/gofrs/uuid"
 	"github.com/sirupsen/logrus"
@@ -46,20 +49,40 @@ type StorageApiClient struct {
 	organization string
 }
 
-func getArduinoAPIBaseURL() string {
-	baseURL := "https://api-media.arduino.cc"
-	if url := os.Getenv("IOT_API_MEDIA_URL"); url != "" {
-		baseURL = url
+// mediaHosts maps the hosts of Arduino Cloud API
+// to the hosts of the corresponding media APIs.
+var mediaHosts = map[string]string{
+	"api2.arduino.cc":    "api-media.arduino.cc",
+	"api-dev.arduino.cc": "api-media.oniudra.cc",
+	"api2.oniudra.cc":    "api-media.oniudra.cc",
+}
+
+// getArduinoAPIBaseURLFor returns the base URL of the media API to be used
+// with the passed credentials. The IOT_API_MEDIA_URL environment variable
+// has the highest priority, otherwise the URL is derived from the base URL
+// of Arduino Cloud API. Unknown hosts, like local mock servers, are expected
+// to serve the media API too.
+func getArduinoAPIBaseURLFor(credentials *config.Credentials) string {
+	if mediaURL := os.Getenv("IOT_API_MEDIA_URL"); mediaURL != "" {
+		return mediaURL
+	}
+	baseURL := iot.GetArduinoAPIBaseURLFor(credentials)
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return baseURL
+	}
+	if host, ok := mediaHosts[u.Host]; ok {
+		return "https://" + host
 	}
 	return baseURL
 }
 
 func NewClient(credentials *config.Credentials) *StorageApiClient {
-	host := getArduinoAPIBaseURL()
-	iothost := iot.GetArduinoAPIBaseURL()
+	host := getArduinoAPIBaseURLFor(credentials)
+	iothost := iot.GetArduinoAPIBaseURLFor(credentials)
 	tokenSource := iot.NewUserTokenSource(credentials.Client, credentials.Secret, iothost, credentials.Organization)
 	return &StorageApiClient{
-		client:       &http.Client{},
+		client:       httpclient.New(),
 		src:          tokenSource,
 		host:         host,
 		organization: credentials.Organization,
@@ -94,7 +117,9 @@ func (c *StorageApiClient) performMultipartRequest(endpoint, method, token, file
 		return nil, err
 	}
 
-	req, err := http.NewRequest(method, endpoint, &buffer)
+	// The upload time depends on the file size, so the global timeout doesn't apply
+	ctx := httpclient.WithAttemptTimeout(context.Background(), 0)
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, &buffer)
 	if err != nil {
 		return nil, err
 	}
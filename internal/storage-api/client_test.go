@@ -0,0 +1,38 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package storageapi
+
+import (
+	"testing"
+
+	"github.com/arduino/arduino-cloud-cli/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetArduinoAPIBaseURLFor(t *testing.T) {
+	t.Setenv("IOT_API_URL", "")
+	t.Setenv("IOT_API_MEDIA_URL", "")
+	assert.Equal(t, "https://api-media.arduino.cc", getArduinoAPIBaseURLFor(&config.Credentials{}))
+	assert.Equal(t, "https://api-media.oniudra.cc", getArduinoAPIBaseURLFor(&config.Credentials{BaseURL: "https://api2.oniudra.cc/"}))
+	assert.Equal(t, "http://127.0.0.1:8080", getArduinoAPIBaseURLFor(&config.Credentials{BaseURL: "http://127.0.0.1:8080"}))
+
+	t.Setenv("IOT_API_URL", "http://127.0.0.1:9090")
+	assert.Equal(t, "http://127.0.0.1:9090", getArduinoAPIBaseURLFor(&config.Credentials{BaseURL: "https://api2.oniudra.cc"}))
+
+	t.Setenv("IOT_API_MEDIA_URL", "https://media.example.com")
+	assert.Equal(t, "https://media.example.com", getArduinoAPIBaseURLFor(&config.Credentials{BaseURL: "https://api2.oniudra.cc"}))
+}
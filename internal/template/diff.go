@@ -0,0 +1,464 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	iotclient "github.com/arduino/iot-client-go/v3"
+)
+
+// Operations of a Change.
+const (
+	OpAdd    = "+"
+	OpRemove = "-"
+	OpChange = "~"
+)
+
+// Change is a difference between a template and a resource on the cloud.
+type Change struct {
+	Op   string `json:"op"`
+	Path string `json:"path"` // Changed element, e.g. "variable temperature type"
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+}
+
+func (c Change) String() string {
+	switch {
+	case c.Op == OpChange:
+		return fmt.Sprintf("%s %s: %s -> %s", c.Op, c.Path, c.From, c.To)
+	case c.Op == OpAdd && c.To != "":
+		return fmt.Sprintf("%s %s: %s", c.Op, c.Path, c.To)
+	case c.Op == OpRemove && c.From != "":
+		return fmt.Sprintf("%s %s: %s", c.Op, c.Path, c.From)
+	}
+	return c.Op + " " + c.Path
+}
+
+// ThingDiff contains the differences between a thing template and a thing.
+// Besides the list of changes, it contains the operations
+// needed to align the thing to the template.
+type ThingDiff struct {
+	Changes []Change
+
+	Name            *string                       // New name of the thing, if changed
+	Timezone        *string                       // New timezone of the thing, if changed
+	CreateVariables []iotclient.Property          // Variables missing from the thing
+	UpdateVariables map[string]iotclient.Property // Changed variables, keyed by property id
+	DeleteVariables []string                      // Ids of the properties missing from the template
+	SetTags         map[string]string             // Tags missing from the thing or with a different value
+	DeleteTags      []string                      // Tags missing from the template
+}
+
+// Empty checks if the thing matches the template.
+func (d *ThingDiff) Empty() bool {
+	return len(d.Changes) == 0
+}
+
+// DiffThing compares a thing template, loaded with LoadThing, with a thing.
+// The thing is normalised with FromThing, so only the fields
+// contained in extracted templates are compared.
+// Variables are matched by variable name; optional fields
+// like timezone and update parameter are compared only if set in the template.
+func DiffThing(want *iotclient.ThingCreate, got *iotclient.ArduinoThing) (*ThingDiff, error) {
+	// Normalise the thing as if it was loaded from an extracted template
+	extracted, err := json.Marshal(FromThing(got))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", "extracting thing template", err)
+	}
+	var template map[string]interface{}
+	if err := json.Unmarshal(extracted, &template); err != nil {
+		return nil, fmt.Errorf("%s: %w", "extracting thing template", err)
+	}
+	current, err := thingFromTemplate(template)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &ThingDiff{
+		UpdateVariables: make(map[string]iotclient.Property),
+		SetTags:         make(map[string]string),
+	}
+
+	if want.Name != nil && *want.Name != got.Name {
+		diff.Name = want.Name
+		diff.Changes = append(diff.Changes, Change{Op: OpChange, Path: "name", From: got.Name, To: *want.Name})
+	}
+	if want.Timezone != nil && *want.Timezone != got.Timezone {
+		diff.Timezone = want.Timezone
+		diff.Changes = append(diff.Changes, Change{Op: OpChange, Path: "timezone", From: got.Timezone, To: *want.Timezone})
+	}
+
+	props := make(map[string]iotclient.Property, len(current.Properties))
+	ids := make(map[string]string, len(got.Properties))
+	for i, p := range current.Properties {
+		key := propertyKey(p.Name, p.VariableName)
+		props[key] = p
+		ids[key] = got.Properties[i].Id
+	}
+	wanted := make(map[string]bool, len(want.Properties))
+	for _, p := range want.Properties {
+		key := propertyKey(p.Name, p.VariableName)
+		wanted[key] = true
+		cur, ok := props[key]
+		if !ok {
+			diff.CreateVariables = append(diff.CreateVariables, p)
+			diff.Changes = append(diff.Changes, Change{Op: OpAdd, Path: "variable " + key, To: p.Type})
+			continue
+		}
+		changes := diffProperty(key, p, cur)
+		if len(changes) > 0 {
+			diff.UpdateVariables[ids[key]] = p
+			diff.Changes = append(diff.Changes, changes...)
+		}
+	}
+	for _, key := range sortedKeys(props) {
+		if !wanted[key] {
+			diff.DeleteVariables = append(diff.DeleteVariables, ids[key])
+			diff.Changes = append(diff.Changes, Change{Op: OpRemove, Path: "variable " + key, From: props[key].Type})
+		}
+	}
+
+	wantTags := make(map[string]string, len(want.Tags))
+	for _, t := range want.Tags {
+		wantTags[t.Key] = t.Value
+	}
+	gotTags := make(map[string]string, len(current.Tags))
+	for _, t := range current.Tags {
+		gotTags[t.Key] = t.Value
+	}
+	for _, key := range sortedKeys(wantTags) {
+		cur, ok := gotTags[key]
+		switch {
+		case !ok:
+			diff.SetTags[key] = wantTags[key]
+			diff.Changes = append(diff.Changes, Change{Op: OpAdd, Path: "tag " + key, To: wantTags[key]})
+		case cur != wantTags[key]:
+			diff.SetTags[key] = wantTags[key]
+			diff.Changes = append(diff.Changes, Change{Op: OpChange, Path: "tag " + key, From: cur, To: wantTags[key]})
+		}
+	}
+	for _, key := range sortedKeys(gotTags) {
+		if _, ok := wantTags[key]; !ok {
+			diff.DeleteTags = append(diff.DeleteTags, key)
+			diff.Changes = append(diff.Changes, Change{Op: OpRemove, Path: "tag " + key, From: gotTags[key]})
+		}
+	}
+
+	return diff, nil
+}
+
+// diffProperty compares the fields of two properties.
+func diffProperty(key string, want, got iotclient.Property) []Change {
+	var changes []Change
+	compare := func(field, from, to string) {
+		if from != to {
+			changes = append(changes, Change{Op: OpChange, Path: "variable " + key + " " + field, From: from, To: to})
+		}
+	}
+	compare("name", got.Name, want.Name)
+	compare("type", got.Type, want.Type)
+	compare("permission", got.Permission, want.Permission)
+	compare("update_strategy", got.UpdateStrategy, want.UpdateStrategy)
+	if want.UpdateParameter != nil {
+		compare("update_parameter", formatFloat(got.UpdateParameter), formatFloat(want.UpdateParameter))
+	}
+	return changes
+}
+
+// DashboardDiff contains the differences between a dashboard template and a dashboard.
+type DashboardDiff struct {
+	Changes []Change
+}
+
+// Empty checks if the dashboard matches the template.
+func (d *DashboardDiff) Empty() bool {
+	return len(d.Changes) == 0
+}
+
+// widgetState is the normalised form of a widget used for comparisons.
+type widgetState struct {
+	key     string // Name and type of the widget
+	id      string
+	layout  string
+	links   []string
+	options map[string]interface{}
+}
+
+// DiffDashboard compares a dashboard, loaded with LoadDashboard, with a dashboard.
+// Widgets are matched by name and type, see matchWidgets; their position, size
+// and linked variables are compared. Widget options are not compared, since the cloud fills them with default values.
+func DiffDashboard(want *iotclient.Dashboardv3, got *iotclient.ArduinoDashboardv3) *DashboardDiff {
+	diff := &DashboardDiff{}
+	if want.Name != nil && *want.Name != got.Name {
+		diff.Changes = append(diff.Changes, Change{Op: OpChange, Path: "name", From: got.Name, To: *want.Name})
+	}
+
+	wantWidgets := make([]widgetState, 0, len(want.Widgets))
+	for _, w := range want.Widgets {
+		wantWidgets = append(wantWidgets, widgetState{
+			key:    widgetKey(w.Name, w.Type),
+			id:     w.Id,
+			layout: widgetLayout(w.X, w.Y, w.Width, w.Height),
+			links:  w.Variables,
+		})
+	}
+	gotWidgets := make([]widgetState, 0, len(got.Widgets))
+	for _, w := range got.Widgets {
+		var links []string
+		for _, v := range w.Variables {
+			links = append(links, v.Id)
+		}
+		gotWidgets = append(gotWidgets, widgetState{
+			key:    widgetKey(w.Name, w.Type),
+			id:     w.Id,
+			layout: widgetLayout(w.X, w.Y, w.Width, w.Height),
+			links:  links,
+		})
+	}
+	diff.Changes = append(diff.Changes, diffWidgets(matchWidgets(wantWidgets, gotWidgets))...)
+	return diff
+}
+
+// DiffDashboardTemplate compares two dashboard templates: a template file,
+// loaded with LoadDashboardTemplate, and a template extracted with FromDashboard.
+// The override parameter maps the thing ids of the template file to the ones of the extracted template.
+// Widgets are matched by name and type, see matchWidgets; their position, size,
+// linked variables and options are compared. Options are filtered as when a dashboard is created.
+func DiffDashboardTemplate(want, got *DashboardTemplate, override map[string]string) *DashboardDiff {
+	diff := &DashboardDiff{}
+	if want.Name != "" && want.Name != got.Name {
+		diff.Changes = append(diff.Changes, Change{Op: OpChange, Path: "name", From: got.Name, To: want.Name})
+	}
+	diff.Changes = append(diff.Changes, diffWidgets(matchWidgets(widgetStates(want, override), widgetStates(got, nil)))...)
+	return diff
+}
+
+func widgetStates(t *DashboardTemplate, override map[string]string) []widgetState {
+	widgets := make([]widgetState, 0, len(t.Widgets))
+	for _, w := range t.Widgets {
+		name := w.Name
+		state := widgetState{
+			key:     widgetKey(&name, w.Type),
+			id:      w.Id,
+			layout:  widgetLayout(int64(w.X), int64(w.Y), int64(w.Width), int64(w.Height)),
+			options: make(map[string]interface{}, len(w.Options)),
+		}
+		if w.XMobile != nil || w.YMobile != nil || w.WidthMobile != nil || w.HeightMobile != nil {
+			state.layout += " mobile:" + widgetLayout(intValue(w.XMobile), intValue(w.YMobile), intValue(w.WidthMobile), intValue(w.HeightMobile))
+		}
+		for _, v := range w.Variables {
+			thing := v.ThingID
+			if id, ok := override[thing]; ok {
+				thing = id
+			}
+			state.links = append(state.links, thing+":"+v.VariableID)
+		}
+		for k, v := range w.Options {
+			state.options[k] = v
+		}
+		filterWidgetOptions(state.options)
+		widgets = append(widgets, state)
+	}
+	return widgets
+}
+
+// matchWidgets pairs the wanted widgets with the current ones, keying
+// the matching widgets with the same string. Widgets are matched by name
+// and type. Widgets sharing both are matched by id, when the same id is
+// found on both sides, and then in order of appearance; their keys are
+// numbered by position to tell them apart.
+func matchWidgets(want, got []widgetState) (map[string]widgetState, map[string]widgetState) {
+	wantGroups := groupWidgets(want)
+	gotGroups := groupWidgets(got)
+	wantKeyed := make(map[string]widgetState, len(want))
+	gotKeyed := make(map[string]widgetState, len(got))
+	// Wanted widgets missing from the current ones are matched with none
+	for key := range wantGroups {
+		if _, ok := gotGroups[key]; !ok {
+			gotGroups[key] = nil
+		}
+	}
+	for key, g := range gotGroups {
+		w := wantGroups[key]
+		if len(w) <= 1 && len(g) <= 1 {
+			if len(w) == 1 {
+				wantKeyed[key] = w[0]
+			}
+			if len(g) == 1 {
+				gotKeyed[key] = g[0]
+			}
+			continue
+		}
+
+		// pair maps the index of each wanted widget to the matching current one, -1 if none
+		pair := make([]int, len(w))
+		matched := make([]bool, len(g))
+		for i := range w {
+			pair[i] = -1
+			if w[i].id == "" {
+				continue
+			}
+			for j := range g {
+				if !matched[j] && g[j].id == w[i].id {
+					pair[i] = j
+					matched[j] = true
+					break
+				}
+			}
+		}
+		next := 0
+		for i := range w {
+			if pair[i] >= 0 {
+				continue
+			}
+			for next < len(g) && matched[next] {
+				next++
+			}
+			if next < len(g) {
+				pair[i] = next
+				matched[next] = true
+			}
+		}
+
+		for i := range w {
+			numbered := fmt.Sprintf("%s #%d", key, i+1)
+			wantKeyed[numbered] = w[i]
+			if pair[i] >= 0 {
+				gotKeyed[numbered] = g[pair[i]]
+			}
+		}
+		n := len(w)
+		for j := range g {
+			if !matched[j] {
+				n++
+				gotKeyed[fmt.Sprintf("%s #%d", key, n)] = g[j]
+			}
+		}
+	}
+	return wantKeyed, gotKeyed
+}
+
+// groupWidgets groups the widgets by key, keeping their order.
+func groupWidgets(widgets []widgetState) map[string][]widgetState {
+	groups := make(map[string][]widgetState)
+	for _, w := range widgets {
+		groups[w.key] = append(groups[w.key], w)
+	}
+	return groups
+}
+
+func diffWidgets(want, got map[string]widgetState) []Change {
+	var changes []Change
+	for _, key := range sortedKeys(want) {
+		w := want[key]
+		g, ok := got[key]
+		if !ok {
+			changes = append(changes, Change{Op: OpAdd, Path: "widget " + key})
+			continue
+		}
+		if w.layout != g.layout {
+			changes = append(changes, Change{Op: OpChange, Path: "widget " + key + " position", From: g.layout, To: w.layout})
+		}
+		if from, to := formatLinks(g.links), formatLinks(w.links); from != to {
+			changes = append(changes, Change{Op: OpChange, Path: "widget " + key + " variables", From: from, To: to})
+		}
+		for _, opt := range sortedKeys(w.options) {
+			to := formatOption(w.options[opt])
+			from, ok := g.options[opt]
+			switch {
+			case !ok:
+				changes = append(changes, Change{Op: OpAdd, Path: "widget " + key + " option " + opt, To: to})
+			case formatOption(from) != to:
+				changes = append(changes, Change{Op: OpChange, Path: "widget " + key + " option " + opt, From: formatOption(from), To: to})
+			}
+		}
+		for _, opt := range sortedKeys(g.options) {
+			if _, ok := w.options[opt]; !ok {
+				changes = append(changes, Change{Op: OpRemove, Path: "widget " + key + " option " + opt, From: formatOption(g.options[opt])})
+			}
+		}
+	}
+	for _, key := range sortedKeys(got) {
+		if _, ok := want[key]; !ok {
+			changes = append(changes, Change{Op: OpRemove, Path: "widget " + key})
+		}
+	}
+	return changes
+}
+
+func propertyKey(name string, variableName *string) string {
+	if variableName != nil && *variableName != "" {
+		return *variableName
+	}
+	return name
+}
+
+func widgetKey(name *string, widgetType string) string {
+	if name == nil {
+		return "(" + widgetType + ")"
+	}
+	return *name + " (" + widgetType + ")"
+}
+
+func widgetLayout(x, y, width, height int64) string {
+	return fmt.Sprintf("x=%d y=%d width=%d height=%d", x, y, width, height)
+}
+
+func formatLinks(links []string) string {
+	sorted := append([]string(nil), links...)
+	sort.Strings(sorted)
+	return "[" + strings.Join(sorted, ",") + "]"
+}
+
+// formatOption converts an option value to json, so that values
+// decoded from json and yaml can be compared.
+func formatOption(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+func intValue[T int | int64](i *T) int64 {
+	if i == nil {
+		return 0
+	}
+	return int64(*i)
+}
+
+func formatFloat(f *float64) string {
+	if f == nil {
+		return "0"
+	}
+	return strconv.FormatFloat(*f, 'g', -1, 64)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,247 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package template
+
+import (
+	"testing"
+
+	iotclient "github.com/arduino/iot-client-go/v3"
+	"github.com/google/go-cmp/cmp"
+)
+
+func toFloat64Pointer(f float64) *float64 {
+	return &f
+}
+
+func TestDiffThing(t *testing.T) {
+	want := &iotclient.ThingCreate{
+		Name: toStringPointer("thing"),
+		Properties: []iotclient.Property{
+			{Name: "temperature", VariableName: toStringPointer("temperature"), Type: "TEMPERATURE_C", Permission: "READ_ONLY", UpdateStrategy: "ON_CHANGE"},
+			{Name: "humidity", VariableName: toStringPointer("humidity"), Type: "HUMIDITY", Permission: "READ_ONLY", UpdateStrategy: "ON_CHANGE", UpdateParameter: toFloat64Pointer(0)},
+			{Name: "led", VariableName: toStringPointer("led"), Type: "STATUS", Permission: "READ_WRITE", UpdateStrategy: "ON_CHANGE"},
+		},
+		Tags: []iotclient.Tag{{Key: "room", Value: "kitchen"}, {Key: "floor", Value: "1"}},
+	}
+
+	tests := []struct {
+		name string
+		got  *iotclient.ArduinoThing
+		want []Change
+	}{
+		{
+			name: "no-changes",
+			got: &iotclient.ArduinoThing{
+				Name: "thing",
+				Properties: []iotclient.ArduinoProperty{
+					{Id: "t-id", Name: "temperature", VariableName: toStringPointer("temperature"), Type: "TEMPERATURE_C", Permission: "READ_ONLY", UpdateStrategy: "ON_CHANGE", MaxValue: toFloat64Pointer(100)},
+					{Id: "h-id", Name: "humidity", VariableName: toStringPointer("humidity"), Type: "HUMIDITY", Permission: "READ_ONLY", UpdateStrategy: "ON_CHANGE"},
+					{Id: "l-id", Name: "led", VariableName: toStringPointer("led"), Type: "STATUS", Permission: "READ_WRITE", UpdateStrategy: "ON_CHANGE"},
+				},
+				Tags: map[string]interface{}{"room": "kitchen", "floor": "1"},
+			},
+		},
+		{
+			name: "drift",
+			got: &iotclient.ArduinoThing{
+				Name: "old-thing",
+				Properties: []iotclient.ArduinoProperty{
+					{Id: "t-id", Name: "temperature", VariableName: toStringPointer("temperature"), Type: "TEMPERATURE_F", Permission: "READ_ONLY", UpdateStrategy: "ON_CHANGE"},
+					{Id: "h-id", Name: "humidity", VariableName: toStringPointer("humidity"), Type: "HUMIDITY", Permission: "READ_WRITE", UpdateStrategy: "ON_CHANGE", UpdateParameter: toFloat64Pointer(10)},
+					{Id: "x-id", Name: "extra", VariableName: toStringPointer("extra"), Type: "INT", Permission: "READ_WRITE", UpdateStrategy: "ON_CHANGE"},
+				},
+				Tags: map[string]interface{}{"room": "bathroom", "owner": "me"},
+			},
+			want: []Change{
+				{Op: OpChange, Path: "name", From: "old-thing", To: "thing"},
+				{Op: OpChange, Path: "variable temperature type", From: "TEMPERATURE_F", To: "TEMPERATURE_C"},
+				{Op: OpChange, Path: "variable humidity permission", From: "READ_WRITE", To: "READ_ONLY"},
+				{Op: OpChange, Path: "variable humidity update_parameter", From: "10", To: "0"},
+				{Op: OpAdd, Path: "variable led", To: "STATUS"},
+				{Op: OpRemove, Path: "variable extra", From: "INT"},
+				{Op: OpAdd, Path: "tag floor", To: "1"},
+				{Op: OpChange, Path: "tag room", From: "bathroom", To: "kitchen"},
+				{Op: OpRemove, Path: "tag owner", From: "me"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, err := DiffThing(want, tt.got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(diff.Changes, tt.want) {
+				t.Errorf("Wrong changes, diff:\n%s", cmp.Diff(tt.want, diff.Changes))
+			}
+			if diff.Empty() != (len(tt.want) == 0) {
+				t.Errorf("Expected Empty() to be %v", len(tt.want) == 0)
+			}
+		})
+	}
+
+	diff, err := DiffThing(want, tests[1].got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.CreateVariables) != 1 || diff.CreateVariables[0].Name != "led" {
+		t.Errorf("Expected variable led to be created, got %v", diff.CreateVariables)
+	}
+	if _, ok := diff.UpdateVariables["t-id"]; !ok || len(diff.UpdateVariables) != 2 {
+		t.Errorf("Expected variables temperature and humidity to be updated, got %v", diff.UpdateVariables)
+	}
+	if !cmp.Equal(diff.DeleteVariables, []string{"x-id"}) {
+		t.Errorf("Expected variable extra to be deleted, got %v", diff.DeleteVariables)
+	}
+	if !cmp.Equal(diff.SetTags, map[string]string{"floor": "1", "room": "kitchen"}) {
+		t.Errorf("Wrong tags to set: %v", diff.SetTags)
+	}
+}
+
+func TestDiffDashboard(t *testing.T) {
+	want := &iotclient.Dashboardv3{
+		Name: toStringPointer("dashboard"),
+		Widgets: []iotclient.Widgetv3{
+			{Name: toStringPointer("switch"), Type: "Switch", X: 0, Y: 0, Width: 2, Height: 2, Variables: []string{"switchy-id"}},
+			{Name: toStringPointer("chart"), Type: "Chart", X: 2, Y: 0, Width: 4, Height: 2, Variables: []string{"relay-id"}},
+		},
+	}
+	got := &iotclient.ArduinoDashboardv3{
+		Name: "dashboard",
+		Widgets: []iotclient.ArduinoWidgetv3{
+			{Name: toStringPointer("switch"), Type: "Switch", X: 0, Y: 0, Width: 2, Height: 2, Variables: []iotclient.ArduinoLinkedvariable{{Id: "switchy-id"}}},
+			{Name: toStringPointer("chart"), Type: "Chart", X: 2, Y: 2, Width: 4, Height: 2, Variables: []iotclient.ArduinoLinkedvariable{{Id: "relay-id"}}},
+			{Name: toStringPointer("old"), Type: "Value"},
+		},
+	}
+
+	diff := DiffDashboard(want, got)
+	expected := []Change{
+		{Op: OpChange, Path: "widget chart (Chart) position", From: "x=2 y=2 width=4 height=2", To: "x=2 y=0 width=4 height=2"},
+		{Op: OpRemove, Path: "widget old (Value)"},
+	}
+	if !cmp.Equal(diff.Changes, expected) {
+		t.Errorf("Wrong changes, diff:\n%s", cmp.Diff(expected, diff.Changes))
+	}
+}
+
+func TestDiffDashboardDuplicates(t *testing.T) {
+	value := func(id string, x int64) iotclient.Widgetv3 {
+		return iotclient.Widgetv3{Id: id, Name: toStringPointer("value"), Type: "Value", X: x, Width: 2, Height: 2}
+	}
+	currentValue := func(id string, x int64) iotclient.ArduinoWidgetv3 {
+		return iotclient.ArduinoWidgetv3{Id: id, Name: toStringPointer("value"), Type: "Value", X: x, Width: 2, Height: 2}
+	}
+	want := &iotclient.Dashboardv3{
+		Widgets: []iotclient.Widgetv3{value("template-1", 0), value("widget-2", 2), value("", 4)},
+	}
+	got := &iotclient.ArduinoDashboardv3{
+		// The widget with a matching id is matched first, the others in order
+		Widgets: []iotclient.ArduinoWidgetv3{currentValue("widget-2", 2), currentValue("widget-1", 0), currentValue("widget-3", 5), currentValue("widget-4", 6)},
+	}
+
+	diff := DiffDashboard(want, got)
+	expected := []Change{
+		{Op: OpChange, Path: "widget value (Value) #3 position", From: "x=5 y=0 width=2 height=2", To: "x=4 y=0 width=2 height=2"},
+		{Op: OpRemove, Path: "widget value (Value) #4"},
+	}
+	if !cmp.Equal(diff.Changes, expected) {
+		t.Errorf("Wrong changes, diff:\n%s", cmp.Diff(expected, diff.Changes))
+	}
+
+	// Missing duplicates are added
+	want.Widgets = append(want.Widgets, value("", 8))
+	got.Widgets = got.Widgets[:3]
+	diff = DiffDashboard(want, got)
+	expected = []Change{
+		{Op: OpChange, Path: "widget value (Value) #3 position", From: "x=5 y=0 width=2 height=2", To: "x=4 y=0 width=2 height=2"},
+		{Op: OpAdd, Path: "widget value (Value) #4"},
+	}
+	if !cmp.Equal(diff.Changes, expected) {
+		t.Errorf("Wrong changes, diff:\n%s", cmp.Diff(expected, diff.Changes))
+	}
+}
+
+func TestDiffDashboardTemplate(t *testing.T) {
+	want, err := LoadDashboardTemplate("testdata/home-security-dashboard.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		got      *DashboardTemplate
+		override map[string]string
+		want     []Change
+	}{
+		{
+			name: "no-changes",
+			got: &DashboardTemplate{
+				Name: "Home Security Alarm",
+				Widgets: []WidgetTemplate{
+					{Type: "Messenger", Name: "message_update", Variables: []VariableTemplate{{ThingID: "home-security-alarm", VariableID: "message_update"}}},
+					// Blacklisted options are ignored
+					{Type: "Switch", Name: "light_alarm", Variables: []VariableTemplate{{ThingID: "home-security-alarm", VariableID: "light_alarm"}},
+						Options: map[string]interface{}{"showLabels": true, "thingId": "some-id"}},
+				},
+			},
+		},
+		{
+			name: "override",
+			got: &DashboardTemplate{
+				Name: "Home Security Alarm",
+				Widgets: []WidgetTemplate{
+					{Type: "Messenger", Name: "message_update", Variables: []VariableTemplate{{ThingID: "alarm", VariableID: "message_update"}}},
+					{Type: "Switch", Name: "light_alarm", Variables: []VariableTemplate{{ThingID: "alarm", VariableID: "light_alarm"}},
+						Options: map[string]interface{}{"showLabels": true}},
+				},
+			},
+			override: map[string]string{"home-security-alarm": "alarm"},
+		},
+		{
+			name: "drift",
+			got: &DashboardTemplate{
+				Name: "Home Alarm",
+				Widgets: []WidgetTemplate{
+					{Type: "Switch", Name: "light_alarm", X: 2, Variables: []VariableTemplate{{ThingID: "home-security-alarm", VariableID: "light"}},
+						Options: map[string]interface{}{"showLabels": false, "color": "red"}},
+					{Type: "Value", Name: "temperature"},
+				},
+			},
+			want: []Change{
+				{Op: OpChange, Path: "name", From: "Home Alarm", To: "Home Security Alarm"},
+				{Op: OpChange, Path: "widget light_alarm (Switch) position", From: "x=2 y=0 width=0 height=0", To: "x=0 y=0 width=0 height=0"},
+				{Op: OpChange, Path: "widget light_alarm (Switch) variables", From: "[home-security-alarm:light]", To: "[home-security-alarm:light_alarm]"},
+				{Op: OpChange, Path: "widget light_alarm (Switch) option showLabels", From: "false", To: "true"},
+				{Op: OpRemove, Path: "widget light_alarm (Switch) option color", From: `"red"`},
+				{Op: OpAdd, Path: "widget message_update (Messenger)"},
+				{Op: OpRemove, Path: "widget temperature (Value)"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := DiffDashboardTemplate(want, tt.got, tt.override)
+			if !cmp.Equal(diff.Changes, tt.want) {
+				t.Errorf("Wrong changes, diff:\n%s", cmp.Diff(tt.want, diff.Changes))
+			}
+		})
+	}
+}
@@ -64,7 +64,11 @@ func LoadThing(file string) (*iotclient.ThingCreate, error) {
 	if err != nil {
 		return nil, err
 	}
+	return thingFromTemplate(template)
+}
 
+// thingFromTemplate converts a generic thing template into a thing structure.
+func thingFromTemplate(template map[string]interface{}) (*iotclient.ThingCreate, error) {
 	// Adapt thing template to thing structure
 	delete(template, "id")
 	template["properties"] = template["variables"]
@@ -109,6 +113,48 @@ func LoadThing(file string) (*iotclient.ThingCreate, error) {
 	return thing, nil
 }
 
+// LoadVariables loads a list of variables from a file.
+// The file can contain either a list of variables or a thing
+// template, in which case the variables of the template are loaded.
+func LoadVariables(file string) ([]iotclient.Property, error) {
+	var template interface{}
+	if err := loadTemplate(file, &template); err != nil {
+		return nil, err
+	}
+	if thing, ok := template.(map[string]interface{}); ok {
+		template = thing["variables"]
+	}
+	if _, ok := template.([]interface{}); !ok {
+		return nil, errors.New("reading variables file: a list of variables or a thing template is expected")
+	}
+
+	// Convert template into properties exploiting json marshalling/unmarshalling
+	t, err := json.Marshal(template)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", "extracting variables", err)
+	}
+	var props []iotclient.Property
+	if err = json.Unmarshal(t, &props); err != nil {
+		return nil, fmt.Errorf("%s: %w", "creating variables from file", err)
+	}
+	for i, p := range props {
+		if p.Name == "" || p.Type == "" {
+			return nil, fmt.Errorf("variable %d: name and type are required", i+1)
+		}
+	}
+	return props, nil
+}
+
+// LoadDashboardTemplate loads a dashboard template file, without resolving
+// its thing and variable references.
+func LoadDashboardTemplate(file string) (*DashboardTemplate, error) {
+	template := &DashboardTemplate{}
+	if err := loadTemplate(file, template); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
 // LoadDashboard loads a dashboard from a dashboard template file.
 // It applies the thing overrides specified by the override parameter.
 // It requires a ThingFetcher to retrieve the actual variable ids.
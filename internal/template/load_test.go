@@ -24,6 +24,7 @@ import (
 	iotclient "github.com/arduino/iot-client-go/v3"
 	"github.com/gofrs/uuid"
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
 const (
@@ -253,3 +254,40 @@ func TestLoadDashboard(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadVariables(t *testing.T) {
+	persist := true
+	want := []iotclient.Property{
+		{Name: "temperature", Type: "TEMPERATURE_C", Permission: "READ_ONLY", UpdateStrategy: "ON_CHANGE", VariableName: toStringPointer("temperature")},
+		{Name: "led", Type: "STATUS", Permission: "READ_WRITE", UpdateStrategy: "ON_CHANGE", Persist: &persist},
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		wantErr bool
+	}{
+		{name: "list of variables", file: "testdata/variables.yaml"},
+		{name: "thing template", file: "testdata/thing-variables.json"},
+		{name: "missing type", file: "testdata/variables-no-type.yaml", wantErr: true},
+		{name: "dashboard template", file: "testdata/home-security-dashboard.yaml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadVariables(tt.file)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(got, want, cmpopts.EquateEmpty()) {
+				t.Errorf("Wrong variables loaded, diff:\n%s", cmp.Diff(want, got, cmpopts.EquateEmpty()))
+			}
+		})
+	}
+}
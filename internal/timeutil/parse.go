@@ -0,0 +1,62 @@
+// This file is part of arduino-cloud-cli.
+//
+// Copyright (C) ARDUINO SRL (http://www.arduino.cc)
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package timeutil
+
+import (
+	"fmt"
+	"time"
+)
+
+// dateLayout is the layout of dates accepted in place of full timestamps.
+const dateLayout = "2006-01-02"
+
+// ParseTime parses a time passed by the user,
+// either as an RFC3339 timestamp or as a YYYY-MM-DD date (UTC).
+func ParseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(dateLayout, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("time '%s' not valid, use RFC3339 format or YYYY-MM-DD", s)
+	}
+	return t, nil
+}
+
+// ParseRange parses the bounds of a time range passed by the user.
+// Empty bounds default to the passed duration before the end
+// of the range and to now, respectively.
+func ParseRange(from, to string, defaultSpan time.Duration) (time.Time, time.Time, error) {
+	end := time.Now()
+	if to != "" {
+		t, err := ParseTime(to)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		end = t
+	}
+	start := end.Add(-defaultSpan)
+	if from != "" {
+		t, err := ParseTime(from)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		start = t
+	}
+	return start, end, nil
+}